@@ -0,0 +1,46 @@
+// commands/keys.go - ротация секрета подписи JWT
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// rotateKeysCommand выпускает новый секрет подписи токенов
+func rotateKeysCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "rotate-keys",
+		Usage: "выпустить новый секрет подписи JWT",
+		Description: "Выводит новые значения JWT_SECRET и JWT_PREVIOUS_SECRETS; они вступают в силу после перезапуска.\n" +
+			"Уже выданные токены остаются действительными до истечения срока. С флагом --compromised\n" +
+			"старый секрет не сохраняется, а сессии всех пользователей завершаются сразу.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "compromised", Usage: "секрет скомпрометирован: не принимать старые токены"},
+		},
+		Action: rotateKeys,
+	}
+}
+
+// rotateKeys выпускает секрет и выводит переменные окружения для развертывания
+func rotateKeys(c *cli.Context) error {
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	rotation, err := newAdminService(db, cfg).RotateSigningKey(c.Context, c.Bool("compromised"), cliMeta())
+	if err != nil {
+		return cli.Exit("ошибка ротации ключа: "+err.Error(), 1)
+	}
+
+	fmt.Printf("новый ключ подписи (kid %s). Обновите окружение и перезапустите сервис:\n\n", rotation.KeyID)
+	fmt.Printf("JWT_SECRET=%s\n", rotation.Secret)
+	fmt.Printf("JWT_PREVIOUS_SECRETS=%s\n", strings.Join(rotation.PreviousSecrets, ","))
+	if c.Bool("compromised") {
+		fmt.Printf("\nсессии завершены у %d пользователей\n", rotation.SessionsRevoked)
+	}
+	fmt.Println("\nнезавершенные ссылки и коды из писем после перезапуска станут недействительны")
+	return nil
+}
@@ -0,0 +1,140 @@
+// commands/migrate.go - применение, откат и создание миграций схемы
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"AuthApplications/config"
+	"AuthApplications/migrations"
+
+	"github.com/urfave/cli/v2"
+)
+
+// migrateCommand группа подкоманд управления схемой базы данных
+func migrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "управление схемой базы данных",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "up",
+				Usage:  "применить все непримененные миграции",
+				Action: migrateUp,
+			},
+			{
+				Name:      "down",
+				Usage:     "откатить последние миграции",
+				ArgsUsage: "[N]",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "steps", Aliases: []string{"n"}, Value: 1, Usage: "число откатываемых миграций"},
+				},
+				Action: migrateDown,
+			},
+			{
+				Name:   "status",
+				Usage:  "показать состояние миграций",
+				Action: migrateStatus,
+			},
+			{
+				Name:      "create",
+				Usage:     "создать пустую пару файлов в " + migrations.Dir,
+				ArgsUsage: "<имя>",
+				Action:    migrateCreate,
+			},
+		},
+	}
+}
+
+// newMigrator подключается к базе данных без проверки схемы
+func newMigrator() (migrations.Migrator, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := config.OpenDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return migrations.NewMigrator(db)
+}
+
+// migrateUp применяет непримененные миграции
+func migrateUp(c *cli.Context) error {
+	migrator, err := newMigrator()
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up()
+	for _, migration := range applied {
+		fmt.Printf("применена %04d_%s\n", migration.Version, migration.Name)
+	}
+	if err == nil && len(applied) == 0 {
+		fmt.Println("схема актуальна")
+	}
+	return err
+}
+
+// migrateDown откатывает последние миграции; число можно передать аргументом или флагом --steps
+func migrateDown(c *cli.Context) error {
+	steps := c.Int("steps")
+	if c.Args().Present() {
+		if _, err := fmt.Sscan(c.Args().First(), &steps); err != nil {
+			return cli.Exit(fmt.Sprintf("некорректное число миграций: %q", c.Args().First()), 1)
+		}
+	}
+	if steps < 1 {
+		return cli.Exit("число миграций должно быть положительным", 1)
+	}
+
+	migrator, err := newMigrator()
+	if err != nil {
+		return err
+	}
+
+	reverted, err := migrator.Down(steps)
+	for _, migration := range reverted {
+		fmt.Printf("откачена %04d_%s\n", migration.Version, migration.Name)
+	}
+	return err
+}
+
+// migrateStatus выводит состояние всех миграций
+func migrateStatus(c *cli.Context) error {
+	migrator, err := newMigrator()
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "-"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt)
+	}
+	return w.Flush()
+}
+
+// migrateCreate создает файлы новой миграции; подключение к базе данных не требуется
+func migrateCreate(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("использование: migrate create <имя>", 1)
+	}
+
+	upPath, downPath, err := migrations.Create(migrations.Dir, c.Args().First())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("создано:\n  %s\n  %s\n", upPath, downPath)
+	return nil
+}
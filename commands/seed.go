@@ -0,0 +1,98 @@
+// commands/seed.go - демонстрационные данные для локальной разработки
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"AuthApplications/dto"
+	"AuthApplications/mailer"
+	"AuthApplications/metrics"
+	"AuthApplications/notifier"
+	"AuthApplications/repositories"
+	"AuthApplications/services"
+
+	"github.com/urfave/cli/v2"
+	"gorm.io/gorm"
+)
+
+// seedPassword пароль демонстрационных пользователей
+const seedPassword = "password123"
+
+// seedUser демонстрационный пользователь и его книги
+type seedUser struct {
+	request dto.RegisterRequest
+	books   []dto.BookRequest
+}
+
+// seedUsers демонстрационные данные
+var seedUsers = []seedUser{
+	{
+		request: dto.RegisterRequest{Username: "alice", Email: "alice@example.com", FirstName: "Alice", LastName: "Smith"},
+		books: []dto.BookRequest{
+			{Title: "Основы Go", ISBN: "978-5-00000-001-1", Genre: "programming", Language: "ru", PublishYear: 2023, PageCount: 320},
+			{Title: "Практика PostgreSQL", ISBN: "978-5-00000-002-8", Genre: "programming", Language: "ru", PublishYear: 2022, PageCount: 410},
+		},
+	},
+	{
+		request: dto.RegisterRequest{Username: "bob", Email: "bob@example.com", FirstName: "Bob", LastName: "Jones"},
+		books: []dto.BookRequest{
+			{Title: "Северный ветер", ISBN: "978-5-00000-003-5", Genre: "fiction", Language: "ru", PublishYear: 2021, PageCount: 256},
+		},
+	},
+}
+
+// seedCommand заполняет базу демонстрационными пользователями и книгами
+func seedCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "seed",
+		Usage:  "создать демонстрационных пользователей и книги (пароль " + seedPassword + ")",
+		Action: seed,
+	}
+}
+
+// seed создает недостающих демонстрационных пользователей и их книги; повторный запуск ничего не меняет
+func seed(c *cli.Context) error {
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	userRepo := repositories.NewUserRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	txManager := repositories.NewTxManager(db)
+	auditService := services.NewAuditService(repositories.NewAuditRepository(db))
+	var notifications sync.WaitGroup
+	defer notifications.Wait()
+	loginHistoryService := services.NewLoginHistoryService(repositories.NewLoginEventRepository(db), notifier.New(cfg, mailer.New(cfg)), &notifications, slog.Default())
+	authService := services.NewAuthService(userRepo, repositories.NewTokenRepository(db), outboxRepo, txManager, auditService, loginHistoryService, metrics.NewNop(), slog.Default(), cfg)
+	bookService := services.NewBookService(repositories.NewBookRepository(db), outboxRepo, txManager)
+
+	for _, entry := range seedUsers {
+		if _, err := userRepo.FindByEmail(c.Context, entry.request.Email); err == nil {
+			fmt.Printf("пропущен %s: уже существует\n", entry.request.Email)
+			continue
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		request := entry.request
+		request.Password = seedPassword
+		user, err := authService.Register(c.Context, request, cliMeta())
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("ошибка создания %s: %v", request.Email, err), 1)
+		}
+
+		for _, book := range entry.books {
+			book.AuthorID = user.ID
+			if _, err := bookService.CreateBook(c.Context, book); err != nil {
+				return cli.Exit(fmt.Sprintf("ошибка создания книги %q: %v", book.Title, err), 1)
+			}
+		}
+		fmt.Printf("создан %s (%d книг)\n", user.Email, len(entry.books))
+	}
+
+	return nil
+}
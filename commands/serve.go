@@ -0,0 +1,224 @@
+// commands/serve.go - запуск HTTP сервера и фоновых обработчиков
+package commands
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"AuthApplications/config"
+	"AuthApplications/mailer"
+	"AuthApplications/metrics"
+	"AuthApplications/repositories"
+	"AuthApplications/routes"
+	"AuthApplications/services"
+	"AuthApplications/tracing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/urfave/cli/v2"
+	"gorm.io/gorm"
+)
+
+// serveCommand запускает HTTP сервер (подкоманда по умолчанию)
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "serve",
+		Usage:  "запустить HTTP сервер и фоновые обработчики",
+		Action: serve,
+	}
+}
+
+// serve применяет или проверяет миграции, запускает фоновые обработчики и HTTP сервер.
+// По SIGINT или SIGTERM сервер перестает принимать соединения и дожидается текущих запросов,
+// затем останавливаются фоновые обработчики, отправляются накопленные спаны
+// и закрывается пул соединений с базой;
+// на все это отводится SHUTDOWN_TIMEOUT.
+func serve(c *cli.Context) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Инициализация базы данных
+	db, err := config.InitDB(cfg)
+	if err != nil {
+		return cli.Exit("ошибка инициализации базы данных: "+err.Error(), 1)
+	}
+	defer closeDB(db)
+
+	// Трассировка OpenTelemetry: HTTP запросы, вызовы сервисов и запросы к базе
+	shutdownTracing, err := tracing.Setup(c.Context, cfg)
+	if err != nil {
+		return cli.Exit("ошибка настройки трассировки: "+err.Error(), 1)
+	}
+	if err := tracing.InstrumentDB(db); err != nil {
+		return cli.Exit("ошибка подключения трассировки базы данных: "+err.Error(), 1)
+	}
+
+	// Метрики Prometheus: запросы к базе и пул соединений, HTTP и аутентификация
+	appMetrics := metrics.New(prometheus.NewRegistry())
+	if err := appMetrics.InstrumentDB(db); err != nil {
+		return cli.Exit("ошибка подключения метрик базы данных: "+err.Error(), 1)
+	}
+
+	// Настройка роутера
+	var notifications sync.WaitGroup
+	r, err := routes.SetupRouter(db, cfg, appMetrics, &notifications, slog.Default())
+	if err != nil {
+		return cli.Exit("ошибка настройки маршрутов: "+err.Error(), 1)
+	}
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	startWorkers(workersCtx, &workers, db, cfg, slog.Default())
+
+	server := &http.Server{
+		Addr:              ":" + cfg.ServerPort,
+		Handler:           r,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP сервер слушает", "addr", server.Addr)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		stopWorkers()
+		workers.Wait()
+		shutdownTracing(context.Background())
+		return cli.Exit("ошибка HTTP сервера: "+err.Error(), 1)
+	case <-signalCtx.Done():
+	}
+
+	// Повторный сигнал завершает процесс немедленно
+	stopSignals()
+	slog.Info("Получен сигнал остановки, завершение текущих запросов", "timeout", cfg.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	shutdown(shutdownCtx, server, &notifications, stopWorkers, &workers, shutdownTracing)
+
+	// Пул соединений с базой закрывается отложенным closeDB
+	slog.Info("Сервер остановлен")
+	return nil
+}
+
+// shutdown останавливает сервер по шагам; каждый следующий шаг начинается после предыдущего,
+// а общее время ограничено ctx
+func shutdown(
+	ctx context.Context,
+	server *http.Server,
+	notifications *sync.WaitGroup,
+	stopWorkers context.CancelFunc,
+	workers *sync.WaitGroup,
+	shutdownTracing func(context.Context) error,
+) {
+	// 1. Новые соединения не принимаются, текущие запросы завершаются
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("Не все запросы завершились до истечения SHUTDOWN_TIMEOUT", "error", err)
+		server.Close()
+	}
+
+	// 2. Уведомления, запущенные обработанными запросами, дописываются
+	if !waitGroupDone(ctx, notifications) {
+		slog.Warn("Не все уведомления отправлены до истечения SHUTDOWN_TIMEOUT")
+	}
+
+	// 3. Фоновые обработчики останавливаются: незавершенные запросы к базе отменяются, транзакции откатываются
+	stopWorkers()
+	if !waitGroupDone(ctx, workers) {
+		slog.Warn("Фоновые обработчики не остановились до истечения SHUTDOWN_TIMEOUT")
+	}
+
+	// 4. Накопленные спаны отправляются в экспортер
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Ошибка отправки спанов трассировки", "error", err)
+	}
+}
+
+// startWorkers запускает фоновые обработчики; каждый работает до отмены ctx
+func startWorkers(ctx context.Context, workers *sync.WaitGroup, db *gorm.DB, cfg *config.Config, logger *slog.Logger) {
+	// Фоновая доставка событий из outbox на webhook
+	dispatcher := services.NewWebhookDispatcher(
+		repositories.NewOutboxRepository(db),
+		repositories.NewWebhookRepository(db),
+		repositories.NewTxManager(db),
+		logger,
+		cfg,
+	)
+
+	// Фоновая сборка архивов для выгрузки персональных данных
+	dataExportWorker := services.NewDataExportWorker(
+		repositories.NewDataExportRepository(db),
+		repositories.NewUserRepository(db),
+		repositories.NewBookRepository(db),
+		repositories.NewLoginEventRepository(db),
+		services.NewAuditService(repositories.NewAuditRepository(db)),
+		mailer.New(cfg),
+		logger,
+		cfg,
+	)
+
+	// Окончательное удаление учетных записей и книг после отсрочки
+	purger := services.NewAccountPurger(
+		repositories.NewUserRepository(db),
+		repositories.NewBookRepository(db),
+		repositories.NewTxManager(db),
+		services.NewAuditService(repositories.NewAuditRepository(db)),
+		logger,
+		cfg,
+	)
+
+	for _, run := range []func(context.Context){dispatcher.Run, dataExportWorker.Run, purger.Run} {
+		workers.Add(1)
+		go func(run func(context.Context)) {
+			defer workers.Done()
+			run(ctx)
+		}(run)
+	}
+}
+
+// waitGroupDone ожидает завершения группы; возвращает false, если ctx истек раньше
+func waitGroupDone(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// closeDB закрывает пул соединений с базой данных
+func closeDB(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		slog.Error("Ошибка закрытия соединений с базой данных", "error", err)
+	}
+}
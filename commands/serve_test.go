@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// shutdownSteps записывает порядок шагов остановки
+type shutdownSteps struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (s *shutdownSteps) add(step string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = append(s.steps, step)
+}
+
+func (s *shutdownSteps) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.steps)
+}
+
+func TestShutdownDrainsRequestsBeforeStoppingWorkers(t *testing.T) {
+	steps := &shutdownSteps{}
+	var notifications, workers sync.WaitGroup
+
+	// Запрос, который обрабатывается во время остановки и запускает фоновое уведомление
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		notifications.Add(1)
+		go func() {
+			defer notifications.Done()
+			time.Sleep(50 * time.Millisecond)
+			steps.add("notification")
+		}()
+		steps.add("request")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		<-workersCtx.Done()
+		steps.add("worker")
+	}()
+
+	response := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			response <- 0
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		response <- resp.StatusCode
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown(ctx, server, &notifications, stopWorkers, &workers, func(context.Context) error {
+		steps.add("tracing")
+		return nil
+	})
+
+	if status := <-response; status != http.StatusNoContent {
+		t.Errorf("запрос, начатый до остановки, завершился со статусом %d", status)
+	}
+	want := []string{"request", "notification", "worker", "tracing"}
+	if got := steps.list(); !slices.Equal(got, want) {
+		t.Errorf("порядок остановки = %v, ожидался %v", got, want)
+	}
+	if _, err := http.Get("http://" + listener.Addr().String()); err == nil {
+		t.Error("сервер принимает соединения после остановки")
+	}
+}
+
+func TestShutdownRespectsDeadline(t *testing.T) {
+	var notifications, workers sync.WaitGroup
+	// Фоновый обработчик, который не реагирует на отмену
+	workers.Add(1)
+	defer workers.Done()
+
+	server := &http.Server{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		shutdown(ctx, server, &notifications, func() {}, &workers, func(context.Context) error { return nil })
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("остановка не завершилась по истечении SHUTDOWN_TIMEOUT")
+	}
+}
+
+func TestWaitGroupDone(t *testing.T) {
+	var wg sync.WaitGroup
+	if !waitGroupDone(context.Background(), &wg) {
+		t.Error("пустая группа не считается завершенной")
+	}
+
+	wg.Add(1)
+	defer wg.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if waitGroupDone(ctx, &wg) {
+		t.Error("незавершенная группа считается завершенной")
+	}
+}
+
+func TestCloseDBClosesPool(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectClose()
+
+	closeDB(db)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,238 @@
+// commands/users.go - управление пользователями из командной строки
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"AuthApplications/dto"
+	"AuthApplications/repositories"
+	"AuthApplications/services"
+
+	"github.com/urfave/cli/v2"
+	"gorm.io/gorm"
+)
+
+// errUserNotFound сообщение для неизвестного email или имени пользователя
+var errUserNotFound = cli.Exit("пользователь не найден", 1)
+
+// createAdminCommand создает первого или дополнительного администратора
+func createAdminCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "create-admin",
+		Usage: "создать локального пользователя с ролью admin",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "email", Required: true, Usage: "email администратора"},
+			&cli.StringFlag{Name: "username", Usage: "имя пользователя"},
+			&cli.StringFlag{Name: "password", EnvVars: []string{"ADMIN_PASSWORD"}, Usage: "пароль; если не задан, будет сгенерирован"},
+			&cli.StringFlag{Name: "first-name", Usage: "имя"},
+			&cli.StringFlag{Name: "last-name", Usage: "фамилия"},
+		},
+		Action: createAdmin,
+	}
+}
+
+// createAdmin создает администратора и выводит сгенерированный пароль
+func createAdmin(c *cli.Context) error {
+	password, generated, err := passwordFlag(c)
+	if err != nil {
+		return err
+	}
+
+	request := dto.RegisterRequest{
+		Username:  c.String("username"),
+		Email:     c.String("email"),
+		Password:  password,
+		FirstName: c.String("first-name"),
+		LastName:  c.String("last-name"),
+	}
+	if err := validate(&request); err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	user, err := newAdminService(db, cfg).CreateAdmin(c.Context, request, cliMeta())
+	if err != nil {
+		return cli.Exit("ошибка создания администратора: "+err.Error(), 1)
+	}
+
+	fmt.Printf("администратор создан: %s (%s)\n", user.Email, user.ID)
+	if generated {
+		fmt.Printf("пароль: %s\n", password)
+	}
+	return nil
+}
+
+// resetPasswordCommand задает новый пароль пользователю
+func resetPasswordCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "reset-password",
+		Usage:     "задать новый пароль локальному пользователю и завершить его сессии",
+		ArgsUsage: "<email или имя пользователя>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "password", EnvVars: []string{"NEW_PASSWORD"}, Usage: "новый пароль; если не задан, будет сгенерирован"},
+		},
+		Action: resetPassword,
+	}
+}
+
+// resetPassword сбрасывает пароль и выводит сгенерированный пароль
+func resetPassword(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("использование: reset-password <email или имя пользователя>", 1)
+	}
+
+	password, generated, err := passwordFlag(c)
+	if err != nil {
+		return err
+	}
+
+	request := dto.ResetPasswordRequest{
+		Identifier: strings.TrimSpace(c.Args().First()),
+		Password:   password,
+	}
+	if err := validate(&request); err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	user, err := newAdminService(db, cfg).ResetPassword(c.Context, request, cliMeta())
+	if err != nil {
+		return userError("ошибка сброса пароля", err)
+	}
+
+	fmt.Printf("пароль пользователя %s изменен, сессии завершены\n", user.Email)
+	if generated {
+		fmt.Printf("пароль: %s\n", password)
+	}
+	return nil
+}
+
+// revokeSessionsCommand завершает сессии одного или всех пользователей
+func revokeSessionsCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "revoke-sessions",
+		Usage:     "сделать недействительными ранее выданные токены",
+		ArgsUsage: "<email или имя пользователя>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "all", Usage: "завершить сессии всех пользователей"},
+		},
+		Action: revokeSessions,
+	}
+}
+
+// revokeSessions завершает сессии пользователя или, с флагом --all, всех пользователей
+func revokeSessions(c *cli.Context) error {
+	if c.Bool("all") == (c.NArg() == 1) || c.NArg() > 1 {
+		return cli.Exit("использование: revoke-sessions <email или имя пользователя> | --all", 1)
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	adminService := newAdminService(db, cfg)
+
+	if c.Bool("all") {
+		count, err := adminService.RevokeAllSessions(c.Context, cliMeta())
+		if err != nil {
+			return cli.Exit("ошибка завершения сессий: "+err.Error(), 1)
+		}
+		fmt.Printf("сессии завершены у %d пользователей\n", count)
+		return nil
+	}
+
+	user, err := adminService.RevokeSessions(c.Context, strings.TrimSpace(c.Args().First()), cliMeta())
+	if err != nil {
+		return userError("ошибка завершения сессий", err)
+	}
+	fmt.Printf("сессии пользователя %s завершены\n", user.Email)
+	return nil
+}
+
+// listUsersCommand выводит список пользователей
+func listUsersCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list-users",
+		Usage: "показать пользователей",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "role", Usage: "только пользователи с ролью"},
+			&cli.StringFlag{Name: "status", Usage: "только пользователи со статусом (active, suspended, banned)"},
+			&cli.BoolFlag{Name: "json", Usage: "вывести в формате JSON"},
+		},
+		Action: listUsers,
+	}
+}
+
+// listUsers выводит пользователей таблицей или JSON
+func listUsers(c *cli.Context) error {
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	userService := services.NewUserService(
+		repositories.NewUserRepository(db),
+		repositories.NewOutboxRepository(db),
+		repositories.NewTxManager(db),
+		services.NewAuditService(repositories.NewAuditRepository(db)),
+		cfg,
+	)
+	users, err := userService.GetAllUser(c.Context)
+	if err != nil {
+		return err
+	}
+
+	filtered := make([]*dto.UserResponse, 0, len(users))
+	for _, user := range users {
+		if role := c.String("role"); role != "" && user.Role != role {
+			continue
+		}
+		if status := c.String("status"); status != "" && user.Status != status {
+			continue
+		}
+		filtered = append(filtered, user)
+	}
+
+	if c.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(filtered)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tUSERNAME\tROLE\tSTATUS")
+	for _, user := range filtered {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", user.ID, user.Email, user.Username, user.Role, user.Status)
+	}
+	return w.Flush()
+}
+
+// passwordFlag возвращает пароль из флага --password или генерирует новый
+func passwordFlag(c *cli.Context) (string, bool, error) {
+	if password := c.String("password"); password != "" {
+		return password, false, nil
+	}
+	password, err := generatePassword()
+	return password, true, err
+}
+
+// userError преобразует ошибку поиска пользователя в понятное сообщение
+func userError(message string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errUserNotFound
+	}
+	return cli.Exit(message+": "+err.Error(), 1)
+}
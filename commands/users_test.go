@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Ошибки аргументов обнаруживаются до подключения к базе данных
+func TestUserCommandsRejectInvalidArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "revoke-sessions without target", args: []string{"revoke-sessions"}, want: "использование"},
+		{name: "revoke-sessions with target and --all", args: []string{"revoke-sessions", "--all", "alice"}, want: "использование"},
+		{name: "reset-password without identifier", args: []string{"reset-password"}, want: "использование"},
+		{name: "reset-password with short password", args: []string{"reset-password", "--password", "123", "alice"}, want: "ошибка валидации"},
+		{name: "create-admin with invalid email", args: []string{"create-admin", "--email", "not-an-email"}, want: "ошибка валидации"},
+		{name: "create-admin with invalid username", args: []string{"create-admin", "--email", "root@example.com", "--username", "a@b"}, want: "ошибка валидации"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApp()
+			app.ExitErrHandler = func(*cli.Context, error) {}
+
+			err := app.Run(append([]string{"AuthServices"}, tt.args...))
+			var exitErr cli.ExitCoder
+			if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+				t.Fatalf("ожидался код выхода 1, получено %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("сообщение %q не содержит %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	first, err := generatePassword()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := generatePassword()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Сгенерированный пароль проходит ту же проверку длины, что и пароль из флага
+	if len(first) < 6 || first == second {
+		t.Errorf("сгенерированы пароли %q и %q", first, second)
+	}
+}
@@ -1,9 +1,14 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
+	"AuthApplications/logging"
 	"github.com/joho/godotenv"
 )
 
@@ -14,42 +19,522 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+	DBMigrateOnStart bool // применять миграции при запуске сервера
+
+	// Подключение к базе данных: DBURL заменяет DBHost, DBPort, DBUser, DBPassword и DBName
+	DBURL         string
+	DBSSLMode     string
+	DBSSLRootCert string
+	DBSSLCert     string
+	DBSSLKey      string
+
+	// Пул соединений и таймауты базы данных
+	DBMaxOpenConns      int           // 0 — без ограничения
+	DBMaxIdleConns      int
+	DBConnMaxLifetime   time.Duration // 0 — соединения не пересоздаются по возрасту
+	DBConnMaxIdleTime   time.Duration // 0 — простаивающие соединения не закрываются
+	DBConnectTimeout    time.Duration
+	DBStatementTimeout  time.Duration // 0 — без ограничения
+	DBConnectRetries    int           // повторные попытки подключения при запуске
+	DBConnectRetryDelay time.Duration // первая задержка между попытками, далее удваивается
+
 	JWTSecret  string
+	JWTPreviousSecrets []string // предыдущие секреты: только для проверки уже выданных токенов после ротации
 	ServerPort string
 	CookieDomain string
-	CookieLifetime int
+	CookieLifetime time.Duration
+
+	// HTTP сервер: таймауты соединений и время на завершение запросов при остановке
+	ServerReadTimeout       time.Duration
+	ServerReadHeaderTimeout time.Duration
+	ServerWriteTimeout      time.Duration
+	ServerIdleTimeout       time.Duration
+	ShutdownTimeout         time.Duration
+	HealthCheckTimeout      time.Duration // ограничение времени одной проверки состояния
+	RequestTimeout          time.Duration // срок обработки запроса, включая запросы к базе; 0 — без ограничения
+
+	// Метрики Prometheus на /metrics; при заданном MetricsToken требуется Authorization: Bearer
+	MetricsEnabled bool
+	MetricsToken   string
+
+	// Журнал: уровень debug, info, warn или error и формат json или text
+	LogLevel  string
+	LogFormat string
+
+	// Трассировка OpenTelemetry: экспортер none, stdout или otlp
+	TracingExporter     string
+	TracingServiceName  string
+	TracingSampleRatio  float64 // доля трассируемых запросов без входящего traceparent
+	TracingOTLPEndpoint string  // пусто — OTEL_EXPORTER_OTLP_ENDPOINT или http://localhost:4318
+
+	// Время жизни токена доступа
+	AccessTokenTTL time.Duration
+
+	// Атрибут SameSite cookie с токеном доступа и CSRF токеном: lax, strict или none
+	CookieSameSite http.SameSite
+
+	// Время жизни CSRF токена
+	CSRFTokenTTL time.Duration
+
+	// LDAP / Active Directory
+	LDAPEnabled            bool
+	LDAPURL                string
+	LDAPStartTLS           bool
+	LDAPInsecureSkipVerify bool
+	LDAPBindDN             string
+	LDAPBindPassword       string
+	LDAPBaseDN             string
+	LDAPUserFilter         string
+	LDAPEmailAttribute     string
+	LDAPUsernameAttribute  string
+	LDAPGroupAttribute     string
+	LDAPGroupRoles         []GroupRole
+	LDAPDefaultRole        string
+	LDAPTimeout            time.Duration
+
+	// SAML 2.0 Service Provider
+	SAMLEnabled            bool
+	SAMLRootURL            string
+	SAMLEntityID           string
+	SAMLCertFile           string
+	SAMLKeyFile            string
+	SAMLIDPMetadataURL     string
+	SAMLIDPMetadataFile    string
+	SAMLAllowIDPInitiated  bool
+	SAMLEmailAttribute     string
+	SAMLUsernameAttribute  string
+	SAMLFirstNameAttribute string
+	SAMLLastNameAttribute  string
+	SAMLGroupAttribute     string
+	SAMLGroupRoles         []GroupRole
+	SAMLDefaultRole        string
+
+	// Публичный адрес сервиса для ссылок в письмах
+	PublicURL string
+
+	// SMTP для отправки писем (если SMTPHost пуст, письма пишутся в лог)
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Уведомления о входе с нового устройства: email, log или none
+	LoginAlertNotifier string
+
+	// Вход без пароля: время жизни ссылки и кода, число попыток ввода кода
+	// и лимит выпуска новых кодов и ссылок на один email за окно времени
+	MagicLinkTTL     time.Duration
+	OTPTTL           time.Duration
+	OTPMaxAttempts   int
+	OTPRequestLimit  int
+	OTPRequestWindow time.Duration
+
+	// Смена email: время жизни ссылки подтверждения и ссылки отмены
+	EmailChangeTTL       time.Duration
+	EmailChangeCancelTTL time.Duration
+
+	// WebAuthn / passkeys
+	WebAuthnRPID          string
+	WebAuthnRPDisplayName string
+	WebAuthnRPOrigins     []string
+
+	// Время жизни токена имперсонации
+	ImpersonationTTL time.Duration
+
+	// Давность аутентификации, после которой чувствительные операции требуют повторного входа
+	StepUpMaxAge time.Duration
+
+	// Доставка событий на webhook
+	WebhookPollInterval time.Duration // интервал проверки outbox
+	WebhookTimeout      time.Duration // время на один HTTP запрос
+	WebhookMaxAttempts  int           // после исчерпания попыток доставка переходит в dead
+	WebhookBatchSize    int
+
+	// Удаление учетных записей: отсрочка до окончательного удаления и интервал очистки
+	AccountDeletionGracePeriod time.Duration
+	PurgeInterval              time.Duration
+
+	// Выгрузка персональных данных пользователя
+	DataExportPollInterval time.Duration // интервал проверки очереди выгрузок
+	DataExportLinkTTL      time.Duration // время действия ссылки на скачивание архива
+}
+
+// GroupRole сопоставляет группу внешнего каталога или IdP с ролью пользователя
+type GroupRole struct {
+	Group string
+	Role  string
 }
 
-// LoadConfig загружает конфигурацию из .env файла или переменных окружения
+// minJWTSecretLength минимальная длина секрета подписи токенов (HS256 требует не менее 256 бит)
+const minJWTSecretLength = 32
+
+// LoadConfig загружает конфигурацию из файла CONFIG_FILE, .env файла, переменных окружения
+// и файлов секретов (*_FILE) и проверяет ее. Ошибки всех некорректных настроек возвращаются вместе.
 func LoadConfig() (*Config, error) {
 	// Загрузка .env файла, если он существует
 	_ = godotenv.Load()
 
+	src, err := newSource()
+	if err != nil {
+		return nil, err
+	}
+
 	config := &Config{
-		DBHost:     getEnv("DB_HOST", ""),
-		DBPort:     getEnv("DB_PORT", ""),
-		DBUser:     getEnv("DB_USER", ""),
-		DBPassword: getEnv("DB_PASSWORD", ""),
-		DBName:     getEnv("DB_NAME", ""),
-		JWTSecret:  getEnv("JWT_SECRET", ""),
-		ServerPort: getEnv("SERVER_PORT", ""),
-		CookieDomain: getEnv("COOKIE_DOMAIN", ""),
+		DBHost:     src.get("DB_HOST", ""),
+		DBPort:     src.get("DB_PORT", "5432"),
+		DBUser:     src.get("DB_USER", ""),
+		DBPassword: src.get("DB_PASSWORD", ""),
+		DBName:     src.get("DB_NAME", ""),
+		JWTSecret:  src.get("JWT_SECRET", ""),
+		ServerPort: src.get("SERVER_PORT", "8080"),
+		CookieDomain: src.get("COOKIE_DOMAIN", ""),
 	}
 
-	cookieLifetime, err := strconv.Atoi(getEnv("COOKIE_LIFETIME", "3600"))
-	if err != nil {
-		return nil, err
+	config.DBMigrateOnStart = src.bool("DB_MIGRATE_ON_START", true)
+	config.JWTPreviousSecrets = src.list("JWT_PREVIOUS_SECRETS", "")
+	config.AccessTokenTTL = src.duration("ACCESS_TOKEN_TTL", 24*time.Hour, time.Minute)
+	config.CookieLifetime = src.duration("COOKIE_LIFETIME", time.Hour, time.Second)
+	config.ImpersonationTTL = src.duration("IMPERSONATION_TTL", 30*time.Minute, time.Minute)
+	config.StepUpMaxAge = src.duration("STEP_UP_MAX_AGE", 5*time.Minute, time.Minute)
+
+	loadDatabaseConfig(config, src)
+	loadServerConfig(config, src)
+	loadLoggingConfig(config, src)
+	loadTracingConfig(config, src)
+	loadCookieConfig(config, src)
+	loadLDAPConfig(config, src)
+	loadSAMLConfig(config, src)
+	loadPasswordlessConfig(config, src)
+	loadWebAuthnConfig(config, src)
+	loadWebhookConfig(config, src)
+	loadDataExportConfig(config, src)
+	loadDeletionConfig(config, src)
+
+	if err := errors.Join(append(src.errs, config.Validate()...)...); err != nil {
+		return nil, fmt.Errorf("некорректная конфигурация:\n%w", err)
 	}
-	config.CookieLifetime = cookieLifetime
 
 	return config, nil
 }
 
-// getEnv получает переменную окружения или возвращает значение по умолчанию
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// Validate проверяет обязательные настройки и допустимые значения
+func (c *Config) Validate() []error {
+	var errs []error
+	require := func(key, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s: не задан", key))
+		}
+	}
+	positive := func(key string, value time.Duration) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: должен быть больше нуля", key))
+		}
+	}
+
+	if c.DBURL == "" {
+		require("DB_HOST", c.DBHost)
+		require("DB_USER", c.DBUser)
+		require("DB_NAME", c.DBName)
+		if !isPort(c.DBPort) {
+			errs = append(errs, fmt.Errorf("DB_PORT: некорректный порт %q", c.DBPort))
+		}
+	}
+	switch c.DBSSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("DB_SSLMODE: ожидается disable, allow, prefer, require, verify-ca или verify-full, получено %q", c.DBSSLMode))
+	}
+	if (c.DBSSLCert == "") != (c.DBSSLKey == "") {
+		errs = append(errs, errors.New("DB_SSLCERT и DB_SSLKEY: задаются вместе"))
+	}
+	if c.DBMaxOpenConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS: не может быть отрицательным"))
+	}
+	if c.DBMaxIdleConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_IDLE_CONNS: не может быть отрицательным"))
+	}
+	if c.DBConnectRetries < 0 {
+		errs = append(errs, errors.New("DB_CONNECT_RETRIES: не может быть отрицательным"))
+	}
+	if c.DBConnMaxLifetime < 0 || c.DBConnMaxIdleTime < 0 || c.DBStatementTimeout < 0 {
+		errs = append(errs, errors.New("DB_CONN_MAX_LIFETIME, DB_CONN_MAX_IDLE_TIME и DB_STATEMENT_TIMEOUT: не могут быть отрицательными"))
+	}
+	positive("DB_CONNECT_TIMEOUT", c.DBConnectTimeout)
+	positive("DB_CONNECT_RETRY_DELAY", c.DBConnectRetryDelay)
+
+	if len(c.JWTSecret) < minJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET: должен содержать не менее %d символов", minJWTSecretLength))
+	}
+	if !isPort(c.ServerPort) {
+		errs = append(errs, fmt.Errorf("SERVER_PORT: некорректный порт %q", c.ServerPort))
+	}
+
+	positive("SERVER_READ_TIMEOUT", c.ServerReadTimeout)
+	positive("SERVER_READ_HEADER_TIMEOUT", c.ServerReadHeaderTimeout)
+	positive("SERVER_WRITE_TIMEOUT", c.ServerWriteTimeout)
+	positive("SERVER_IDLE_TIMEOUT", c.ServerIdleTimeout)
+	positive("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+	positive("HEALTH_CHECK_TIMEOUT", c.HealthCheckTimeout)
+	if c.RequestTimeout < 0 {
+		errs = append(errs, errors.New("REQUEST_TIMEOUT: не может быть отрицательным"))
+	}
+	positive("ACCESS_TOKEN_TTL", c.AccessTokenTTL)
+	positive("COOKIE_LIFETIME", c.CookieLifetime)
+	positive("CSRF_TOKEN_TTL", c.CSRFTokenTTL)
+	positive("IMPERSONATION_TTL", c.ImpersonationTTL)
+	positive("STEP_UP_MAX_AGE", c.StepUpMaxAge)
+	positive("MAGIC_LINK_TTL", c.MagicLinkTTL)
+	positive("OTP_TTL", c.OTPTTL)
+	positive("OTP_REQUEST_WINDOW", c.OTPRequestWindow)
+	positive("EMAIL_CHANGE_TTL", c.EmailChangeTTL)
+	positive("EMAIL_CHANGE_CANCEL_TTL", c.EmailChangeCancelTTL)
+	positive("WEBHOOK_POLL_INTERVAL", c.WebhookPollInterval)
+	positive("WEBHOOK_TIMEOUT", c.WebhookTimeout)
+	positive("ACCOUNT_DELETION_GRACE_PERIOD", c.AccountDeletionGracePeriod)
+	positive("PURGE_INTERVAL", c.PurgeInterval)
+	positive("DATA_EXPORT_POLL_INTERVAL", c.DataExportPollInterval)
+	positive("DATA_EXPORT_LINK_TTL", c.DataExportLinkTTL)
+	if c.OTPMaxAttempts < 1 {
+		errs = append(errs, errors.New("OTP_MAX_ATTEMPTS: должно быть не меньше 1"))
+	}
+	if c.OTPRequestLimit < 1 {
+		errs = append(errs, errors.New("OTP_REQUEST_LIMIT: должно быть не меньше 1"))
+	}
+	if c.WebhookMaxAttempts < 1 {
+		errs = append(errs, errors.New("WEBHOOK_MAX_ATTEMPTS: должно быть не меньше 1"))
+	}
+	if c.WebhookBatchSize < 1 {
+		errs = append(errs, errors.New("WEBHOOK_BATCH_SIZE: должно быть не меньше 1"))
+	}
+
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w", err))
+	}
+	if c.LogFormat != logging.FormatJSON && c.LogFormat != logging.FormatText {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT: ожидается json или text, получено %q", c.LogFormat))
+	}
+
+	switch c.TracingExporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER: ожидается none, stdout или otlp, получено %q", c.TracingExporter))
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO: ожидается число от 0 до 1, получено %v", c.TracingSampleRatio))
+	}
+
+	switch c.LoginAlertNotifier {
+	case "email", "log", "none":
+	default:
+		errs = append(errs, fmt.Errorf("LOGIN_ALERT_NOTIFIER: ожидается email, log или none, получено %q", c.LoginAlertNotifier))
+	}
+	if publicURL, err := url.Parse(c.PublicURL); err != nil || publicURL.Scheme == "" || publicURL.Host == "" {
+		errs = append(errs, fmt.Errorf("PUBLIC_URL: ожидается абсолютный адрес, получено %q", c.PublicURL))
+	}
+
+	if c.LDAPEnabled {
+		require("LDAP_URL", c.LDAPURL)
+		require("LDAP_BASE_DN", c.LDAPBaseDN)
+		positive("LDAP_TIMEOUT", c.LDAPTimeout)
+		if !strings.Contains(c.LDAPUserFilter, "%s") {
+			errs = append(errs, fmt.Errorf("LDAP_USER_FILTER: должен содержать %%s, получено %q", c.LDAPUserFilter))
+		}
+	}
+
+	if c.SAMLEnabled {
+		require("SAML_ROOT_URL", c.SAMLRootURL)
+		require("SAML_CERT_FILE", c.SAMLCertFile)
+		require("SAML_KEY_FILE", c.SAMLKeyFile)
+		if c.SAMLIDPMetadataURL == "" && c.SAMLIDPMetadataFile == "" {
+			errs = append(errs, errors.New("SAML_IDP_METADATA_URL или SAML_IDP_METADATA_FILE: не задан"))
+		}
+	}
+
+	return errs
+}
+
+// loadDatabaseConfig загружает параметры подключения к базе данных, TLS, пула соединений и таймаутов
+func loadDatabaseConfig(config *Config, src *source) {
+	config.DBURL = src.get("DB_URL", "")
+	config.DBSSLMode = strings.ToLower(src.get("DB_SSLMODE", "disable"))
+	config.DBSSLRootCert = src.get("DB_SSLROOTCERT", "")
+	config.DBSSLCert = src.get("DB_SSLCERT", "")
+	config.DBSSLKey = src.get("DB_SSLKEY", "")
+
+	config.DBMaxOpenConns = src.int("DB_MAX_OPEN_CONNS", 25)
+	config.DBMaxIdleConns = src.int("DB_MAX_IDLE_CONNS", 5)
+	config.DBConnMaxLifetime = src.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute, time.Second)
+	config.DBConnMaxIdleTime = src.duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute, time.Second)
+	config.DBConnectTimeout = src.duration("DB_CONNECT_TIMEOUT", 5*time.Second, time.Second)
+	config.DBStatementTimeout = src.duration("DB_STATEMENT_TIMEOUT", 0, time.Millisecond)
+	config.DBConnectRetries = src.int("DB_CONNECT_RETRIES", 5)
+	config.DBConnectRetryDelay = src.duration("DB_CONNECT_RETRY_DELAY", time.Second, time.Second)
+}
+
+// loadServerConfig загружает таймауты HTTP сервера, проверок состояния, время на корректную остановку
+// и настройки страницы метрик
+func loadServerConfig(config *Config, src *source) {
+	config.ServerReadTimeout = src.duration("SERVER_READ_TIMEOUT", 15*time.Second, time.Second)
+	config.ServerReadHeaderTimeout = src.duration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second, time.Second)
+	config.ServerWriteTimeout = src.duration("SERVER_WRITE_TIMEOUT", 60*time.Second, time.Second)
+	config.ServerIdleTimeout = src.duration("SERVER_IDLE_TIMEOUT", 120*time.Second, time.Second)
+	config.ShutdownTimeout = src.duration("SHUTDOWN_TIMEOUT", 30*time.Second, time.Second)
+	config.HealthCheckTimeout = src.duration("HEALTH_CHECK_TIMEOUT", 2*time.Second, time.Second)
+	config.RequestTimeout = src.duration("REQUEST_TIMEOUT", 30*time.Second, time.Second)
+	config.MetricsEnabled = src.bool("METRICS_ENABLED", true)
+	config.MetricsToken = src.get("METRICS_TOKEN", "")
+}
+
+// loadLoggingConfig загружает настройки журнала
+func loadLoggingConfig(config *Config, src *source) {
+	config.LogLevel = strings.ToLower(src.get("LOG_LEVEL", "info"))
+	config.LogFormat = strings.ToLower(src.get("LOG_FORMAT", logging.FormatJSON))
+}
+
+// loadTracingConfig загружает настройки трассировки OpenTelemetry
+func loadTracingConfig(config *Config, src *source) {
+	config.TracingExporter = strings.ToLower(src.get("TRACING_EXPORTER", "none"))
+	config.TracingServiceName = src.get("TRACING_SERVICE_NAME", "auth-api")
+	config.TracingSampleRatio = src.float("TRACING_SAMPLE_RATIO", 1)
+	config.TracingOTLPEndpoint = src.get("TRACING_OTLP_ENDPOINT", "")
+}
+
+// loadCookieConfig загружает атрибуты cookie и настройки защиты от CSRF
+func loadCookieConfig(config *Config, src *source) {
+	switch sameSite := src.get("COOKIE_SAME_SITE", "lax"); strings.ToLower(sameSite) {
+	case "lax":
+		config.CookieSameSite = http.SameSiteLaxMode
+	case "strict":
+		config.CookieSameSite = http.SameSiteStrictMode
+	case "none":
+		config.CookieSameSite = http.SameSiteNoneMode
+	default:
+		src.errs = append(src.errs, fmt.Errorf("COOKIE_SAME_SITE: ожидается lax, strict или none, получено %q", sameSite))
+	}
+
+	config.CSRFTokenTTL = src.duration("CSRF_TOKEN_TTL", 12*time.Hour, time.Minute)
+}
+
+// loadLDAPConfig загружает настройки LDAP-аутентификации
+func loadLDAPConfig(config *Config, src *source) {
+	config.LDAPEnabled = src.bool("LDAP_ENABLED", false)
+	config.LDAPStartTLS = src.bool("LDAP_START_TLS", false)
+	config.LDAPInsecureSkipVerify = src.bool("LDAP_INSECURE_SKIP_VERIFY", false)
+
+	config.LDAPURL = src.get("LDAP_URL", "")
+	config.LDAPBindDN = src.get("LDAP_BIND_DN", "")
+	config.LDAPBindPassword = src.get("LDAP_BIND_PASSWORD", "")
+	config.LDAPBaseDN = src.get("LDAP_BASE_DN", "")
+	config.LDAPUserFilter = src.get("LDAP_USER_FILTER", "(mail=%s)")
+	config.LDAPEmailAttribute = src.get("LDAP_EMAIL_ATTRIBUTE", "mail")
+	config.LDAPUsernameAttribute = src.get("LDAP_USERNAME_ATTRIBUTE", "uid")
+	config.LDAPGroupAttribute = src.get("LDAP_GROUP_ATTRIBUTE", "memberOf")
+	config.LDAPDefaultRole = src.get("LDAP_DEFAULT_ROLE", "user")
+	config.LDAPTimeout = src.duration("LDAP_TIMEOUT", 5*time.Second, time.Second)
+	config.LDAPGroupRoles = parseGroupRoles(src, "LDAP_GROUP_ROLES")
+}
+
+// loadSAMLConfig загружает настройки входа через SAML 2.0
+func loadSAMLConfig(config *Config, src *source) {
+	config.SAMLEnabled = src.bool("SAML_ENABLED", false)
+	config.SAMLAllowIDPInitiated = src.bool("SAML_ALLOW_IDP_INITIATED", false)
+
+	config.SAMLRootURL = src.get("SAML_ROOT_URL", "")
+	config.SAMLEntityID = src.get("SAML_ENTITY_ID", "")
+	config.SAMLCertFile = src.get("SAML_CERT_FILE", "")
+	config.SAMLKeyFile = src.get("SAML_KEY_FILE", "")
+	config.SAMLIDPMetadataURL = src.get("SAML_IDP_METADATA_URL", "")
+	config.SAMLIDPMetadataFile = src.get("SAML_IDP_METADATA_FILE", "")
+	config.SAMLEmailAttribute = src.get("SAML_EMAIL_ATTRIBUTE", "email")
+	config.SAMLUsernameAttribute = src.get("SAML_USERNAME_ATTRIBUTE", "uid")
+	config.SAMLFirstNameAttribute = src.get("SAML_FIRST_NAME_ATTRIBUTE", "givenName")
+	config.SAMLLastNameAttribute = src.get("SAML_LAST_NAME_ATTRIBUTE", "sn")
+	config.SAMLGroupAttribute = src.get("SAML_GROUP_ATTRIBUTE", "groups")
+	config.SAMLDefaultRole = src.get("SAML_DEFAULT_ROLE", "user")
+	config.SAMLGroupRoles = parseGroupRoles(src, "SAML_GROUP_ROLES")
+}
+
+// loadPasswordlessConfig загружает настройки почты и входа по ссылке или одноразовому коду
+func loadPasswordlessConfig(config *Config, src *source) {
+	config.PublicURL = strings.TrimRight(src.get("PUBLIC_URL", "http://localhost:8080"), "/")
+
+	config.SMTPHost = src.get("SMTP_HOST", "")
+	config.SMTPPort = src.get("SMTP_PORT", "587")
+	config.SMTPUsername = src.get("SMTP_USERNAME", "")
+	config.SMTPPassword = src.get("SMTP_PASSWORD", "")
+	config.SMTPFrom = src.get("SMTP_FROM", "no-reply@localhost")
+	config.LoginAlertNotifier = src.get("LOGIN_ALERT_NOTIFIER", "email")
+
+	config.MagicLinkTTL = src.duration("MAGIC_LINK_TTL", 15*time.Minute, time.Minute)
+	config.OTPTTL = src.duration("OTP_TTL", 10*time.Minute, time.Minute)
+	config.OTPMaxAttempts = src.int("OTP_MAX_ATTEMPTS", 5)
+	config.OTPRequestLimit = src.int("OTP_REQUEST_LIMIT", 3)
+	config.OTPRequestWindow = src.duration("OTP_REQUEST_WINDOW", 15*time.Minute, time.Minute)
+	config.EmailChangeTTL = src.duration("EMAIL_CHANGE_TTL", time.Hour, time.Minute)
+	config.EmailChangeCancelTTL = src.duration("EMAIL_CHANGE_CANCEL_TTL", 72*time.Hour, time.Hour)
+}
+
+// loadWebAuthnConfig загружает настройки Relying Party для WebAuthn.
+// По умолчанию RP ID и origin берутся из PUBLIC_URL.
+func loadWebAuthnConfig(config *Config, src *source) {
+	var hostname string
+	if publicURL, err := url.Parse(config.PublicURL); err == nil {
+		hostname = publicURL.Hostname()
+	}
+
+	config.WebAuthnRPID = src.get("WEBAUTHN_RP_ID", hostname)
+	config.WebAuthnRPDisplayName = src.get("WEBAUTHN_RP_DISPLAY_NAME", "Auth Services")
+	config.WebAuthnRPOrigins = src.list("WEBAUTHN_RP_ORIGINS", config.PublicURL)
+}
+
+// loadWebhookConfig загружает настройки фоновой доставки событий на webhook
+func loadWebhookConfig(config *Config, src *source) {
+	config.WebhookPollInterval = src.duration("WEBHOOK_POLL_INTERVAL", 5*time.Second, time.Second)
+	config.WebhookTimeout = src.duration("WEBHOOK_TIMEOUT", 10*time.Second, time.Second)
+	config.WebhookMaxAttempts = src.int("WEBHOOK_MAX_ATTEMPTS", 8)
+	config.WebhookBatchSize = src.int("WEBHOOK_BATCH_SIZE", 50)
+}
+
+// loadDeletionConfig загружает настройки отложенного удаления учетных записей
+func loadDeletionConfig(config *Config, src *source) {
+	config.AccountDeletionGracePeriod = src.duration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour, 24*time.Hour)
+	config.PurgeInterval = src.duration("PURGE_INTERVAL", time.Hour, time.Minute)
+}
+
+// loadDataExportConfig загружает настройки выгрузки персональных данных
+func loadDataExportConfig(config *Config, src *source) {
+	config.DataExportPollInterval = src.duration("DATA_EXPORT_POLL_INTERVAL", 10*time.Second, time.Second)
+	config.DataExportLinkTTL = src.duration("DATA_EXPORT_LINK_TTL", 24*time.Hour, time.Hour)
+}
+
+// parseGroupRoles разбирает сопоставление групп и ролей.
+// Формат: "cn=admins,ou=groups,dc=example,dc=com:admin;staff:user"
+func parseGroupRoles(src *source, key string) []GroupRole {
+	var groupRoles []GroupRole
+	for _, pair := range strings.Split(src.get(key, ""), ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		idx := strings.LastIndex(pair, ":")
+		if idx <= 0 || idx == len(pair)-1 {
+			src.errs = append(src.errs, fmt.Errorf("%s: некорректное значение %q", key, pair))
+			continue
+		}
+		groupRoles = append(groupRoles, GroupRole{
+			Group: strings.TrimSpace(pair[:idx]),
+			Role:  strings.TrimSpace(pair[idx+1:]),
+		})
+	}
+	return groupRoles
+}
+
+// isPort проверяет, что значение — номер TCP порта
+func isPort(value string) bool {
+	var port int
+	if _, err := fmt.Sscan(value, &port); err != nil || fmt.Sprint(port) != value {
+		return false
 	}
-	return value
+	return port > 0 && port < 65536
 }
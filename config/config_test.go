@@ -0,0 +1,240 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+// setupEnv запускает загрузку в пустом каталоге (без .env проекта) и задает переменные окружения
+func setupEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+
+	t.Chdir(t.TempDir())
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+// writeFile записывает файл во временный каталог и возвращает путь к нему
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFromYAMLFileWithEnvOverride(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+db:
+  host: db.internal
+  user: auth
+  name: authdb
+  max_open_conns: 40
+server:
+  port: 9090
+access_token_ttl: 2h
+ldap:
+  group_roles:
+    - group: "cn=admins,dc=example,dc=com"
+      role: admin
+    - group: staff
+      role: user
+`)
+	setupEnv(t, map[string]string{
+		ConfigFileEnv: path,
+		"JWT_SECRET":  testJWTSecret,
+		"SERVER_PORT": "8081",
+	})
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.DBHost != "db.internal" || cfg.DBUser != "auth" || cfg.DBName != "authdb" || cfg.DBMaxOpenConns != 40 {
+		t.Errorf("настройки базы из файла: %+v", cfg)
+	}
+	if cfg.ServerPort != "8081" {
+		t.Errorf("SERVER_PORT = %q, переменная окружения должна иметь приоритет над файлом", cfg.ServerPort)
+	}
+	if cfg.AccessTokenTTL != 2*time.Hour {
+		t.Errorf("ACCESS_TOKEN_TTL = %v", cfg.AccessTokenTTL)
+	}
+	want := []GroupRole{{Group: "cn=admins,dc=example,dc=com", Role: "admin"}, {Group: "staff", Role: "user"}}
+	if len(cfg.LDAPGroupRoles) != 2 || cfg.LDAPGroupRoles[0] != want[0] || cfg.LDAPGroupRoles[1] != want[1] {
+		t.Errorf("LDAP_GROUP_ROLES = %v", cfg.LDAPGroupRoles)
+	}
+}
+
+func TestLoadConfigKeepsGroupRoleOrderFromFile(t *testing.T) {
+	// Порядок в файле обратен алфавитному: у пользователя в обеих группах роль admin
+	path := writeFile(t, "config.yaml", `
+saml:
+  group_roles:
+    - group: zeta-admins
+      role: admin
+    - group: alpha-readers
+      role: user
+`)
+	setupEnv(t, map[string]string{
+		ConfigFileEnv: path,
+		"DB_HOST":     "localhost",
+		"DB_USER":     "auth",
+		"DB_NAME":     "authdb",
+		"JWT_SECRET":  testJWTSecret,
+	})
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	want := []GroupRole{{Group: "zeta-admins", Role: "admin"}, {Group: "alpha-readers", Role: "user"}}
+	if len(cfg.SAMLGroupRoles) != 2 || cfg.SAMLGroupRoles[0] != want[0] || cfg.SAMLGroupRoles[1] != want[1] {
+		t.Errorf("SAML_GROUP_ROLES = %v, ожидался порядок из файла %v", cfg.SAMLGroupRoles, want)
+	}
+}
+
+func TestLoadConfigRejectsGroupRoleTable(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+ldap:
+  group_roles:
+    admins: admin
+`)
+	setupEnv(t, map[string]string{ConfigFileEnv: path, "JWT_SECRET": testJWTSecret})
+
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "LDAP_GROUP_ROLES") {
+		t.Fatalf("ожидалась ошибка о неупорядоченном сопоставлении, получено %v", err)
+	}
+}
+
+func TestLoadConfigFromTOMLFile(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+jwt_secret = "`+testJWTSecret+`"
+
+[db]
+host = "db.internal"
+user = "auth"
+name = "authdb"
+`)
+	setupEnv(t, map[string]string{ConfigFileEnv: path})
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.DBHost != "db.internal" || cfg.JWTSecret != testJWTSecret {
+		t.Errorf("настройки из TOML: host = %q, secret = %q", cfg.DBHost, cfg.JWTSecret)
+	}
+}
+
+func TestLoadConfigExampleFile(t *testing.T) {
+	example, err := filepath.Abs("../config.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupEnv(t, map[string]string{ConfigFileEnv: example, "JWT_SECRET": testJWTSecret})
+
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("пример конфигурации не проходит проверку: %v", err)
+	}
+}
+
+func TestLoadConfigReadsSecretFiles(t *testing.T) {
+	secretPath := writeFile(t, "jwt_secret", testJWTSecret+"\n")
+	setupEnv(t, map[string]string{
+		"DB_HOST":         "localhost",
+		"DB_USER":         "auth",
+		"DB_NAME":         "authdb",
+		"JWT_SECRET_FILE": secretPath,
+	})
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.JWTSecret != testJWTSecret {
+		t.Errorf("JWT_SECRET = %q, перевод строки в конце файла должен отбрасываться", cfg.JWTSecret)
+	}
+}
+
+func TestLoadConfigRejectsSecretInEnvAndFile(t *testing.T) {
+	setupEnv(t, map[string]string{
+		"JWT_SECRET":      testJWTSecret,
+		"JWT_SECRET_FILE": writeFile(t, "jwt_secret", testJWTSecret),
+	})
+
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "JWT_SECRET_FILE") {
+		t.Fatalf("ожидалась ошибка о двух источниках секрета, получено %v", err)
+	}
+}
+
+func TestLoadConfigReportsAllErrors(t *testing.T) {
+	setupEnv(t, map[string]string{
+		"DB_HOST":          "localhost",
+		"DB_USER":          "auth",
+		"DB_NAME":          "authdb",
+		"JWT_SECRET":       "short",
+		"ACCESS_TOKEN_TTL": "soon",
+		"LOG_LEVEL":        "verbose",
+		"SERVER_PORT":      "http",
+	})
+
+	_, err := LoadConfig()
+	if err == nil {
+		t.Fatal("некорректная конфигурация принята")
+	}
+	for _, key := range []string{"JWT_SECRET", "ACCESS_TOKEN_TTL", "LOG_LEVEL", "SERVER_PORT"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("ошибка не упоминает %s: %v", key, err)
+		}
+	}
+}
+
+func TestLoadConfigRequiresDatabaseSettings(t *testing.T) {
+	setupEnv(t, map[string]string{"JWT_SECRET": testJWTSecret})
+
+	_, err := LoadConfig()
+	if err == nil {
+		t.Fatal("конфигурация без настроек базы принята")
+	}
+	for _, key := range []string{"DB_HOST", "DB_USER", "DB_NAME"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("ошибка не упоминает %s: %v", key, err)
+		}
+	}
+
+	// Полная строка подключения заменяет отдельные параметры
+	t.Setenv("DB_URL", "postgres://auth@localhost/authdb")
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig с DB_URL: %v", err)
+	}
+}
+
+func TestSourceDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: time.Hour},
+		{value: "90", want: 90 * time.Minute},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: "45s", want: 45 * time.Second},
+	}
+
+	for _, tt := range tests {
+		src := &source{values: map[string]string{"TTL": tt.value}}
+		if got := src.duration("TTL", time.Hour, time.Minute); got != tt.want {
+			t.Errorf("duration(%q) = %v, ожидалось %v", tt.value, got, tt.want)
+		}
+		if len(src.errs) != 0 {
+			t.Errorf("duration(%q): %v", tt.value, src.errs)
+		}
+	}
+}
@@ -2,32 +2,186 @@
 package config
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
-	"AuthApplications/models"
+	"AuthApplications/logging"
+	"AuthApplications/migrations"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-// InitDB инициализирует подключение к базе данных
-func InitDB(cfg *Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+// maxDBConnectRetryDelay верхняя граница задержки между попытками подключения при запуске
+const maxDBConnectRetryDelay = 30 * time.Second
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// OpenDB открывает подключение к базе данных без применения миграций.
+// Если база еще недоступна (например, контейнер PostgreSQL только стартует), подключение
+// повторяется DB_CONNECT_RETRIES раз с удваивающейся задержкой.
+func OpenDB(cfg *Config) (*gorm.DB, error) {
+	connConfig, err := connConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	// Автоматическая миграция таблиц
- 	err = db.AutoMigrate(
-		&models.User{},
-		&models.Book{},
-		&models.AuthorBook{},
-		)
+	sqlDB := stdlib.OpenDB(*connConfig)
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	if err := waitForDB(sqlDB, cfg); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	// TranslateError преобразует ошибки PostgreSQL (например, нарушение уникальности) в ошибки gorm.
+	// Ошибки и медленные запросы пишутся в журнал по умолчанию (slog.SetDefault) без значений параметров.
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		TranslateError: true,
+		Logger:         logging.NewGormLogger(slog.Default()),
+	})
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// connConfig разбирает строку подключения: DB_URL целиком или собранную из DB_HOST, DB_SSLMODE и др.
+// Таймаут подключения и statement_timeout добавляются, если не заданы в самой строке.
+func connConfig(cfg *Config) (*pgx.ConnConfig, error) {
+	dsn := cfg.DBURL
+	if dsn == "" {
+		dsn = buildDSN(cfg)
+	}
+
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("некорректная строка подключения к базе данных: %w", err)
+	}
+
+	if connConfig.ConnectTimeout == 0 {
+		connConfig.ConnectTimeout = cfg.DBConnectTimeout
+	}
+	if _, ok := connConfig.RuntimeParams["statement_timeout"]; !ok && cfg.DBStatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = fmt.Sprint(cfg.DBStatementTimeout.Milliseconds())
+	}
+	return connConfig, nil
+}
+
+// buildDSN собирает строку подключения в формате "ключ=значение" из отдельных настроек
+func buildDSN(cfg *Config) string {
+	params := []string{
+		"host=" + dsnValue(cfg.DBHost),
+		"port=" + dsnValue(cfg.DBPort),
+		"user=" + dsnValue(cfg.DBUser),
+		"dbname=" + dsnValue(cfg.DBName),
+		"sslmode=" + dsnValue(cfg.DBSSLMode),
+	}
+	optional := [][2]string{
+		{"password", cfg.DBPassword},
+		{"sslrootcert", cfg.DBSSLRootCert},
+		{"sslcert", cfg.DBSSLCert},
+		{"sslkey", cfg.DBSSLKey},
+	}
+	for _, param := range optional {
+		if param[1] != "" {
+			params = append(params, param[0]+"="+dsnValue(param[1]))
+		}
+	}
+	return strings.Join(params, " ")
+}
+
+// dsnValue экранирует значение для строки подключения: пароли и пути могут содержать пробелы и кавычки
+func dsnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// waitForDB проверяет доступность базы, повторяя попытки с экспоненциальной задержкой
+func waitForDB(sqlDB *sql.DB, cfg *Config) error {
+	delay := cfg.DBConnectRetryDelay
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.DBConnectTimeout)
+		err := sqlDB.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= cfg.DBConnectRetries {
+			return fmt.Errorf("база данных недоступна после %d попыток: %w", attempt+1, err)
+		}
+
+		slog.Warn("База данных недоступна, повтор подключения",
+			"attempt", attempt+1, "attempts", cfg.DBConnectRetries+1, "retry_in", delay, "error", err)
+		time.Sleep(delay)
+		delay = min(delay*2, maxDBConnectRetryDelay)
+	}
+}
+
+// InitDB инициализирует подключение к базе данных и проверяет схему.
+// При DB_MIGRATE_ON_START непримененные миграции применяются (реплики ждут друг друга
+// на блокировке), иначе запуск прерывается, пока не будет выполнена команда migrate up.
+func InitDB(cfg *Config) (*gorm.DB, error) {
+	db, err := OpenDB(cfg)
 	if err != nil {
 		return nil, err
 	}
+	return prepareSchema(db, cfg)
+}
 
+// prepareSchema применяет или проверяет миграции на открытом подключении.
+// При любой ошибке пул соединений закрывается, чтобы не оставлять открытые подключения.
+func prepareSchema(db *gorm.DB, cfg *Config) (*gorm.DB, error) {
+	if err := migrateOrCheck(db, cfg); err != nil {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+		return nil, err
+	}
 	return db, nil
-}
\ No newline at end of file
+}
+
+// migrateOrCheck применяет миграции при DB_MIGRATE_ON_START, иначе требует, чтобы их не осталось
+func migrateOrCheck(db *gorm.DB, cfg *Config) error {
+	if !cfg.DBMigrateOnStart {
+		return CheckSchema(db)
+	}
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up()
+	return err
+}
+
+// CheckSchema возвращает ошибку, если в базе остались непримененные миграции.
+// Сама схема не меняется: миграции применяет команда migrate up или serve при DB_MIGRATE_ON_START.
+func CheckSchema(db *gorm.DB) error {
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		names := make([]string, 0, len(pending))
+		for _, migration := range pending {
+			names = append(names, fmt.Sprintf("%d_%s", migration.Version, migration.Name))
+		}
+		return fmt.Errorf("схема базы данных устарела, выполните migrate up (не применены: %s)",
+			strings.Join(names, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDBConfig настройки подключения к базе со значениями по умолчанию
+func testDBConfig() *Config {
+	return &Config{
+		DBHost:              "db.internal",
+		DBPort:              "5432",
+		DBUser:              "auth",
+		DBName:              "authdb",
+		DBSSLMode:           "disable",
+		DBConnectTimeout:    5 * time.Second,
+		DBConnectRetryDelay: time.Millisecond,
+	}
+}
+
+func TestConnConfigFromSettings(t *testing.T) {
+	cfg := testDBConfig()
+	cfg.DBPassword = `pa ss'w\rd`
+	cfg.DBStatementTimeout = 15 * time.Second
+
+	parsed, err := connConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Host != "db.internal" || parsed.Port != 5432 || parsed.User != "auth" || parsed.Database != "authdb" {
+		t.Errorf("параметры подключения: %s:%d %s/%s", parsed.Host, parsed.Port, parsed.User, parsed.Database)
+	}
+	if parsed.Password != cfg.DBPassword {
+		t.Errorf("пароль с пробелом и кавычкой разобран как %q", parsed.Password)
+	}
+	if parsed.TLSConfig != nil {
+		t.Error("TLS включен при sslmode=disable")
+	}
+	if parsed.ConnectTimeout != 5*time.Second {
+		t.Errorf("connect_timeout = %v", parsed.ConnectTimeout)
+	}
+	if parsed.RuntimeParams["statement_timeout"] != "15000" {
+		t.Errorf("statement_timeout = %q", parsed.RuntimeParams["statement_timeout"])
+	}
+}
+
+func TestConnConfigTLS(t *testing.T) {
+	cfg := testDBConfig()
+	cfg.DBSSLMode = "require"
+
+	parsed, err := connConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.TLSConfig == nil {
+		t.Fatal("TLS не включен при sslmode=require")
+	}
+
+	// Путь к корневому сертификату передается драйверу, который читает файл при разборе
+	cfg.DBSSLMode = "verify-full"
+	cfg.DBSSLRootCert = "/nonexistent/root.crt"
+	if _, err := connConfig(cfg); err == nil {
+		t.Error("отсутствующий корневой сертификат не обнаружен")
+	}
+}
+
+func TestConnConfigKeepsDSNOverrideParameters(t *testing.T) {
+	cfg := testDBConfig()
+	cfg.DBURL = "postgres://other@replica.internal:6432/otherdb?connect_timeout=2&statement_timeout=500&sslmode=disable"
+	cfg.DBStatementTimeout = 15 * time.Second
+
+	parsed, err := connConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Host != "replica.internal" || parsed.Port != 6432 || parsed.Database != "otherdb" {
+		t.Errorf("DB_URL не заменил отдельные параметры: %s:%d/%s", parsed.Host, parsed.Port, parsed.Database)
+	}
+	if parsed.ConnectTimeout != 2*time.Second || parsed.RuntimeParams["statement_timeout"] != "500" {
+		t.Errorf("параметры из DB_URL перезаписаны: connect_timeout = %v, statement_timeout = %q",
+			parsed.ConnectTimeout, parsed.RuntimeParams["statement_timeout"])
+	}
+}
+
+func TestWaitForDBRetriesUntilReachable(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	cfg := testDBConfig()
+	cfg.DBConnectRetries = 3
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mock.ExpectPing()
+
+	if err := waitForDB(sqlDB, cfg); err != nil {
+		t.Fatalf("waitForDB: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitForDBGivesUpAfterRetries(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	cfg := testDBConfig()
+	cfg.DBConnectRetries = 1
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+	err = waitForDB(sqlDB, cfg)
+	if err == nil || !strings.Contains(err.Error(), "2 попыток") {
+		t.Fatalf("ожидалась ошибка после двух попыток, получено %v", err)
+	}
+}
+
+func TestPrepareSchemaClosesPoolOnError(t *testing.T) {
+	for _, migrateOnStart := range []bool{false, true} {
+		sqlDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg := testDBConfig()
+		cfg.DBMigrateOnStart = migrateOnStart
+		mock.MatchExpectationsInOrder(false)
+		mock.ExpectQuery(".*").WillReturnError(errors.New("permission denied"))
+		mock.ExpectExec(".*").WillReturnError(errors.New("permission denied"))
+		mock.ExpectClose()
+
+		if _, err := prepareSchema(db, cfg); err == nil {
+			t.Fatalf("DBMigrateOnStart=%v: ожидалась ошибка", migrateOnStart)
+		}
+		if err := sqlDB.Ping(); err == nil || !strings.Contains(err.Error(), "closed") {
+			t.Errorf("DBMigrateOnStart=%v: пул соединений не закрыт: %v", migrateOnStart, err)
+		}
+	}
+}
+
+func TestCheckSchemaReportsPendingWithoutMigrating(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Таблицы schema_migrations нет: все миграции считаются непримененными.
+	// Любой другой запрос (в том числе создание таблиц) sqlmock отклонит.
+	mock.ExpectQuery("information_schema.tables").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	err = CheckSchema(db)
+	if err == nil || !strings.Contains(err.Error(), "migrate up") {
+		t.Fatalf("ожидалась ошибка об устаревшей схеме, получено %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateDatabaseSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   string
+	}{
+		{name: "sslmode", modify: func(cfg *Config) { cfg.DBSSLMode = "on" }, want: "DB_SSLMODE"},
+		{name: "client cert without key", modify: func(cfg *Config) { cfg.DBSSLCert = "/etc/db/client.crt" }, want: "DB_SSLKEY"},
+		{name: "negative pool size", modify: func(cfg *Config) { cfg.DBMaxOpenConns = -1 }, want: "DB_MAX_OPEN_CONNS"},
+		{name: "port", modify: func(cfg *Config) { cfg.DBPort = "5432x" }, want: "DB_PORT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testDBConfig()
+			tt.modify(cfg)
+
+			err := errors.Join(cfg.Validate()...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ожидалась ошибка %s, получено %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+// config/source.go - источники настроек: файл конфигурации, переменные окружения и файлы секретов
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv переменная окружения с путем к YAML или TOML файлу конфигурации
+const ConfigFileEnv = "CONFIG_FILE"
+
+// secretKeys настройки, которые можно передать файлом: значение KEY читается из пути в KEY_FILE
+// (Docker и Kubernetes secrets). Ключи вроде SAML_CERT_FILE сами являются путями и сюда не входят.
+var secretKeys = []string{
+	"DB_URL",
+	"DB_USER",
+	"DB_PASSWORD",
+	"JWT_SECRET",
+	"JWT_PREVIOUS_SECRETS",
+	"LDAP_BIND_PASSWORD",
+	"METRICS_TOKEN",
+	"SMTP_USERNAME",
+	"SMTP_PASSWORD",
+}
+
+// groupRoleKeys настройки сопоставления групп и ролей: в файле конфигурации задаются
+// списком {group, role}, а в окружении строкой "группа:роль;группа:роль"
+var groupRoleKeys = map[string]bool{
+	"LDAP_GROUP_ROLES": true,
+	"SAML_GROUP_ROLES": true,
+}
+
+// source объединяет источники настроек по приоритету: переменная окружения, файл секрета
+// из KEY_FILE, файл конфигурации, значение по умолчанию. Ошибки разбора накапливаются,
+// чтобы при запуске сообщить обо всех некорректных настройках сразу.
+type source struct {
+	values map[string]string
+	errs   []error
+}
+
+// newSource читает файл конфигурации (если задан CONFIG_FILE), переменные окружения и файлы секретов
+func newSource() (*source, error) {
+	s := &source{values: make(map[string]string)}
+
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		if err := s.loadFile(path); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	for _, key := range secretKeys {
+		path := os.Getenv(key + "_FILE")
+		if path == "" {
+			continue
+		}
+		if os.Getenv(key) != "" {
+			return nil, fmt.Errorf("заданы одновременно %s и %s_FILE", key, key)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s_FILE: %w", key, err)
+		}
+		s.values[key] = strings.TrimRight(string(content), "\r\n")
+	}
+
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if value != "" {
+			s.values[key] = value
+		}
+	}
+
+	return s, nil
+}
+
+// loadFile читает YAML или TOML файл. Вложенные разделы превращаются в имена переменных окружения:
+// db: {host: x} соответствует DB_HOST.
+func (s *source) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	tree := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		return fmt.Errorf("неизвестный формат файла конфигурации, ожидается .yaml, .yml или .toml")
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.flatten("", tree)
+}
+
+// flatten записывает значения дерева настроек под ключами вида SECTION_NAME
+func (s *source) flatten(prefix string, tree map[string]interface{}) error {
+	for name, value := range tree {
+		key := strings.ToUpper(name)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if groupRoleKeys[key] {
+				// Порядок ключей таблицы не сохраняется, а роль выбирается по первому совпавшему правилу
+				return fmt.Errorf("%s: задайте сопоставление списком {group, role} в порядке приоритета", key)
+			}
+			if err := s.flatten(key, v); err != nil {
+				return err
+			}
+		case []interface{}:
+			if groupRoleKeys[key] {
+				groupRoles, err := joinGroupRoles(key, v)
+				if err != nil {
+					return err
+				}
+				s.values[key] = groupRoles
+				continue
+			}
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			s.values[key] = strings.Join(items, ",")
+		case nil:
+		default:
+			s.values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// joinGroupRoles преобразует список {group, role} в строку формата parseGroupRoles.
+// Порядок элементов сохраняется: он определяет приоритет правил.
+func joinGroupRoles(key string, items []interface{}) (string, error) {
+	pairs := make([]string, 0, len(items))
+	for i, item := range items {
+		mapping, ok := item.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s[%d]: ожидается {group, role}, получено %v", key, i, item)
+		}
+		if mapping["group"] == nil || mapping["role"] == nil {
+			return "", fmt.Errorf("%s[%d]: нужны group и role", key, i)
+		}
+		pairs = append(pairs, fmt.Sprintf("%v:%v", mapping["group"], mapping["role"]))
+	}
+	return strings.Join(pairs, ";"), nil
+}
+
+// get возвращает строковое значение настройки или значение по умолчанию
+func (s *source) get(key, defaultValue string) string {
+	if value := s.values[key]; value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// list возвращает непустые элементы значения, разделенного запятыми
+func (s *source) list(key, defaultValue string) []string {
+	var items []string
+	for _, item := range strings.Split(s.get(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// bool возвращает логическое значение настройки
+func (s *source) bool(key string, defaultValue bool) bool {
+	value := s.get(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: ожидается true или false, получено %q", key, value))
+		return defaultValue
+	}
+	return parsed
+}
+
+// int возвращает целое значение настройки
+func (s *source) int(key string, defaultValue int) int {
+	value := s.get(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: ожидается целое число, получено %q", key, value))
+		return defaultValue
+	}
+	return parsed
+}
+
+// float возвращает дробное значение настройки
+func (s *source) float(key string, defaultValue float64) float64 {
+	value := s.get(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: ожидается число, получено %q", key, value))
+		return defaultValue
+	}
+	return parsed
+}
+
+// duration возвращает длительность. Принимается формат Go ("15m", "24h") или целое число
+// в единицах unit — так значения из прежних версий (например, COOKIE_LIFETIME=3600) не меняют смысл.
+func (s *source) duration(key string, defaultValue, unit time.Duration) time.Duration {
+	value := s.get(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	if number, err := strconv.Atoi(value); err == nil {
+		return time.Duration(number) * unit
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: ожидается длительность (например, 15m или 24h), получено %q", key, value))
+		return defaultValue
+	}
+	return parsed
+}
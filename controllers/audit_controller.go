@@ -0,0 +1,140 @@
+// controllers/audit_controller.go - обработчики HTTP запросов для журнала аудита
+package controllers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"AuthApplications/dto"
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditController интерфейс контроллера журнала аудита
+type AuditController interface {
+	List(c *gin.Context)
+	Export(c *gin.Context)
+}
+
+// auditController реализация AuditController
+type auditController struct {
+	auditService services.AuditService
+	logger       *slog.Logger
+}
+
+// NewAuditController создает новый контроллер журнала аудита
+func NewAuditController(auditService services.AuditService, logger *slog.Logger) AuditController {
+	return &auditController{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// List godoc
+// @Summary Журнал аудита
+// @Description Возвращает события безопасности (новые первыми) с фильтрами и пагинацией
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param action query string false "Тип события, например login.failure"
+// @Param actor_id query string false "ID пользователя, выполнившего действие (или администратора при имперсонации)"
+// @Param target_id query string false "ID пользователя, над которым выполнено действие"
+// @Param user_id query string false "ID пользователя в любой роли (исполнитель, администратор или цель)"
+// @Param ip query string false "IP адрес клиента"
+// @Param request_id query string false "ID запроса"
+// @Param from query string false "Начало периода (RFC 3339)"
+// @Param to query string false "Конец периода, не включительно (RFC 3339)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param page_size query int false "Размер страницы (до 500)" default(50)
+// @Success 200 {object} dto.AuditListResponse "Страница журнала"
+// @Failure 400 {object} map[string]string "Некорректные параметры"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 403 {object} map[string]string "Нет прав доступа"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/audit [get]
+func (ctrl *auditController) List(c *gin.Context) {
+	filter, err := bindAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := ctrl.auditService.Find(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения журнала аудита"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Export godoc
+// @Summary Экспорт журнала аудита
+// @Description Выгружает все события по фильтрам в формате JSON Lines (одно событие на строку, в хронологическом порядке)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param action query string false "Тип события"
+// @Param actor_id query string false "ID пользователя, выполнившего действие"
+// @Param target_id query string false "ID пользователя, над которым выполнено действие"
+// @Param user_id query string false "ID пользователя в любой роли"
+// @Param ip query string false "IP адрес клиента"
+// @Param request_id query string false "ID запроса"
+// @Param from query string false "Начало периода (RFC 3339)"
+// @Param to query string false "Конец периода, не включительно (RFC 3339)"
+// @Success 200 {string} string "События в формате application/x-ndjson"
+// @Failure 400 {object} map[string]string "Некорректные параметры"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 403 {object} map[string]string "Нет прав доступа"
+// @Router /api/admin/audit/export [get]
+func (ctrl *auditController) Export(c *gin.Context) {
+	filter, err := bindAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	c.Status(http.StatusOK)
+
+	// Ответ уже начат, поэтому ошибка посреди выгрузки только обрывает поток
+	encoder := json.NewEncoder(c.Writer)
+	err = ctrl.auditService.Export(c.Request.Context(), filter, func(event dto.AuditEventResponse) error {
+		return encoder.Encode(event)
+	})
+	if err != nil {
+		ctrl.logger.ErrorContext(c.Request.Context(), "Ошибка экспорта журнала аудита", "error", err)
+		c.Abort()
+	}
+}
+
+// bindAuditFilter читает параметры журнала из строки запроса и разбирает идентификаторы
+func bindAuditFilter(c *gin.Context) (dto.AuditFilter, error) {
+	var query dto.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return dto.AuditFilter{}, err
+	}
+
+	filter := dto.AuditFilter{
+		Action:    query.Action,
+		IP:        query.IP,
+		RequestID: query.RequestID,
+		From:      query.From,
+		To:        query.To,
+		Page:      query.Page,
+		PageSize:  query.PageSize,
+	}
+	var err error
+	if filter.ActorID, err = optionalUUID("actor_id", query.ActorID); err != nil {
+		return dto.AuditFilter{}, err
+	}
+	if filter.TargetID, err = optionalUUID("target_id", query.TargetID); err != nil {
+		return dto.AuditFilter{}, err
+	}
+	if filter.UserID, err = optionalUUID("user_id", query.UserID); err != nil {
+		return dto.AuditFilter{}, err
+	}
+	return filter, nil
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"AuthApplications/dto"
+	"AuthApplications/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeAuditService запоминает фильтр последнего запроса к журналу
+type fakeAuditService struct {
+	filter    *dto.AuditFilter
+	exportErr error // ошибка, которой обрывается выгрузка после первого события
+}
+
+func (s *fakeAuditService) Record(context.Context, dto.RequestMeta, string, *uuid.UUID, *uuid.UUID, map[string]interface{}) error {
+	return nil
+}
+
+func (s *fakeAuditService) Find(ctx context.Context, filter dto.AuditFilter) (*dto.AuditListResponse, error) {
+	s.filter = &filter
+	return &dto.AuditListResponse{Items: []dto.AuditEventResponse{}, Page: filter.Page, PageSize: filter.PageSize}, nil
+}
+
+func (s *fakeAuditService) Export(ctx context.Context, filter dto.AuditFilter, fn func(event dto.AuditEventResponse) error) error {
+	s.filter = &filter
+	if err := fn(dto.AuditEventResponse{ID: uuid.New(), Action: "login.success"}); err != nil {
+		return err
+	}
+	return s.exportErr
+}
+
+func newAuditRouter(service *fakeAuditService) *gin.Engine {
+	return newAuditRouterWithLogger(service, logging.Nop())
+}
+
+func newAuditRouterWithLogger(service *fakeAuditService, logger *slog.Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	ctrl := NewAuditController(service, logger)
+	r := gin.New()
+	r.GET("/audit", ctrl.List)
+	r.GET("/audit/export", ctrl.Export)
+	return r
+}
+
+func TestAuditControllerFiltersByID(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		param string
+		field func(dto.AuditFilter) *uuid.UUID
+	}{
+		{"actor_id", func(f dto.AuditFilter) *uuid.UUID { return f.ActorID }},
+		{"target_id", func(f dto.AuditFilter) *uuid.UUID { return f.TargetID }},
+		{"user_id", func(f dto.AuditFilter) *uuid.UUID { return f.UserID }},
+	}
+
+	for _, tt := range tests {
+		for _, path := range []string{"/audit", "/audit/export"} {
+			t.Run(tt.param+path, func(t *testing.T) {
+				service := &fakeAuditService{}
+				w := httptest.NewRecorder()
+				newAuditRouter(service).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path+"?"+tt.param+"="+id.String(), nil))
+
+				if w.Code != http.StatusOK {
+					t.Fatalf("статус %d: %s", w.Code, w.Body.String())
+				}
+				if service.filter == nil {
+					t.Fatal("сервис не вызван")
+				}
+				if got := tt.field(*service.filter); got == nil || *got != id {
+					t.Fatalf("%s = %v, ожидалось %s", tt.param, got, id)
+				}
+			})
+		}
+	}
+}
+
+func TestAuditControllerRejectsMalformedID(t *testing.T) {
+	for _, param := range []string{"actor_id", "target_id", "user_id"} {
+		t.Run(param, func(t *testing.T) {
+			service := &fakeAuditService{}
+			w := httptest.NewRecorder()
+			newAuditRouter(service).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit?"+param+"=not-a-uuid", nil))
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("статус %d, ожидался 400", w.Code)
+			}
+			if service.filter != nil {
+				t.Fatal("сервис вызван с неверным фильтром")
+			}
+		})
+	}
+}
+
+func TestAuditControllerWithoutFilters(t *testing.T) {
+	service := &fakeAuditService{}
+	w := httptest.NewRecorder()
+	newAuditRouter(service).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("статус %d: %s", w.Code, w.Body.String())
+	}
+	if f := service.filter; f.ActorID != nil || f.TargetID != nil || f.UserID != nil || f.Page != 1 || f.PageSize != 50 {
+		t.Fatalf("фильтр по умолчанию: %+v", *f)
+	}
+}
+
+func TestAuditControllerLogsExportFailure(t *testing.T) {
+	var logs bytes.Buffer
+	service := &fakeAuditService{exportErr: errors.New("соединение с базой разорвано")}
+	r := newAuditRouterWithLogger(service, logging.New(&logs, "json", slog.LevelInfo))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit/export", nil))
+
+	// Начатый ответ не меняется, ошибка попадает в журнал приложения
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "login.success") {
+		t.Fatalf("status = %d, body = %q", w.Code, w.Body.String())
+	}
+	if !strings.Contains(logs.String(), "соединение с базой разорвано") {
+		t.Errorf("ошибка выгрузки не записана в журнал: %q", logs.String())
+	}
+}
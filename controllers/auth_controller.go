@@ -2,9 +2,11 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"AuthApplications/dto"
+	"AuthApplications/middleware"
 	"AuthApplications/services"
 	"AuthApplications/config"
 	"github.com/gin-gonic/gin"
@@ -20,12 +22,14 @@ type AuthController interface {
 // authController реализация AuthController
 type authController struct {
 	authService services.AuthService
+	cfg         *config.Config
 }
 
 // NewAuthController создает новый контроллер аутентификации
-func NewAuthController(authService services.AuthService) AuthController {
+func NewAuthController(authService services.AuthService, cfg *config.Config) AuthController {
 	return &authController{
 		authService: authService,
+		cfg:         cfg,
 	}
 }
 
@@ -47,7 +51,7 @@ func (ctrl *authController) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := ctrl.authService.Register(request)
+	user, err := ctrl.authService.Register(c.Request.Context(), request, requestMeta(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -61,7 +65,8 @@ func (ctrl *authController) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary Вход в систему
-// @Description Аутентифицирует пользователя и возвращает JWT токен
+// @Description Аутентифицирует пользователя и возвращает JWT токен.
+// @Description Если включен второй фактор, возвращается second_factor_required и токен для /api/auth/webauthn/mfa/*
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -69,6 +74,7 @@ func (ctrl *authController) Register(c *gin.Context) {
 // @Success 200 {object} dto.AuthResponse "Успешный вход в систему"
 // @Failure 400 {object} map[string]string "Ошибка валидации"
 // @Failure 401 {object} map[string]string "Неверные учетные данные"
+// @Failure 403 {object} map[string]string "Email занят пользователем другого источника или учетная запись заблокирована"
 // @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
 // @Router /api/auth/login [post]
 func (ctrl *authController) Login(c *gin.Context) {
@@ -78,48 +84,84 @@ func (ctrl *authController) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := ctrl.authService.Login(request)
+	response, err := ctrl.authService.Login(c.Request.Context(), request, requestMeta(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		if respondAccountBlocked(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrExternalAccountConflict):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка аутентификации"})
+		}
 		return
 	}
 
-	config, err := config.LoadConfig()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка загрузки конфигурации"})
+	respondLogin(c, ctrl.cfg, response)
+}
+
+// respondLogin отвечает на успешную проверку учетных данных. Полноценный токен
+// устанавливается в cookie; токен подтверждения второго фактора только возвращается в ответе.
+func respondLogin(c *gin.Context, cfg *config.Config, response *dto.AuthResponse) {
+	if response.SecondFactorRequired {
+		response.Message = "Требуется подтверждение входа ключом доступа"
+		c.JSON(http.StatusOK, response)
 		return
 	}
 
-	c.SetCookie("access_token", token, config.CookieLifetime, "/", config.CookieDomain, true, true)
+	setAccessTokenCookie(c, cfg, response.Token)
 
-	response := dto.AuthResponse{
-		Token:   token,
-		Message: "Успешный вход в систему",
-	}
+	response.Message = "Успешный вход в систему"
 
 	c.JSON(http.StatusOK, response)
 }
 
 // Logout godoc
 // @Summary Выход из системы
-// @Description Удаляет токен и завершает сессию пользователя
+// @Description Отзывает текущий токен, удаляет его из cookies и завершает сессию пользователя
 // @Tags auth
 // @Security BearerAuth
+// @Param X-CSRF-Token header string false "CSRF токен, обязателен при аутентификации cookie"
 // @Success 200 {object} map[string]string "Успешный выход из системы"
 // @Failure 401 {object} map[string]string "Пользователь не авторизован"
 // @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
 // @Router /api/auth/logout [post]
 func (ctrl *authController) Logout(c *gin.Context) {
-    err := ctrl.authService.Logout()
+    // Токен берется так же, как в AuthMiddleware: сначала cookie, затем заголовок Authorization
+    tokenString, _ := c.Cookie(middleware.AccessTokenCookieName)
+    if tokenString == "" {
+        tokenString = bearerToken(c)
+    }
+
+    err := ctrl.authService.Logout(c.Request.Context(), tokenString, requestMeta(c))
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при выходе из системы"})
         return
     }
 
     // Очистка токена в cookies
-    c.SetCookie("auth_token", "", -1, "/", "", true, true)
+    c.SetCookie(middleware.AccessTokenCookieName, "", -1, "/", ctrl.cfg.CookieDomain, true, true)
 
     c.JSON(http.StatusOK, gin.H{
         "message": "Успешный выход из системы",
     })
-}
\ No newline at end of file
+}
+
+// respondAccountBlocked отвечает 403 с причиной и сроком блокировки, если err — блокировка учетной записи
+func respondAccountBlocked(c *gin.Context, err error) bool {
+	var blocked *services.AccountBlockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, dto.AccountBlockedResponse{
+		Error:  blocked.Error(),
+		Status: blocked.Status,
+		Reason: blocked.Reason,
+		Until:  blocked.Until,
+	})
+	return true
+}
@@ -50,7 +50,7 @@ func (bc *bookController) CreateBook(c *gin.Context) {
         return
     }
 
-    createdBook, err := bc.bookService.CreateBook(request)
+    createdBook, err := bc.bookService.CreateBook(c.Request.Context(), request)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -75,7 +75,7 @@ func (bc *bookController) CreateBook(c *gin.Context) {
 // @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
 // @Router /api/books [get]
 func (bc *bookController) GetAllBooks(c *gin.Context) {
-    books, err := bc.bookService.GetAllBook()
+    books, err := bc.bookService.GetAllBook(c.Request.Context())
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -106,7 +106,7 @@ func (bc *bookController) GetByID(c *gin.Context) {
         return
     }
 
-    book, err := bc.bookService.GetByID(id)
+    book, err := bc.bookService.GetByID(c.Request.Context(), id)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -131,7 +131,7 @@ func (bc *bookController) GetByID(c *gin.Context) {
 func (bc *bookController) FindByGenre(c *gin.Context) {
     genre := c.Param("genre")
 
-    books, err := bc.bookService.FindByGenre(genre)
+    books, err := bc.bookService.FindByGenre(c.Request.Context(), genre)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -0,0 +1,64 @@
+// controllers/csrf_controller.go - выдача CSRF токена и установка cookie с токеном доступа
+package controllers
+
+import (
+	"net/http"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/middleware"
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CSRFController интерфейс контроллера CSRF токенов
+type CSRFController interface {
+	Token(c *gin.Context)
+}
+
+// csrfController реализация CSRFController
+type csrfController struct {
+	csrfService services.CSRFService
+	cfg         *config.Config
+}
+
+// NewCSRFController создает новый контроллер CSRF токенов
+func NewCSRFController(csrfService services.CSRFService, cfg *config.Config) CSRFController {
+	return &csrfController{
+		csrfService: csrfService,
+		cfg:         cfg,
+	}
+}
+
+// Token godoc
+// @Summary Получение CSRF токена
+// @Description Выдает CSRF токен и устанавливает его в cookie csrf_token.
+// @Description Клиенты, аутентифицированные cookie access_token, передают токен в заголовке X-CSRF-Token
+// @Description во всех запросах, кроме GET, HEAD и OPTIONS. С заголовком Authorization токен не нужен.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} dto.CSRFResponse "CSRF токен"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/csrf [get]
+func (ctrl *csrfController) Token(c *gin.Context) {
+	token, expiresAt, err := ctrl.csrfService.Issue()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка формирования CSRF токена"})
+		return
+	}
+
+	// Cookie доступен скрипту страницы: он копирует значение в заголовок X-CSRF-Token
+	c.SetSameSite(ctrl.cfg.CookieSameSite)
+	c.SetCookie(middleware.CSRFCookieName, token, int(ctrl.cfg.CSRFTokenTTL.Seconds()), "/", ctrl.cfg.CookieDomain, true, false)
+
+	c.JSON(http.StatusOK, dto.CSRFResponse{
+		CSRFToken: token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// setAccessTokenCookie устанавливает cookie с токеном доступа с настроенным атрибутом SameSite
+func setAccessTokenCookie(c *gin.Context, cfg *config.Config, token string) {
+	c.SetSameSite(cfg.CookieSameSite)
+	c.SetCookie(middleware.AccessTokenCookieName, token, int(cfg.CookieLifetime.Seconds()), "/", cfg.CookieDomain, true, true)
+}
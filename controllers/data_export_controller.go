@@ -0,0 +1,131 @@
+// controllers/data_export_controller.go - обработчики HTTP запросов для выгрузки данных пользователя
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DataExportController интерфейс контроллера выгрузки данных
+type DataExportController interface {
+	Request(c *gin.Context)
+	Get(c *gin.Context)
+	Download(c *gin.Context)
+}
+
+// dataExportController реализация DataExportController
+type dataExportController struct {
+	dataExportService services.DataExportService
+}
+
+// NewDataExportController создает новый контроллер выгрузки данных
+func NewDataExportController(dataExportService services.DataExportService) DataExportController {
+	return &dataExportController{
+		dataExportService: dataExportService,
+	}
+}
+
+// Request godoc
+// @Summary Запрос выгрузки персональных данных
+// @Description Ставит в очередь сборку ZIP архива с профилем, записями о чтении, книгами автора,
+// @Description историей входов и журналом аудита. Ссылка на скачивание отправляется на email
+// @Description и возвращается в GET /api/users/profile/export/{id}, когда архив готов.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} dto.DataExportResponse "Выгрузка поставлена в очередь"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/users/profile/export [post]
+func (ctrl *dataExportController) Request(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	export, err := ctrl.dataExportService.Request(c.Request.Context(), userID.(uuid.UUID), requestMeta(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка запроса выгрузки"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, export)
+}
+
+// Get godoc
+// @Summary Состояние выгрузки персональных данных
+// @Description Возвращает статус выгрузки и подписанную ссылку на скачивание готового архива
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID выгрузки"
+// @Success 200 {object} dto.DataExportResponse "Состояние выгрузки"
+// @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 404 {object} map[string]string "Выгрузка не найдена"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/users/profile/export/{id} [get]
+func (ctrl *dataExportController) Get(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	exportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID выгрузки"})
+		return
+	}
+
+	export, err := ctrl.dataExportService.Get(c.Request.Context(), userID.(uuid.UUID), exportID)
+	if err != nil {
+		if errors.Is(err, services.ErrDataExportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения выгрузки"})
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// Download godoc
+// @Summary Скачивание архива с персональными данными
+// @Description Отдает ZIP архив по подписанной ссылке; авторизация не требуется, пока ссылка не истекла
+// @Tags users
+// @Produce application/zip
+// @Param id path string true "ID выгрузки"
+// @Param expires query int true "Срок действия ссылки (Unix time)"
+// @Param signature query string true "Подпись ссылки"
+// @Success 200 {file} file "ZIP архив"
+// @Failure 403 {object} map[string]string "Недействительная или истекшая ссылка"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/users/profile/export/{id}/download [get]
+func (ctrl *dataExportController) Download(c *gin.Context) {
+	exportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": services.ErrInvalidDownloadLink.Error()})
+		return
+	}
+
+	archive, err := ctrl.dataExportService.Download(c.Request.Context(), exportID, c.Query("expires"), c.Query("signature"), requestMeta(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDownloadLink) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка скачивания выгрузки"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="data-export-`+exportID.String()+`.zip"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", archive)
+}
@@ -0,0 +1,130 @@
+// controllers/email_change_controller.go - обработчики HTTP запросов для смены email
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"AuthApplications/dto"
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// EmailChangeController интерфейс контроллера смены email
+type EmailChangeController interface {
+	RequestChange(c *gin.Context)
+	Confirm(c *gin.Context)
+	Cancel(c *gin.Context)
+}
+
+// emailChangeController реализация EmailChangeController
+type emailChangeController struct {
+	emailChangeService services.EmailChangeService
+}
+
+// NewEmailChangeController создает новый контроллер смены email
+func NewEmailChangeController(emailChangeService services.EmailChangeService) EmailChangeController {
+	return &emailChangeController{
+		emailChangeService: emailChangeService,
+	}
+}
+
+// RequestChange godoc
+// @Summary Запрос смены email
+// @Description Отправляет ссылку подтверждения на новый адрес и уведомление со ссылкой отмены на текущий.
+// @Description Email меняется только после перехода по ссылке подтверждения. Требует недавней аутентификации (см. /api/auth/reauthenticate).
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.EmailChangeRequest true "Новый email"
+// @Success 202 {object} map[string]string "Письма отправлены"
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 401 {object} map[string]string "Требуется повторная аутентификация"
+// @Failure 403 {object} map[string]string "Email управляется внешним провайдером"
+// @Failure 409 {object} map[string]string "Email уже используется"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/users/profile/email [post]
+func (ctrl *emailChangeController) RequestChange(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	var request dto.EmailChangeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.emailChangeService.RequestChange(c.Request.Context(), userID.(uuid.UUID), request, requestMeta(c)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEmailChangeNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка запроса смены email"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Ссылка подтверждения отправлена на новый email",
+	})
+}
+
+// Confirm godoc
+// @Summary Подтверждение смены email
+// @Description Применяет смену email по ссылке из письма на новый адрес
+// @Tags users
+// @Produce json
+// @Param token query string true "Токен из ссылки"
+// @Success 200 {object} map[string]string "Email изменен"
+// @Failure 400 {object} map[string]string "Недействительная или истекшая ссылка"
+// @Failure 409 {object} map[string]string "Email уже используется"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/users/email/confirm [get]
+func (ctrl *emailChangeController) Confirm(c *gin.Context) {
+	if err := ctrl.emailChangeService.Confirm(c.Request.Context(), c.Query("token"), requestMeta(c)); err != nil {
+		ctrl.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email изменен"})
+}
+
+// Cancel godoc
+// @Summary Отмена смены email
+// @Description Отменяет смену email по ссылке из письма на старый адрес.
+// @Description Если смена уже подтверждена, прежний email восстанавливается, а все сессии завершаются.
+// @Tags users
+// @Produce json
+// @Param token query string true "Токен из ссылки"
+// @Success 200 {object} map[string]string "Смена email отменена"
+// @Failure 400 {object} map[string]string "Недействительная или истекшая ссылка"
+// @Failure 409 {object} map[string]string "Прежний email уже занят"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/users/email/cancel [get]
+func (ctrl *emailChangeController) Cancel(c *gin.Context) {
+	if err := ctrl.emailChangeService.Cancel(c.Request.Context(), c.Query("token"), requestMeta(c)); err != nil {
+		ctrl.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Смена email отменена"})
+}
+
+// respondError преобразует ошибки ссылок смены email в HTTP ответ
+func (ctrl *emailChangeController) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidEmailChangeToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обработки ссылки"})
+	}
+}
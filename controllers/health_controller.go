@@ -0,0 +1,81 @@
+// controllers/health_controller.go - проверки живости и готовности для оркестратора и администратора
+package controllers
+
+import (
+	"net/http"
+
+	"AuthApplications/dto"
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthController интерфейс контроллера проверок состояния
+type HealthController interface {
+	Liveness(c *gin.Context)
+	Readiness(c *gin.Context)
+	Details(c *gin.Context)
+}
+
+// healthController реализация HealthController
+type healthController struct {
+	healthService services.HealthService
+}
+
+// NewHealthController создает новый контроллер проверок состояния
+func NewHealthController(healthService services.HealthService) HealthController {
+	return &healthController{
+		healthService: healthService,
+	}
+}
+
+// Liveness godoc
+// @Summary Проверка живости
+// @Description Отвечает, пока процесс способен обрабатывать запросы. Зависимости не проверяются.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string "Процесс работает"
+// @Router /healthz [get]
+func (ctrl *healthController) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": dto.HealthStatusOK})
+}
+
+// Readiness godoc
+// @Summary Проверка готовности
+// @Description Выполняет обязательные проверки: доступность базы данных, применение миграций, ключи подписи токенов.
+// @Description Подробности ошибок и задержки не раскрываются, они доступны в /api/admin/health.
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthReport "Сервис готов принимать запросы"
+// @Failure 503 {object} dto.HealthReport "Обязательная проверка не пройдена"
+// @Router /readyz [get]
+func (ctrl *healthController) Readiness(c *gin.Context) {
+	report := ctrl.healthService.Ready(c.Request.Context())
+	for i := range report.Checks {
+		report.Checks[i].LatencyMs = 0
+		report.Checks[i].Error = ""
+	}
+
+	c.JSON(reportStatusCode(report), report)
+}
+
+// Details godoc
+// @Summary Подробное состояние сервиса
+// @Description Выполняет все зарегистрированные проверки, включая необязательные, и возвращает задержку и ошибку каждой
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.HealthReport "Все обязательные проверки пройдены"
+// @Failure 503 {object} dto.HealthReport "Обязательная проверка не пройдена"
+// @Router /api/admin/health [get]
+func (ctrl *healthController) Details(c *gin.Context) {
+	report := ctrl.healthService.Report(c.Request.Context())
+	c.JSON(reportStatusCode(report), report)
+}
+
+// reportStatusCode возвращает 503, если не пройдена обязательная проверка
+func reportStatusCode(report dto.HealthReport) int {
+	if report.Status != dto.HealthStatusOK {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newHealthRouter(healthService services.HealthService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	ctrl := NewHealthController(healthService)
+	r := gin.New()
+	r.GET("/healthz", ctrl.Liveness)
+	r.GET("/readyz", ctrl.Readiness)
+	r.GET("/api/admin/health", ctrl.Details)
+	return r
+}
+
+// serveHealth выполняет запрос и разбирает отчет о состоянии
+func serveHealth(t *testing.T, r *gin.Engine, path string) (int, dto.HealthReport) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report dto.HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("ответ %s: %v", w.Body.String(), err)
+	}
+	return w.Code, report
+}
+
+func TestHealthControllerReadinessHidesFailureDetails(t *testing.T) {
+	healthService := services.NewHealthService(time.Second)
+	healthService.Register(services.NewHealthCheck("database", func(ctx context.Context) error {
+		return errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	}))
+	r := newHealthRouter(healthService)
+
+	code, report := serveHealth(t, r, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != dto.HealthStatusFail {
+		t.Fatalf("статус %d, отчет %+v", code, report)
+	}
+	if check := report.Checks[0]; check.Error != "" || check.LatencyMs != 0 {
+		t.Errorf("/readyz раскрывает подробности проверки: %+v", check)
+	}
+
+	code, report = serveHealth(t, r, "/api/admin/health")
+	if code != http.StatusServiceUnavailable || report.Checks[0].Error == "" {
+		t.Errorf("подробный отчет без ошибки: статус %d, %+v", code, report)
+	}
+
+	// Живость не зависит от состояния базы
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/healthz: статус %d", w.Code)
+	}
+}
+
+func TestHealthControllerReady(t *testing.T) {
+	healthService := services.NewHealthService(time.Second)
+	healthService.Register(services.NewHealthCheck("database", func(ctx context.Context) error { return nil }))
+
+	code, report := serveHealth(t, newHealthRouter(healthService), "/readyz")
+	if code != http.StatusOK || report.Status != dto.HealthStatusOK || len(report.Checks) != 1 {
+		t.Fatalf("статус %d, отчет %+v", code, report)
+	}
+}
@@ -0,0 +1,125 @@
+// controllers/impersonation_controller.go - обработчики HTTP запросов для входа администратора от имени пользователя
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ImpersonationController интерфейс контроллера имперсонации
+type ImpersonationController interface {
+	Start(c *gin.Context)
+	End(c *gin.Context)
+}
+
+// impersonationController реализация ImpersonationController
+type impersonationController struct {
+	authService services.AuthService
+	cfg         *config.Config
+}
+
+// NewImpersonationController создает новый контроллер имперсонации
+func NewImpersonationController(authService services.AuthService, cfg *config.Config) ImpersonationController {
+	return &impersonationController{
+		authService: authService,
+		cfg:         cfg,
+	}
+}
+
+// Start godoc
+// @Summary Вход от имени пользователя
+// @Description Выдает администратору короткоживущий токен с правами пользователя и claim "act".
+// @Description Токен возвращается только в теле ответа и не устанавливается в cookie. Начало сессии записывается в журнал аудита.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Param request body dto.ImpersonateRequest true "Причина имперсонации"
+// @Success 200 {object} dto.ImpersonateResponse "Токен имперсонации"
+// @Failure 400 {object} map[string]string "Неверный ID или не указана причина"
+// @Failure 403 {object} map[string]string "Имперсонация запрещена"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/users/{id}/impersonate [post]
+func (ctrl *impersonationController) Start(c *gin.Context) {
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
+
+	var request dto.ImpersonateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Повторная имперсонация из токена имперсонации не допускается
+	if _, impersonating := c.Get("impersonatorID"); impersonating {
+		c.JSON(http.StatusForbidden, gin.H{"error": services.ErrImpersonationForbidden.Error()})
+		return
+	}
+
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	token, err := ctrl.authService.Impersonate(c.Request.Context(), actorID.(uuid.UUID), targetID, request.Reason, requestMeta(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrImpersonationForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка имперсонации"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ImpersonateResponse{
+		Token:     token,
+		ExpiresIn: int(ctrl.cfg.ImpersonationTTL.Seconds()),
+		UserID:    targetID,
+	})
+}
+
+// End godoc
+// @Summary Завершение имперсонации
+// @Description Отзывает текущий токен имперсонации и записывает завершение в журнал аудита
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Имперсонация завершена"
+// @Failure 400 {object} map[string]string "Токен не является токеном имперсонации"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/impersonation/end [post]
+func (ctrl *impersonationController) End(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	if err := ctrl.authService.EndImpersonation(c.Request.Context(), claims, requestMeta(c)); err != nil {
+		if errors.Is(err, services.ErrNotImpersonating) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка завершения имперсонации"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Имперсонация завершена"})
+}
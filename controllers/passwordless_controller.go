@@ -0,0 +1,190 @@
+// controllers/passwordless_controller.go - обработчики HTTP запросов для входа без пароля
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+)
+
+// loginDeviceCookieName привязывает одноразовые коды к браузеру, в котором был запрошен вход
+const loginDeviceCookieName = "login_device"
+
+// PasswordlessController интерфейс контроллера входа без пароля
+type PasswordlessController interface {
+	RequestMagicLink(c *gin.Context)
+	VerifyMagicLink(c *gin.Context)
+	RequestOTP(c *gin.Context)
+	VerifyOTP(c *gin.Context)
+}
+
+// passwordlessController реализация PasswordlessController
+type passwordlessController struct {
+	passwordlessService services.PasswordlessService
+	cfg                 *config.Config
+}
+
+// NewPasswordlessController создает новый контроллер входа без пароля
+func NewPasswordlessController(passwordlessService services.PasswordlessService, cfg *config.Config) PasswordlessController {
+	return &passwordlessController{
+		passwordlessService: passwordlessService,
+		cfg:                 cfg,
+	}
+}
+
+// RequestMagicLink godoc
+// @Summary Запрос ссылки для входа
+// @Description Отправляет на email одноразовую ссылку для входа, привязанную к текущему браузеру
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.PasswordlessRequest true "Email пользователя"
+// @Success 202 {object} map[string]string "Если пользователь существует, письмо отправлено"
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/magic-link [post]
+func (ctrl *passwordlessController) RequestMagicLink(c *gin.Context) {
+	var request dto.PasswordlessRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deviceID, err := ctrl.ensureDeviceID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания сессии устройства"})
+		return
+	}
+
+	if err := ctrl.passwordlessService.RequestMagicLink(c.Request.Context(), request.Email, deviceID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка отправки ссылки для входа"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Если пользователь существует, ссылка для входа отправлена на email",
+	})
+}
+
+// VerifyMagicLink godoc
+// @Summary Вход по ссылке
+// @Description Проверяет одноразовую ссылку и выдает JWT токен
+// @Tags auth
+// @Produce json
+// @Param token query string true "Токен из ссылки"
+// @Success 200 {object} dto.AuthResponse "Успешный вход в систему"
+// @Failure 401 {object} map[string]string "Недействительная или истекшая ссылка"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/magic-link/verify [get]
+func (ctrl *passwordlessController) VerifyMagicLink(c *gin.Context) {
+	deviceID, _ := c.Cookie(loginDeviceCookieName)
+
+	response, err := ctrl.passwordlessService.RedeemMagicLink(c.Request.Context(), c.Query("token"), deviceID, requestMeta(c))
+	if err != nil {
+		ctrl.respondRedeemError(c, err)
+		return
+	}
+
+	respondLogin(c, ctrl.cfg, response)
+}
+
+// RequestOTP godoc
+// @Summary Запрос одноразового кода
+// @Description Отправляет на email шестизначный код для входа, привязанный к текущему браузеру
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.PasswordlessRequest true "Email пользователя"
+// @Success 202 {object} map[string]string "Если пользователь существует, код отправлен"
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/otp [post]
+func (ctrl *passwordlessController) RequestOTP(c *gin.Context) {
+	var request dto.PasswordlessRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deviceID, err := ctrl.ensureDeviceID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания сессии устройства"})
+		return
+	}
+
+	if err := ctrl.passwordlessService.RequestOTP(c.Request.Context(), request.Email, deviceID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка отправки кода"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Если пользователь существует, код для входа отправлен на email",
+	})
+}
+
+// VerifyOTP godoc
+// @Summary Вход по одноразовому коду
+// @Description Проверяет шестизначный код и выдает JWT токен
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.OTPVerifyRequest true "Email и код"
+// @Success 200 {object} dto.AuthResponse "Успешный вход в систему"
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 401 {object} map[string]string "Неверный или истекший код"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/otp/verify [post]
+func (ctrl *passwordlessController) VerifyOTP(c *gin.Context) {
+	var request dto.OTPVerifyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deviceID, _ := c.Cookie(loginDeviceCookieName)
+
+	response, err := ctrl.passwordlessService.RedeemOTP(c.Request.Context(), request.Email, request.Code, deviceID, requestMeta(c))
+	if err != nil {
+		ctrl.respondRedeemError(c, err)
+		return
+	}
+
+	respondLogin(c, ctrl.cfg, response)
+}
+
+// ensureDeviceID возвращает идентификатор браузера из cookie, создавая его при первом запросе
+func (ctrl *passwordlessController) ensureDeviceID(c *gin.Context) (string, error) {
+	if deviceID, err := c.Cookie(loginDeviceCookieName); err == nil && deviceID != "" {
+		return deviceID, nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	deviceID := hex.EncodeToString(buf)
+
+	// Lax, чтобы cookie передавался при переходе по ссылке из письма
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(loginDeviceCookieName, deviceID, 30*24*3600, "/api/auth", ctrl.cfg.CookieDomain, true, true)
+
+	return deviceID, nil
+}
+
+// respondRedeemError преобразует ошибку проверки кода в HTTP ответ
+func (ctrl *passwordlessController) respondRedeemError(c *gin.Context, err error) {
+	if respondAccountBlocked(c, err) {
+		return
+	}
+	if errors.Is(err, services.ErrInvalidLoginCode) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка аутентификации"})
+}
@@ -0,0 +1,21 @@
+// controllers/query_params.go - разбор параметров строки запроса
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// optionalUUID разбирает необязательный идентификатор из строки запроса.
+// Пустое значение означает отсутствие фильтра.
+func optionalUUID(name, value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("неверный формат %s: ожидается UUID", name)
+	}
+	return &id, nil
+}
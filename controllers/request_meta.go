@@ -0,0 +1,28 @@
+// controllers/request_meta.go - сведения о запросе для журнала аудита
+package controllers
+
+import (
+	"AuthApplications/dto"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requestMeta собирает сведения об источнике запроса из контекста (см. middleware)
+func requestMeta(c *gin.Context) dto.RequestMeta {
+	meta := dto.RequestMeta{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("requestID"),
+	}
+
+	if userID, ok := c.Get("userID"); ok {
+		id := userID.(uuid.UUID)
+		meta.ActorID = &id
+	}
+	if impersonatorID, ok := c.Get("impersonatorID"); ok {
+		id := impersonatorID.(uuid.UUID)
+		meta.ImpersonatorID = &id
+	}
+
+	return meta
+}
@@ -0,0 +1,138 @@
+// controllers/saml_controller.go - обработчики HTTP запросов для входа через SAML 2.0
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"AuthApplications/config"
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+)
+
+// samlRequestIDCookieName хранит ID отправленного AuthnRequest до возврата пользователя из IdP
+const samlRequestIDCookieName = "saml_request_id"
+
+// SAMLController интерфейс контроллера SAML
+type SAMLController interface {
+	Metadata(c *gin.Context)
+	Login(c *gin.Context)
+	ACS(c *gin.Context)
+}
+
+// samlController реализация SAMLController
+type samlController struct {
+	samlService services.SAMLService
+	cfg         *config.Config
+}
+
+// NewSAMLController создает новый контроллер SAML
+func NewSAMLController(samlService services.SAMLService, cfg *config.Config) SAMLController {
+	return &samlController{
+		samlService: samlService,
+		cfg:         cfg,
+	}
+}
+
+// Metadata godoc
+// @Summary Метаданные SAML Service Provider
+// @Description Возвращает XML метаданные SP для регистрации в Identity Provider
+// @Tags saml
+// @Produce xml
+// @Success 200 {string} string "XML метаданные"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/saml/metadata [get]
+func (ctrl *samlController) Metadata(c *gin.Context) {
+	metadata, err := ctrl.samlService.Metadata()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка формирования метаданных"})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// Login godoc
+// @Summary Вход через SAML
+// @Description Перенаправляет пользователя в Identity Provider с подписанным AuthnRequest
+// @Tags saml
+// @Param redirect query string false "Относительный путь для перенаправления после входа"
+// @Success 302 "Перенаправление в IdP"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/saml/login [get]
+func (ctrl *samlController) Login(c *gin.Context) {
+	redirectURL, requestID, err := ctrl.samlService.AuthnRequest(c.Query("redirect"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка формирования SAML запроса"})
+		return
+	}
+
+	// IdP возвращает пользователя POST-запросом с другого сайта, поэтому нужен SameSite=None
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(samlRequestIDCookieName, requestID, 600, "/api/auth/saml", ctrl.cfg.CookieDomain, true, true)
+
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// ACS godoc
+// @Summary Assertion Consumer Service
+// @Description Принимает SAML ответ от IdP, проверяет подпись и условия и выдает JWT токен
+// @Tags saml
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param SAMLResponse formData string true "SAML ответ в base64"
+// @Param RelayState formData string false "Относительный путь для перенаправления после входа"
+// @Success 200 {object} dto.AuthResponse "Успешный вход в систему"
+// @Success 303 "Перенаправление по RelayState"
+// @Failure 400 {object} map[string]string "Отсутствует SAML ответ"
+// @Failure 401 {object} map[string]string "Недействительный SAML ответ"
+// @Failure 403 {object} map[string]string "Email занят локальным пользователем"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/saml/acs [post]
+func (ctrl *samlController) ACS(c *gin.Context) {
+	samlResponse := c.PostForm("SAMLResponse")
+	if samlResponse == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Отсутствует SAMLResponse"})
+		return
+	}
+
+	var possibleRequestIDs []string
+	if requestID, err := c.Cookie(samlRequestIDCookieName); err == nil && requestID != "" {
+		possibleRequestIDs = append(possibleRequestIDs, requestID)
+	}
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(samlRequestIDCookieName, "", -1, "/api/auth/saml", ctrl.cfg.CookieDomain, true, true)
+
+	response, err := ctrl.samlService.ConsumeResponse(c.Request.Context(), samlResponse, possibleRequestIDs, requestMeta(c))
+	if err != nil {
+		if respondAccountBlocked(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidSAMLResponse):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrExternalAccountConflict):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка аутентификации"})
+		}
+		return
+	}
+
+	// При включенном втором факторе перенаправления нет: клиенту нужен токен подтверждения из ответа
+	if relayState := c.PostForm("RelayState"); !response.SecondFactorRequired && isLocalRedirect(relayState) {
+		setAccessTokenCookie(c, ctrl.cfg, response.Token)
+		c.Redirect(http.StatusSeeOther, relayState)
+		return
+	}
+
+	respondLogin(c, ctrl.cfg, response)
+}
+
+// isLocalRedirect разрешает перенаправление только на относительные пути этого сайта
+func isLocalRedirect(target string) bool {
+	return strings.HasPrefix(target, "/") &&
+		!strings.HasPrefix(target, "//") &&
+		!strings.HasPrefix(target, "/\\")
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeSAMLService возвращает заранее заданный результат разбора SAML ответа
+type fakeSAMLService struct {
+	response *dto.AuthResponse
+}
+
+func (s *fakeSAMLService) Metadata() ([]byte, error) {
+	return nil, nil
+}
+
+func (s *fakeSAMLService) AuthnRequest(string) (string, string, error) {
+	return "", "", nil
+}
+
+func (s *fakeSAMLService) ConsumeResponse(context.Context, string, []string, dto.RequestMeta) (*dto.AuthResponse, error) {
+	response := *s.response
+	return &response, nil
+}
+
+func postACS(service *fakeSAMLService, relayState string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	ctrl := NewSAMLController(service, &config.Config{})
+	r := gin.New()
+	r.POST("/api/auth/saml/acs", ctrl.ACS)
+
+	form := url.Values{"SAMLResponse": {"response"}, "RelayState": {relayState}}
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/saml/acs", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// accessTokenCookie возвращает значение cookie с токеном доступа, если она установлена
+func accessTokenCookie(w *httptest.ResponseRecorder) string {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == middleware.AccessTokenCookieName && cookie.MaxAge >= 0 {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func TestSAMLControllerACSRedirectsWithCookie(t *testing.T) {
+	w := postACS(&fakeSAMLService{response: &dto.AuthResponse{Token: "access"}}, "/books")
+
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/books" {
+		t.Fatalf("статус %d, Location %q", w.Code, w.Header().Get("Location"))
+	}
+	if accessTokenCookie(w) != "access" {
+		t.Fatal("токен доступа не установлен в cookie")
+	}
+}
+
+func TestSAMLControllerACSReturnsMFATokenWithoutRedirect(t *testing.T) {
+	w := postACS(&fakeSAMLService{response: &dto.AuthResponse{Token: "mfa", SecondFactorRequired: true}}, "/books")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("статус %d: %s", w.Code, w.Body.String())
+	}
+	if accessTokenCookie(w) != "" {
+		t.Fatal("токен подтверждения входа установлен в cookie")
+	}
+
+	var body dto.AuthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Token != "mfa" || !body.SecondFactorRequired {
+		t.Fatalf("ответ: %+v", body)
+	}
+}
@@ -0,0 +1,155 @@
+// controllers/step_up_controller.go - обработчики HTTP запросов для повторной аутентификации
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+)
+
+// StepUpController интерфейс контроллера повторной аутентификации
+type StepUpController interface {
+	Reauthenticate(c *gin.Context)
+	BeginWebAuthn(c *gin.Context)
+	FinishWebAuthn(c *gin.Context)
+}
+
+// stepUpController реализация StepUpController
+type stepUpController struct {
+	authService     services.AuthService
+	webAuthnService services.WebAuthnService
+	cfg             *config.Config
+}
+
+// NewStepUpController создает новый контроллер повторной аутентификации
+func NewStepUpController(
+	authService services.AuthService,
+	webAuthnService services.WebAuthnService,
+	cfg *config.Config,
+) StepUpController {
+	return &stepUpController{
+		authService:     authService,
+		webAuthnService: webAuthnService,
+		cfg:             cfg,
+	}
+}
+
+// Reauthenticate godoc
+// @Summary Повторная аутентификация паролем
+// @Description Проверяет пароль текущего пользователя и выдает токен с обновленным auth_time
+// @Description для удаления учетной записи и смены email. Срок действия токена не продлевается.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ReauthenticateRequest true "Пароль"
+// @Success 200 {object} dto.AuthResponse "Токен с обновленным auth_time"
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 401 {object} map[string]string "Неверный пароль"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/reauthenticate [post]
+func (ctrl *stepUpController) Reauthenticate(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	var request dto.ReauthenticateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := ctrl.authService.Reauthenticate(c.Request.Context(), claims, request.Password, requestMeta(c))
+	if err != nil {
+		if errors.Is(err, services.ErrReauthenticationFailed) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка повторной аутентификации"})
+		return
+	}
+
+	ctrl.respondToken(c, token)
+}
+
+// BeginWebAuthn godoc
+// @Summary Начало повторной аутентификации ключом доступа
+// @Description Возвращает параметры для navigator.credentials.get
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.WebAuthnBeginResponse "Параметры проверки"
+// @Failure 400 {object} map[string]string "У пользователя нет ключей"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/reauthenticate/webauthn/begin [post]
+func (ctrl *stepUpController) BeginWebAuthn(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	response, err := ctrl.webAuthnService.BeginReauthentication(c.Request.Context(), claims.UserID)
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// FinishWebAuthn godoc
+// @Summary Завершение повторной аутентификации ключом доступа
+// @Description Проверяет подпись ключа и выдает токен с обновленным auth_time. Срок действия токена не продлевается.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param session_id query string true "Идентификатор сессии из begin"
+// @Success 200 {object} dto.AuthResponse "Токен с обновленным auth_time"
+// @Failure 400 {object} map[string]string "Сессия не найдена"
+// @Failure 401 {object} map[string]string "Недействительный ключ"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/reauthenticate/webauthn/finish [post]
+func (ctrl *stepUpController) FinishWebAuthn(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	token, err := ctrl.webAuthnService.FinishReauthentication(c.Request.Context(), claims, c.Query("session_id"), c.Request.Body, requestMeta(c))
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	ctrl.respondToken(c, token)
+}
+
+// respondToken заменяет токен в cookie и возвращает его в ответе
+func (ctrl *stepUpController) respondToken(c *gin.Context, token string) {
+	setAccessTokenCookie(c, ctrl.cfg, token)
+
+	c.JSON(http.StatusOK, dto.AuthResponse{
+		Token:   token,
+		Message: "Повторная аутентификация выполнена",
+	})
+}
+
+// currentClaims возвращает claims токена текущего запроса (устанавливаются AuthMiddleware)
+func currentClaims(c *gin.Context) (*services.JWTClaim, bool) {
+	value, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*services.JWTClaim)
+	return claims, ok
+}
@@ -3,6 +3,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"AuthApplications/services"
@@ -10,6 +11,7 @@ import (
     
 	"github.com/google/uuid"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // UserController интерфейс контроллера пользователей
@@ -19,17 +21,23 @@ type UserController interface {
 	GetByID(c *gin.Context)
     PatchUser(c *gin.Context)
     DeleteUser(c *gin.Context)
+    GetLoginHistory(c *gin.Context)
+    RestoreUser(c *gin.Context)
+    SuspendUser(c *gin.Context)
+    UnsuspendUser(c *gin.Context)
 }
 
 // userController реализация UserController
 type userController struct {
-	userService services.UserService
+	userService         services.UserService
+	loginHistoryService services.LoginHistoryService
 }
 
 // NewUserController создает новый контроллер пользователей
-func NewUserController(userService services.UserService) UserController {
+func NewUserController(userService services.UserService, loginHistoryService services.LoginHistoryService) UserController {
 	return &userController{
-		userService: userService,
+		userService:         userService,
+		loginHistoryService: loginHistoryService,
 	}
 }
 
@@ -53,7 +61,7 @@ func (ctrl *userController) GetProfile(c *gin.Context) {
 	}
 
 	// Получить профиль пользователя
-	profile, err := ctrl.userService.GetUserProfile(userID.(uuid.UUID))
+	profile, err := ctrl.userService.GetUserProfile(c.Request.Context(), userID.(uuid.UUID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -62,6 +70,41 @@ func (ctrl *userController) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, profile)
 }
 
+// GetLoginHistory godoc
+// @Summary История входов
+// @Description Возвращает успешные и неудачные попытки входа текущего пользователя (новые первыми)
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Номер страницы" default(1)
+// @Param page_size query int false "Размер страницы (до 100)" default(20)
+// @Success 200 {object} dto.LoginHistoryResponse "История входов"
+// @Failure 400 {object} map[string]string "Некорректные параметры"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/users/profile/logins [get]
+func (ctrl *userController) GetLoginHistory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	var query dto.LoginHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := ctrl.loginHistoryService.List(c.Request.Context(), userID.(uuid.UUID), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения истории входов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
 // GetAllUsers godoc
 // @Summary Получение всех пользователей
 // @Description Возвращает список всех пользователей
@@ -75,7 +118,7 @@ func (ctrl *userController) GetProfile(c *gin.Context) {
 // @Router /api/users/all [get]
 func (ctrl *userController) GetAllUsers(c *gin.Context) {
     // Получить всех пользователей через UserService
-    users, err := ctrl.userService.GetAllUser()
+    users, err := ctrl.userService.GetAllUser(c.Request.Context())
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -107,7 +150,7 @@ func (ctrl *userController) GetByID(c *gin.Context) {
     }
 
     // Получить пользователя через UserService
-    user, err := ctrl.userService.GetByID(userID)
+    user, err := ctrl.userService.GetByID(c.Request.Context(), userID)
     if err != nil {
         if err.Error() == "record not found" {
             c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
@@ -122,7 +165,8 @@ func (ctrl *userController) GetByID(c *gin.Context) {
 
 // PatchUserRequsest godoc
 // @Summary Полное обновление пользователя
-// @Description Полностью обновляет данные пользователя по указанному ID
+// @Description Полностью обновляет данные пользователя по указанному ID.
+// @Description Email здесь не меняется — используйте POST /api/users/profile/email.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -132,6 +176,7 @@ func (ctrl *userController) GetByID(c *gin.Context) {
 // @Success 200 {object} dto.UserResponse "Пользователь обновлен"
 // @Failure 400 {object} map[string]string "Некорректный запрос"
 // @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 409 {object} map[string]string "Имя пользователя уже занято"
 // @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
 // @Router /api/users/{id} [patch]
 func (ctrl *userController) PatchUser(c *gin.Context) {
@@ -148,9 +193,13 @@ func (ctrl *userController) PatchUser(c *gin.Context) {
         return
     }
 
-    user, err := ctrl.userService.PatchUser(userID, request)
+    user, err := ctrl.userService.PatchUser(c.Request.Context(), userID, request, requestMeta(c))
     if err != nil {
-        if err.Error() == "record not found" {
+        if errors.Is(err, services.ErrEmailChangeRequiresConfirmation) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        } else if errors.Is(err, services.ErrUsernameTaken) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        } else if err.Error() == "record not found" {
             c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
         } else {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -163,14 +212,17 @@ func (ctrl *userController) PatchUser(c *gin.Context) {
 
 // DeleteUser godoc
 // @Summary Удаление пользователя
-// @Description Удаляет пользователя по указанному ID
+// @Description Мягко удаляет пользователя по указанному ID и назначает окончательное удаление после отсрочки.
+// @Description До этого срока удаление отменяется входом по паролю или администратором.
+// @Description Требует недавней аутентификации (см. /api/auth/reauthenticate).
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param id path string true "ID пользователя"
 // @Security BearerAuth
-// @Success 204 "Пользователь успешно удален"
+// @Success 202 {object} map[string]interface{} "Удаление пользователя запланировано"
 // @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 401 {object} map[string]string "Требуется повторная аутентификация"
 // @Failure 404 {object} map[string]string "Пользователь не найден"
 // @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
 // @Router /api/users/{id} [delete]
@@ -183,7 +235,7 @@ func (ctrl *userController) DeleteUser(c *gin.Context) {
     }
 
     // Удаляем пользователя через сервис
-    err = ctrl.userService.DeleteUser(userID)
+    purgeAt, err := ctrl.userService.DeleteUser(c.Request.Context(), userID, requestMeta(c))
     if err != nil {
         if err.Error() == "record not found" {
             c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
@@ -193,8 +245,126 @@ func (ctrl *userController) DeleteUser(c *gin.Context) {
         return
     }
 
-    c.JSON(http.StatusOK, gin.H{
-        "message": "Пользователь удален",
-        "user_id": userID,
+    c.JSON(http.StatusAccepted, gin.H{
+        "message":                "Пользователь удален",
+        "user_id":                userID,
+        "deletion_scheduled_for": purgeAt,
     })    
 }
+
+// RestoreUser godoc
+// @Summary Восстановление удаленного пользователя
+// @Description Отменяет удаление пользователя, пока не истекла отсрочка окончательного удаления
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Пользователь восстановлен"
+// @Failure 400 {object} map[string]string "Некорректный ID пользователя"
+// @Failure 404 {object} map[string]string "Пользователь не ожидает удаления"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/users/{id}/restore [post]
+func (ctrl *userController) RestoreUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID пользователя"})
+		return
+	}
+
+	if err := ctrl.userService.RestoreUser(c.Request.Context(), userID, requestMeta(c)); err != nil {
+		if errors.Is(err, services.ErrUserNotDeleted) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка восстановления пользователя"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Пользователь восстановлен"})
+}
+
+// SuspendUser godoc
+// @Summary Блокировка пользователя
+// @Description Приостанавливает (suspended) или блокирует (banned) пользователя с указанием причины
+// @Description и необязательного срока. Блокировка действует и для уже выданных токенов.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Param request body dto.SuspendRequest true "Статус, причина и срок"
+// @Security BearerAuth
+// @Success 200 {object} dto.UserResponse "Пользователь заблокирован"
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 403 {object} map[string]string "Нельзя заблокировать себя"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/users/{id}/suspend [post]
+func (ctrl *userController) SuspendUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID пользователя"})
+		return
+	}
+
+	var request dto.SuspendRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	user, err := ctrl.userService.SuspendUser(c.Request.Context(), actorID.(uuid.UUID), userID, request, requestMeta(c))
+	if err != nil {
+		ctrl.respondStatusError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UnsuspendUser godoc
+// @Summary Снятие блокировки пользователя
+// @Description Возвращает пользователя в статус active
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Security BearerAuth
+// @Success 200 {object} dto.UserResponse "Блокировка снята"
+// @Failure 400 {object} map[string]string "Некорректный ID пользователя"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/users/{id}/unsuspend [post]
+func (ctrl *userController) UnsuspendUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID пользователя"})
+		return
+	}
+
+	user, err := ctrl.userService.UnsuspendUser(c.Request.Context(), userID, requestMeta(c))
+	if err != nil {
+		ctrl.respondStatusError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// respondStatusError преобразует ошибки смены статуса пользователя в HTTP ответ
+func (ctrl *userController) respondStatusError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrCannotSuspendSelf):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidStatusUntil):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка изменения статуса пользователя"})
+	}
+}
@@ -0,0 +1,310 @@
+// controllers/webauthn_controller.go - обработчики HTTP запросов для ключей доступа (WebAuthn)
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/middleware"
+	"AuthApplications/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebAuthnController интерфейс контроллера ключей доступа
+type WebAuthnController interface {
+	BeginRegistration(c *gin.Context)
+	FinishRegistration(c *gin.Context)
+	BeginLogin(c *gin.Context)
+	FinishLogin(c *gin.Context)
+	BeginSecondFactor(c *gin.Context)
+	FinishSecondFactor(c *gin.Context)
+	ListCredentials(c *gin.Context)
+	DeleteCredential(c *gin.Context)
+	SetSecondFactor(c *gin.Context)
+}
+
+// webAuthnController реализация WebAuthnController
+type webAuthnController struct {
+	webAuthnService services.WebAuthnService
+	cfg             *config.Config
+}
+
+// NewWebAuthnController создает новый контроллер ключей доступа
+func NewWebAuthnController(webAuthnService services.WebAuthnService, cfg *config.Config) WebAuthnController {
+	return &webAuthnController{
+		webAuthnService: webAuthnService,
+		cfg:             cfg,
+	}
+}
+
+// BeginRegistration godoc
+// @Summary Начало регистрации ключа доступа
+// @Description Возвращает параметры для navigator.credentials.create и идентификатор сессии
+// @Tags webauthn
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.WebAuthnBeginResponse "Параметры регистрации"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/webauthn/register/begin [post]
+func (ctrl *webAuthnController) BeginRegistration(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	response, err := ctrl.webAuthnService.BeginRegistration(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// FinishRegistration godoc
+// @Summary Завершение регистрации ключа доступа
+// @Description Проверяет ответ аутентификатора (аттестация "none") и сохраняет ключ
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param session_id query string true "Идентификатор сессии из begin"
+// @Param name query string false "Название ключа"
+// @Success 201 {object} dto.CredentialResponse "Ключ зарегистрирован"
+// @Failure 400 {object} map[string]string "Сессия не найдена или ключ не прошел проверку"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/webauthn/register/finish [post]
+func (ctrl *webAuthnController) FinishRegistration(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	credential, err := ctrl.webAuthnService.FinishRegistration(
+		c.Request.Context(), userID.(uuid.UUID), c.Query("session_id"), c.Query("name"), c.Request.Body,
+	)
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, credential)
+}
+
+// BeginLogin godoc
+// @Summary Начало входа по ключу доступа
+// @Description Возвращает параметры для navigator.credentials.get без указания пользователя (passkey)
+// @Tags webauthn
+// @Produce json
+// @Success 200 {object} dto.WebAuthnBeginResponse "Параметры входа"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/webauthn/login/begin [post]
+func (ctrl *webAuthnController) BeginLogin(c *gin.Context) {
+	response, err := ctrl.webAuthnService.BeginLogin(c.Request.Context())
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// FinishLogin godoc
+// @Summary Завершение входа по ключу доступа
+// @Description Проверяет подпись и счетчик ключа и выдает JWT токен
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param session_id query string true "Идентификатор сессии из begin"
+// @Success 200 {object} dto.AuthResponse "Успешный вход в систему"
+// @Failure 400 {object} map[string]string "Сессия не найдена"
+// @Failure 401 {object} map[string]string "Ключ не прошел проверку"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/webauthn/login/finish [post]
+func (ctrl *webAuthnController) FinishLogin(c *gin.Context) {
+	response, err := ctrl.webAuthnService.FinishLogin(c.Request.Context(), c.Query("session_id"), c.Request.Body, requestMeta(c))
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	respondLogin(c, ctrl.cfg, response)
+}
+
+// BeginSecondFactor godoc
+// @Summary Начало подтверждения входа ключом доступа
+// @Description Принимает токен подтверждения, выданный /api/auth/login, и возвращает параметры для navigator.credentials.get
+// @Tags webauthn
+// @Produce json
+// @Param Authorization header string true "Bearer <токен подтверждения входа>"
+// @Success 200 {object} dto.WebAuthnBeginResponse "Параметры подтверждения"
+// @Failure 400 {object} map[string]string "У пользователя нет ключей"
+// @Failure 401 {object} map[string]string "Недействительный токен подтверждения"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/webauthn/mfa/begin [post]
+func (ctrl *webAuthnController) BeginSecondFactor(c *gin.Context) {
+	response, err := ctrl.webAuthnService.BeginSecondFactor(c.Request.Context(), bearerToken(c))
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// FinishSecondFactor godoc
+// @Summary Завершение подтверждения входа ключом доступа
+// @Description Проверяет подпись ключа и выдает полноценный JWT токен
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <токен подтверждения входа>"
+// @Param session_id query string true "Идентификатор сессии из begin"
+// @Success 200 {object} dto.AuthResponse "Успешный вход в систему"
+// @Failure 400 {object} map[string]string "Сессия не найдена"
+// @Failure 401 {object} map[string]string "Недействительный токен или ключ"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/webauthn/mfa/finish [post]
+func (ctrl *webAuthnController) FinishSecondFactor(c *gin.Context) {
+	response, err := ctrl.webAuthnService.FinishSecondFactor(c.Request.Context(), bearerToken(c), c.Query("session_id"), c.Request.Body, requestMeta(c))
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	respondLogin(c, ctrl.cfg, response)
+}
+
+// ListCredentials godoc
+// @Summary Список ключей доступа
+// @Description Возвращает ключи доступа текущего пользователя
+// @Tags webauthn
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.CredentialResponse "Ключи доступа"
+// @Failure 401 {object} map[string]string "Пользователь не авторизован"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/webauthn/credentials [get]
+func (ctrl *webAuthnController) ListCredentials(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	credentials, err := ctrl.webAuthnService.ListCredentials(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// DeleteCredential godoc
+// @Summary Удаление ключа доступа
+// @Description Удаляет ключ доступа текущего пользователя; после удаления последнего ключа второй фактор отключается
+// @Description Требует недавней аутентификации (см. /api/auth/reauthenticate).
+// @Tags webauthn
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID ключа"
+// @Success 200 {object} map[string]string "Ключ удален"
+// @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 401 {object} map[string]string "Требуется повторная аутентификация"
+// @Failure 404 {object} map[string]string "Ключ не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/webauthn/credentials/{id} [delete]
+func (ctrl *webAuthnController) DeleteCredential(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	credentialID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID ключа"})
+		return
+	}
+
+	if err := ctrl.webAuthnService.DeleteCredential(c.Request.Context(), userID.(uuid.UUID), credentialID); err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ключ доступа удален"})
+}
+
+// SetSecondFactor godoc
+// @Summary Ключ доступа как второй фактор
+// @Description Включает или выключает обязательное подтверждение входа по паролю ключом доступа
+// @Description Требует недавней аутентификации (см. /api/auth/reauthenticate).
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SecondFactorRequest true "Включить или выключить"
+// @Success 200 {object} map[string]interface{} "Настройка сохранена"
+// @Failure 400 {object} map[string]string "Нет зарегистрированных ключей"
+// @Failure 401 {object} map[string]string "Требуется повторная аутентификация"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/auth/webauthn/mfa [put]
+func (ctrl *webAuthnController) SetSecondFactor(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не авторизован"})
+		return
+	}
+
+	var request dto.SecondFactorRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.webAuthnService.SetSecondFactor(c.Request.Context(), userID.(uuid.UUID), request.Enabled); err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mfa_enabled": request.Enabled})
+}
+
+// respondWebAuthnError преобразует ошибку сервиса ключей доступа в HTTP ответ
+func respondWebAuthnError(c *gin.Context, err error) {
+	if respondAccountBlocked(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrWebAuthnSession), errors.Is(err, services.ErrNoCredentials):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWebAuthnVerification),
+		errors.Is(err, services.ErrCredentialCloned),
+		errors.Is(err, services.ErrInvalidMFAToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCredentialNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обработки ключа доступа"})
+	}
+}
+
+// bearerToken извлекает токен из заголовка Authorization
+func bearerToken(c *gin.Context) string {
+	authHeader := c.GetHeader(middleware.AuthorizationHeaderKey)
+	if !strings.HasPrefix(authHeader, middleware.BearerSchema) {
+		return ""
+	}
+	return authHeader[len(middleware.BearerSchema):]
+}
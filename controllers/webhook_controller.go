@@ -0,0 +1,186 @@
+// controllers/webhook_controller.go - обработчики HTTP запросов для управления webhook
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"AuthApplications/dto"
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebhookController интерфейс контроллера webhook
+type WebhookController interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Delete(c *gin.Context)
+	ListDeliveries(c *gin.Context)
+	RetryDelivery(c *gin.Context)
+}
+
+// webhookController реализация WebhookController
+type webhookController struct {
+	webhookService services.WebhookService
+}
+
+// NewWebhookController создает новый контроллер webhook
+func NewWebhookController(webhookService services.WebhookService) WebhookController {
+	return &webhookController{
+		webhookService: webhookService,
+	}
+}
+
+// Create godoc
+// @Summary Регистрация webhook
+// @Description Регистрирует адрес для доставки событий. Секрет для проверки подписи X-Webhook-Signature возвращается только в этом ответе.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param webhook body dto.WebhookRequest true "Параметры webhook"
+// @Success 201 {object} dto.WebhookResponse "Webhook зарегистрирован"
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/webhooks [post]
+func (ctrl *webhookController) Create(c *gin.Context) {
+	var request dto.WebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := ctrl.webhookService.CreateWebhook(c.Request.Context(), request)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebhook) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка регистрации webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// List godoc
+// @Summary Список webhook
+// @Description Возвращает зарегистрированные webhook без секретов
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.WebhookResponse "Список webhook"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/webhooks [get]
+func (ctrl *webhookController) List(c *gin.Context) {
+	webhooks, err := ctrl.webhookService.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения списка webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// Delete godoc
+// @Summary Удаление webhook
+// @Description Удаляет webhook и все его ожидающие доставки
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID webhook"
+// @Success 200 {object} map[string]string "Webhook удален"
+// @Failure 400 {object} map[string]string "Неверный формат ID"
+// @Failure 404 {object} map[string]string "Webhook не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/webhooks/{id} [delete]
+func (ctrl *webhookController) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
+
+	if err := ctrl.webhookService.DeleteWebhook(c.Request.Context(), id); err != nil {
+		if errors.Is(err, services.ErrWebhookNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook удален"})
+}
+
+// ListDeliveries godoc
+// @Summary Доставки событий
+// @Description Возвращает доставки событий на webhook. По умолчанию — исчерпавшие попытки (dead letter).
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Статус доставки" Enums(pending, delivered, dead) default(dead)
+// @Param webhook_id query string false "ID webhook"
+// @Param page query int false "Номер страницы" default(1)
+// @Param page_size query int false "Размер страницы (до 500)" default(50)
+// @Success 200 {object} dto.WebhookDeliveryListResponse "Страница доставок"
+// @Failure 400 {object} map[string]string "Некорректные параметры"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/webhooks/deliveries [get]
+func (ctrl *webhookController) ListDeliveries(c *gin.Context) {
+	var query dto.WebhookDeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	webhookID, err := optionalUUID("webhook_id", query.WebhookID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := ctrl.webhookService.ListDeliveries(c.Request.Context(), dto.WebhookDeliveryFilter{
+		Status:    query.Status,
+		WebhookID: webhookID,
+		Page:      query.Page,
+		PageSize:  query.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения списка доставок"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RetryDelivery godoc
+// @Summary Повторная доставка события
+// @Description Возвращает недоставленное событие в очередь с обнулением счетчика попыток
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID доставки"
+// @Success 200 {object} map[string]string "Доставка поставлена в очередь"
+// @Failure 400 {object} map[string]string "Неверный формат ID"
+// @Failure 404 {object} map[string]string "Недоставленное событие не найдено"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /api/admin/webhooks/deliveries/{id}/retry [post]
+func (ctrl *webhookController) RetryDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
+
+	if err := ctrl.webhookService.RetryDelivery(c.Request.Context(), id); err != nil {
+		if errors.Is(err, services.ErrDeliveryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка постановки доставки в очередь"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Доставка поставлена в очередь"})
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"AuthApplications/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeWebhookService запоминает фильтр последнего запроса списка доставок
+type fakeWebhookService struct {
+	filter *dto.WebhookDeliveryFilter
+}
+
+func (s *fakeWebhookService) CreateWebhook(context.Context, dto.WebhookRequest) (*dto.WebhookResponse, error) {
+	return &dto.WebhookResponse{}, nil
+}
+
+func (s *fakeWebhookService) ListWebhooks(ctx context.Context) ([]dto.WebhookResponse, error) {
+	return nil, nil
+}
+
+func (s *fakeWebhookService) DeleteWebhook(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func (s *fakeWebhookService) ListDeliveries(ctx context.Context, filter dto.WebhookDeliveryFilter) (*dto.WebhookDeliveryListResponse, error) {
+	s.filter = &filter
+	return &dto.WebhookDeliveryListResponse{Items: []dto.WebhookDeliveryResponse{}}, nil
+}
+
+func (s *fakeWebhookService) RetryDelivery(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func newWebhookRouter(service *fakeWebhookService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	ctrl := NewWebhookController(service)
+	r := gin.New()
+	r.GET("/webhooks/deliveries", ctrl.ListDeliveries)
+	return r
+}
+
+func TestWebhookControllerListDeliveriesFiltersByWebhookID(t *testing.T) {
+	id := uuid.New()
+	service := &fakeWebhookService{}
+	w := httptest.NewRecorder()
+	newWebhookRouter(service).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries?status=pending&webhook_id="+id.String(), nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("статус %d: %s", w.Code, w.Body.String())
+	}
+	if f := service.filter; f == nil || f.WebhookID == nil || *f.WebhookID != id || f.Status != "pending" {
+		t.Fatalf("фильтр: %+v", service.filter)
+	}
+}
+
+func TestWebhookControllerListDeliveriesDefaults(t *testing.T) {
+	service := &fakeWebhookService{}
+	w := httptest.NewRecorder()
+	newWebhookRouter(service).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("статус %d: %s", w.Code, w.Body.String())
+	}
+	if f := service.filter; f.WebhookID != nil || f.Status != "dead" || f.Page != 1 || f.PageSize != 50 {
+		t.Fatalf("фильтр по умолчанию: %+v", *f)
+	}
+}
+
+func TestWebhookControllerListDeliveriesRejectsMalformedID(t *testing.T) {
+	service := &fakeWebhookService{}
+	w := httptest.NewRecorder()
+	newWebhookRouter(service).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries?webhook_id=42", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("статус %d, ожидался 400", w.Code)
+	}
+	if service.filter != nil {
+		t.Fatal("сервис вызван с неверным фильтром")
+	}
+}
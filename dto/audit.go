@@ -0,0 +1,69 @@
+// dto/audit.go - структуры журнала аудита
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestMeta описывает источник запроса для записи в журнал аудита
+type RequestMeta struct {
+	ActorID        *uuid.UUID
+	ImpersonatorID *uuid.UUID
+	IP             string
+	UserAgent      string
+	RequestID      string
+}
+
+// AuditQuery представляет параметры запроса журнала аудита.
+// Идентификаторы принимаются строками и разбираются контроллером в AuditFilter.
+type AuditQuery struct {
+	Action    string     `form:"action"`
+	ActorID   string     `form:"actor_id"`
+	TargetID  string     `form:"target_id"`
+	UserID    string     `form:"user_id"` // пользователь в любой роли: исполнитель, администратор или цель
+	IP        string     `form:"ip"`
+	RequestID string     `form:"request_id"`
+	From      *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page      int        `form:"page,default=1" binding:"min=1"`
+	PageSize  int        `form:"page_size,default=50" binding:"min=1,max=500"`
+}
+
+// AuditFilter представляет фильтры и параметры пагинации журнала аудита
+type AuditFilter struct {
+	Action    string
+	ActorID   *uuid.UUID
+	TargetID  *uuid.UUID
+	UserID    *uuid.UUID
+	IP        string
+	RequestID string
+	From      *time.Time
+	To        *time.Time
+	Page      int
+	PageSize  int
+}
+
+// AuditEventResponse представляет запись журнала аудита
+type AuditEventResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	Action         string          `json:"action"`
+	ActorID        *uuid.UUID      `json:"actor_id"`
+	ImpersonatorID *uuid.UUID      `json:"impersonator_id,omitempty"`
+	TargetID       *uuid.UUID      `json:"target_id"`
+	IP             string          `json:"ip"`
+	UserAgent      string          `json:"user_agent"`
+	RequestID      string          `json:"request_id"`
+	Details        json.RawMessage `json:"details,omitempty" swaggertype:"object"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// AuditListResponse представляет страницу журнала аудита
+type AuditListResponse struct {
+	Items    []AuditEventResponse `json:"items"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
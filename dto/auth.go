@@ -1,26 +1,62 @@
 // dto/auth.go - структуры для передачи данных
 package dto
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // RegisterRequest представляет запрос на регистрацию
 type RegisterRequest struct {
-	Username  string `json:"username"`
-	Email     string `json:"email" binding:"required" example:"user@example.com"`
+	Username  string `json:"username" binding:"omitempty,username" example:"john_doe"` // 3-32 символа: латиница, цифры, . _ -
+	Email     string `json:"email" binding:"required,email" example:"user@example.com"`
 	Password  string `json:"password" binding:"required,min=6"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 }
 
+// ResetPasswordRequest представляет административный сброс пароля
+type ResetPasswordRequest struct {
+	Identifier string `binding:"required"` // email или имя пользователя
+	Password   string `binding:"required,min=6"`
+}
+
+// KeyRotation представляет результат ротации секрета подписи JWT
+type KeyRotation struct {
+	KeyID           string
+	Secret          string
+	PreviousSecrets []string // значение JWT_PREVIOUS_SECRETS; пусто, если старый секрет скомпрометирован
+	SessionsRevoked int64
+}
+
 // LoginRequest представляет запрос на вход
 type LoginRequest struct {
-	Email string `json:"email" binding:"required" example:"user@example.com"`
+	Identifier string `json:"identifier" binding:"required_without=Email" example:"user@example.com"` // email или имя пользователя
+	Email string `json:"email" example:"user@example.com"` // устарело, используйте identifier
 	Password string `json:"password" binding:"required" example:"string"`
 }
 
+// LoginIdentifier возвращает identifier, а для старых клиентов — email
+func (r LoginRequest) LoginIdentifier() string {
+	if r.Identifier != "" {
+		return r.Identifier
+	}
+	return r.Email
+}
+
 // AuthResponse представляет ответ после аутентификации
 type AuthResponse struct {
 	Token   string `json:"token"`
 	Message string `json:"message,omitempty"`
+	// SecondFactorRequired означает, что Token годится только для подтверждения входа ключом доступа
+	SecondFactorRequired bool `json:"second_factor_required,omitempty"`
+}
+
+// CSRFResponse представляет CSRF токен для заголовка X-CSRF-Token
+type CSRFResponse struct {
+	CSRFToken string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // UserResponse представляет информацию о пользователе в ответе
@@ -31,12 +67,51 @@ type UserResponse struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Role      string `json:"role"`
+	Status    string `json:"status,omitempty"` // active, suspended или banned
 }
 
 type PatchUserRequsest struct {
-    Username  *string `json:"username,omitempty"`
+    Username  *string `json:"username,omitempty" binding:"omitempty,username"`
     Email     *string `json:"email,omitempty"`
     FirstName *string `json:"first_name,omitempty"`
     LastName  *string `json:"last_name,omitempty"`
     Role      *string `json:"role,omitempty"`
 }
+
+// SuspendRequest представляет запрос администратора на блокировку пользователя
+type SuspendRequest struct {
+	Status string     `json:"status" binding:"omitempty,oneof=suspended banned" example:"suspended"` // по умолчанию suspended
+	Reason string     `json:"reason" binding:"required"`
+	Until  *time.Time `json:"until"` // пусто — бессрочно
+}
+
+// AccountBlockedResponse возвращается при попытке входа или запросе с токеном заблокированного пользователя
+type AccountBlockedResponse struct {
+	Error  string     `json:"error"`
+	Status string     `json:"status" example:"suspended"`
+	Reason string     `json:"reason,omitempty"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// ImpersonateRequest представляет запрос администратора на вход от имени пользователя
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ImpersonateResponse содержит токен имперсонации
+type ImpersonateResponse struct {
+	Token     string    `json:"token"`
+	ExpiresIn int       `json:"expires_in"` // секунды
+	UserID    uuid.UUID `json:"user_id"`
+}
+
+// ReauthenticateRequest представляет запрос на повторный ввод пароля перед чувствительной операцией
+type ReauthenticateRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// EmailChangeRequest представляет запрос на смену email с подтверждением
+type EmailChangeRequest struct {
+	NewEmail       string `json:"new_email" binding:"required,email" example:"new@example.com"`
+	RevokeSessions bool   `json:"revoke_sessions"` // завершить все сессии после подтверждения
+}
@@ -0,0 +1,31 @@
+// dto/data_export.go - структуры выгрузки персональных данных
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DataExportResponse представляет состояние выгрузки данных пользователя
+type DataExportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status" example:"pending"`
+	Error       string     `json:"error,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"` // подписанная ссылка, только для статуса ready
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ReadingRecordExport представляет запись о чтении книги в архиве выгрузки
+type ReadingRecordExport struct {
+	ID           uuid.UUID `json:"id"`
+	BookID       uuid.UUID `json:"book_id"`
+	IsFavorite   bool      `json:"is_favorite"`
+	ReadingState string    `json:"reading_state"`
+	ReadProgress int       `json:"reading_progress"`
+	LastReadPage int       `json:"last_read_page"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,26 @@
+// dto/health.go - результаты проверок состояния сервиса
+package dto
+
+import "time"
+
+// Состояния проверок и сервиса в целом
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheckResult результат одной проверки
+type HealthCheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`             // влияет ли проверка на готовность (/readyz)
+	LatencyMs float64 `json:"latency_ms,omitempty"` // только в подробном отчете
+	Error     string  `json:"error,omitempty"`      // только в подробном отчете
+}
+
+// HealthReport сводный отчет о состоянии сервиса
+type HealthReport struct {
+	Status    string              `json:"status"`
+	CheckedAt time.Time           `json:"checked_at"`
+	Checks    []HealthCheckResult `json:"checks"`
+}
@@ -0,0 +1,34 @@
+// dto/login_history.go - структуры истории входов пользователя
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginHistoryQuery представляет параметры пагинации истории входов
+type LoginHistoryQuery struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// LoginEventResponse представляет попытку входа
+type LoginEventResponse struct {
+	ID                uuid.UUID `json:"id"`
+	Success           bool      `json:"success"`
+	Method            string    `json:"method"`
+	IP                string    `json:"ip"`
+	UserAgent         string    `json:"user_agent"`
+	DeviceFingerprint string    `json:"device_fingerprint"`
+	NewDevice         bool      `json:"new_device"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// LoginHistoryResponse представляет страницу истории входов
+type LoginHistoryResponse struct {
+	Items    []LoginEventResponse `json:"items"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
@@ -0,0 +1,13 @@
+// dto/passwordless.go - структуры запросов для входа без пароля
+package dto
+
+// PasswordlessRequest представляет запрос на отправку ссылки или кода для входа
+type PasswordlessRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+// OTPVerifyRequest представляет запрос на вход по одноразовому коду
+type OTPVerifyRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+	Code  string `json:"code" binding:"required,len=6,numeric" example:"123456"`
+}
@@ -0,0 +1,30 @@
+// dto/validation.go - собственные правила валидации запросов
+package dto
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// usernamePattern допускает 3-32 символа: латинские буквы, цифры, точку, подчеркивание и дефис,
+// начиная с буквы или цифры. Символ "@" запрещен, чтобы имя не путалось с email при входе.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{2,31}$`)
+
+// ValidUsername проверяет формат имени пользователя
+func ValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+// RegisterValidators регистрирует правила валидации, используемые в тегах binding
+func RegisterValidators() error {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+
+	return validate.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return ValidUsername(fl.Field().String())
+	})
+}
@@ -0,0 +1,26 @@
+package dto
+
+import "testing"
+
+func TestValidUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		valid    bool
+	}{
+		{username: "john_doe", valid: true},
+		{username: "J.Doe-42", valid: true},
+		{username: "abc", valid: true},
+		{username: "ab", valid: false},
+		{username: "a234567890123456789012345678901234", valid: false},
+		{username: "_john", valid: false},
+		{username: "john@example.com", valid: false},
+		{username: "john doe", valid: false},
+		{username: "иван", valid: false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidUsername(tt.username); got != tt.valid {
+			t.Errorf("ValidUsername(%q) = %v, ожидалось %v", tt.username, got, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// dto/webauthn.go - структуры для регистрации и входа по ключам доступа
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnBeginResponse содержит параметры церемонии для navigator.credentials
+type WebAuthnBeginResponse struct {
+	SessionID string      `json:"session_id"`
+	Options   interface{} `json:"options"`
+}
+
+// CredentialResponse представляет зарегистрированный ключ доступа
+type CredentialResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// SecondFactorRequest включает или выключает ключ доступа как второй фактор после пароля
+type SecondFactorRequest struct {
+	Enabled bool `json:"enabled"`
+}
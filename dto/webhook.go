@@ -0,0 +1,67 @@
+// dto/webhook.go - структуры для управления webhook и просмотра доставок
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookRequest представляет запрос на регистрацию webhook
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url" example:"https://example.com/hooks/auth"`
+	EventTypes  []string `json:"event_types" example:"user.registered,book.created"` // пусто — все события
+	Description string   `json:"description"`
+}
+
+// WebhookResponse представляет зарегистрированный webhook
+type WebhookResponse struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	EventTypes  []string  `json:"event_types"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"` // возвращается только при создании
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryQuery представляет параметры запроса списка доставок.
+// Идентификатор webhook принимается строкой и разбирается контроллером в WebhookDeliveryFilter.
+type WebhookDeliveryQuery struct {
+	Status    string `form:"status,default=dead" binding:"omitempty,oneof=pending delivered dead"`
+	WebhookID string `form:"webhook_id"`
+	Page      int    `form:"page,default=1" binding:"min=1"`
+	PageSize  int    `form:"page_size,default=50" binding:"min=1,max=500"`
+}
+
+// WebhookDeliveryFilter представляет фильтры и параметры пагинации списка доставок
+type WebhookDeliveryFilter struct {
+	Status    string
+	WebhookID *uuid.UUID
+	Page      int
+	PageSize  int
+}
+
+// WebhookDeliveryResponse представляет доставку события на webhook
+type WebhookDeliveryResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	WebhookID      uuid.UUID  `json:"webhook_id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WebhookDeliveryListResponse представляет страницу доставок
+type WebhookDeliveryListResponse struct {
+	Items    []WebhookDeliveryResponse `json:"items"`
+	Total    int64                     `json:"total"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+}
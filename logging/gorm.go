@@ -0,0 +1,88 @@
+// logging/gorm.go - журнал GORM через slog без значений параметров запросов
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold длительность, начиная с которой запрос записывается как медленный
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger пишет ошибки и медленные запросы GORM в slog.
+// Текст SQL записывается с плейсхолдерами: значения параметров (email, хеши паролей) в журнал не попадают.
+type gormLogger struct {
+	logger *slog.Logger
+	level  gormlogger.LogLevel
+}
+
+// NewGormLogger создает журнал GORM поверх logger
+func NewGormLogger(logger *slog.Logger) gormlogger.Interface {
+	return &gormLogger{logger: logger, level: gormlogger.Warn}
+}
+
+// LogMode возвращает журнал с другим уровнем (db.Debug())
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &gormLogger{logger: l.logger, level: level}
+}
+
+// Info записывает информационное сообщение GORM
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Warn записывает предупреждение GORM
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Error записывает ошибку GORM
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace записывает запрос: ошибки (кроме отсутствия записи) и медленные запросы,
+// а при уровне Info — все запросы на уровне debug
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		l.logger.ErrorContext(ctx, "ошибка запроса к базе данных",
+			slog.String("sql", sql), slog.Int64("rows", rows), slog.Float64("duration_ms", milliseconds(elapsed)), slog.Any("error", err))
+	case elapsed > slowQueryThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		l.logger.WarnContext(ctx, "медленный запрос к базе данных",
+			slog.String("sql", sql), slog.Int64("rows", rows), slog.Float64("duration_ms", milliseconds(elapsed)))
+	case l.level >= gormlogger.Info:
+		sql, rows := fc()
+		l.logger.DebugContext(ctx, "запрос к базе данных",
+			slog.String("sql", sql), slog.Int64("rows", rows), slog.Float64("duration_ms", milliseconds(elapsed)))
+	}
+}
+
+// ParamsFilter отбрасывает значения параметров, чтобы GORM не подставлял их в текст SQL
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// milliseconds переводит длительность в миллисекунды для журнала
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type loggedUser struct {
+	ID    int
+	Email string
+}
+
+func TestGormLoggerOmitsQueryParameters(t *testing.T) {
+	var buf bytes.Buffer
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:                 NewGormLogger(New(&buf, FormatJSON, slog.LevelDebug)),
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "logged_users"`).
+		WithArgs("alice@example.com", 1).
+		WillReturnError(errors.New("connection reset"))
+
+	var user loggedUser
+	if err := db.Where("email = ?", "alice@example.com").First(&user).Error; err == nil {
+		t.Fatal("ошибка запроса потеряна")
+	}
+
+	records := decodeLines(t, &buf)
+	if len(records) != 1 || records[0]["level"] != "ERROR" {
+		t.Fatalf("записи: %v", records)
+	}
+	if sql, _ := records[0]["sql"].(string); !strings.Contains(sql, "email = $1") {
+		t.Errorf("sql = %q", sql)
+	}
+	if strings.Contains(buf.String(), "alice") {
+		t.Errorf("значение параметра попало в журнал: %s", buf.String())
+	}
+}
+
+func TestGormLoggerSkipsRecordNotFound(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewGormLogger(New(&buf, FormatJSON, slog.LevelDebug))
+
+	logger.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return `SELECT * FROM "users" WHERE id = $1`, 0
+	}, gorm.ErrRecordNotFound)
+
+	if buf.Len() != 0 {
+		t.Errorf("отсутствие записи записано в журнал: %s", buf.String())
+	}
+}
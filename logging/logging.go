@@ -0,0 +1,105 @@
+// logging/logging.go - структурированный журнал slog с идентификаторами запроса и маскированием данных
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Форматы журнала
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// contextKey ключ значений журнала в контексте запроса
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userIDKey
+)
+
+// New создает журнал в формате format (json или text) с минимальным уровнем level.
+// Все атрибуты и сообщения проходят через Redact, к записям добавляются
+// request_id, trace_id и user_id из контекста.
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceAttr,
+	}
+
+	var handler slog.Handler
+	if format == FormatText {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// Nop возвращает журнал, который ничего не пишет
+func Nop() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// ParseLevel разбирает уровень журнала: debug, info, warn или error
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("ожидается debug, info, warn или error, получено %q", value)
+	}
+}
+
+// WithRequestID сохраняет идентификатор запроса в контексте
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// WithUserID сохраняет идентификатор аутентифицированного пользователя в контексте
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// contextHandler добавляет к записи идентификаторы запроса, трассировки и пользователя.
+// Записи без контекста (slog.Info вместо slog.InfoContext) выводятся без них.
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle дополняет запись значениями из ctx
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok && requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	if userID, ok := ctx.Value(userIDKey).(string); ok && userID != "" {
+		record.AddAttrs(slog.String("user_id", userID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs возвращает обработчик с дополнительными атрибутами
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup возвращает обработчик с группой атрибутов
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
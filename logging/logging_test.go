@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// decodeLines разбирает строки журнала в формате JSON
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("строка журнала %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLoggerAddsContextIdentifiers(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, slog.LevelInfo)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = WithUserID(WithRequestID(ctx, "req-1"), "user-1")
+
+	logger.InfoContext(ctx, "Пользователь вошел")
+	logger.Info("Без контекста")
+
+	records := decodeLines(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("записи: %v", records)
+	}
+	if records[0]["request_id"] != "req-1" || records[0]["user_id"] != "user-1" || records[0]["trace_id"] != traceID.String() {
+		t.Errorf("идентификаторы из контекста: %v", records[0])
+	}
+	if _, ok := records[1]["request_id"]; ok {
+		t.Errorf("запись без контекста получила request_id: %v", records[1])
+	}
+}
+
+func TestLoggerRedactsAttributesAndMessage(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, slog.LevelInfo)
+
+	logger.Info("Письмо отправлено на alice@example.com",
+		"password", "hunter2",
+		"accessToken", "abc",
+		"email", "bob@example.com",
+		slog.Group("request", slog.String("authorization", "Bearer abc")),
+		"payload", map[string]any{"new_password": "hunter3", "name": "Bob"},
+	)
+
+	record := decodeLines(t, &buf)[0]
+	if record["msg"] != "Письмо отправлено на ***@example.com" {
+		t.Errorf("msg = %v", record["msg"])
+	}
+	if record["password"] != Redacted || record["accessToken"] != Redacted || record["email"] != "***@example.com" {
+		t.Errorf("атрибуты: %v", record)
+	}
+	if request := record["request"].(map[string]any); request["authorization"] != Redacted {
+		t.Errorf("атрибут группы: %v", request)
+	}
+	if payload := record["payload"].(map[string]any); payload["new_password"] != Redacted || payload["name"] != "Bob" {
+		t.Errorf("вложенные данные: %v", payload)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("hunter")) {
+		t.Errorf("пароль попал в журнал: %s", buf.String())
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	level, err := ParseLevel("WARN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := New(&buf, FormatJSON, level)
+
+	logger.Info("пропускается")
+	logger.Warn("записывается")
+
+	if records := decodeLines(t, &buf); len(records) != 1 || records[0]["msg"] != "записывается" {
+		t.Errorf("записи: %v", records)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("принят неизвестный уровень")
+	}
+}
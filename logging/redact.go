@@ -0,0 +1,146 @@
+// logging/redact.go - маскирование паролей, токенов и email в записях журнала
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redacted значение, которым заменяются секреты
+const Redacted = "[REDACTED]"
+
+// sensitiveKeyParts части имен атрибутов и полей, значения которых всегда скрываются:
+// password, new_password, access_token, Authorization, X-CSRF-Token, ...
+var sensitiveKeyParts = map[string]bool{
+	"password":      true,
+	"passwd":        true,
+	"secret":        true,
+	"token":         true,
+	"authorization": true,
+	"cookie":        true,
+	"signature":     true,
+	"otp":           true,
+	"apikey":        true,
+}
+
+// sensitiveKeys имена, которые скрываются только целиком (status_code сохраняется)
+var sensitiveKeys = map[string]bool{
+	"code":        true,
+	"api_key":     true,
+	"private_key": true,
+	"set_cookie":  true,
+}
+
+var (
+	keySplitPattern  = regexp.MustCompile(`[^a-z0-9]+`)
+	camelCasePattern = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	emailPattern     = regexp.MustCompile(`([A-Za-z0-9._%+\-]+)@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	jwtPattern       = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	bearerPattern    = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=\-]+`)
+	// password=..., token: "...", "secret":"..." в строках запросов, сообщениях ошибок и JSON
+	keyValuePattern = regexp.MustCompile(`(?i)("?[a-z_\-]*(?:password|passwd|secret|token|signature|otp)[a-z_\-]*"?\s*[:=]\s*"?)([^"&\s,;}]+)`)
+)
+
+// IsSensitiveKey сообщает, что значение атрибута или поля с таким именем нужно скрыть.
+// Имена в camelCase (accessToken) разбиваются на слова так же, как snake_case.
+func IsSensitiveKey(key string) bool {
+	key = camelCasePattern.ReplaceAllString(key, "${1}_${2}")
+	normalized := strings.Trim(keySplitPattern.ReplaceAllString(strings.ToLower(key), "_"), "_")
+	if sensitiveKeys[normalized] {
+		return true
+	}
+	for _, part := range strings.Split(normalized, "_") {
+		if sensitiveKeyParts[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskEmail оставляет от адреса только домен: ***@example.com
+func MaskEmail(email string) string {
+	return emailPattern.ReplaceAllString(email, "***@$2")
+}
+
+// RedactString скрывает в строке email, JWT, заголовки Authorization и пары ключ=значение с секретами
+func RedactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "$1 "+Redacted)
+	s = keyValuePattern.ReplaceAllString(s, "${1}"+Redacted)
+	return MaskEmail(s)
+}
+
+// Redact возвращает копию значения со скрытыми секретами. Структуры и карты обходятся
+// по JSON представлению, поэтому теги json определяют имена проверяемых полей.
+func Redact(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return RedactString(v)
+	case error:
+		return RedactString(v.Error())
+	case fmt.Stringer:
+		return RedactString(v.String())
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, item := range v {
+			if IsSensitiveKey(key) {
+				redacted[key] = Redacted
+				continue
+			}
+			redacted[key] = Redact(item)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = Redact(item)
+		}
+		return redacted
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return RedactString(fmt.Sprint(value))
+		}
+		var generic any
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return RedactString(string(data))
+		}
+		return Redact(generic)
+	default:
+		return RedactString(fmt.Sprint(value))
+	}
+}
+
+// replaceAttr применяет маскирование к каждому атрибуту и к тексту сообщения
+func replaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && (attr.Key == slog.TimeKey || attr.Key == slog.LevelKey || attr.Key == slog.SourceKey) {
+		return attr
+	}
+
+	if IsSensitiveKey(attr.Key) {
+		if attr.Value.Kind() == slog.KindString && attr.Value.String() == "" {
+			return attr
+		}
+		return slog.String(attr.Key, Redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, RedactString(attr.Value.String()))
+	case slog.KindAny:
+		return slog.Any(attr.Key, Redact(attr.Value.Any()))
+	default:
+		return attr
+	}
+}
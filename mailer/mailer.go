@@ -0,0 +1,145 @@
+// mailer/mailer.go - отправка писем пользователям
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+
+	"AuthApplications/config"
+)
+
+// Mailer интерфейс отправки писем. Отмена ctx прерывает отправку.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// New создает SMTP-отправитель, если задан SMTP_HOST, иначе пишет письма в лог
+func New(cfg *config.Config) Mailer {
+	if cfg.SMTPHost == "" {
+		return NewLogMailer()
+	}
+	return NewSMTPMailer(cfg)
+}
+
+// smtpMailer отправляет письма через SMTP сервер
+type smtpMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer создает отправитель писем через SMTP
+func NewSMTPMailer(cfg *config.Config) Mailer {
+	return &smtpMailer{
+		addr:     net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort),
+		host:     cfg.SMTPHost,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		from:     cfg.SMTPFrom,
+	}
+}
+
+// Send отправляет текстовое письмо. Соединение с SMTP сервером закрывается при отмене ctx,
+// поэтому недоступный сервер не задерживает отправку дольше срока контекста.
+func (m *smtpMailer) Send(ctx context.Context, to, subject, body string) error {
+	// Защита от внедрения заголовков через адрес или тему
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("недопустимые символы в адресе или теме письма")
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	message := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	if err := m.send(ctx, auth, to, []byte(message)); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("ошибка отправки письма: %w", err)
+	}
+	return nil
+}
+
+// send повторяет smtp.SendMail на соединении, которое закрывается при отмене ctx
+func (m *smtpMailer) send(ctx context.Context, auth smtp.Auth, to string, message []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: сервер не поддерживает AUTH")
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// logMailer выводит письма в stderr вместо отправки (только для разработки).
+// Письма печатаются целиком, со ссылками и кодами, в обход структурированного журнала и маскирования.
+type logMailer struct{}
+
+// NewLogMailer создает отправитель, который пишет письма в stderr
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+// Send выводит письмо в stderr
+func (m *logMailer) Send(ctx context.Context, to, subject, body string) error {
+	fmt.Fprintf(os.Stderr, "mailer: to=%s subject=%q\n%s\n", to, subject, body)
+	return nil
+}
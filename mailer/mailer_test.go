@@ -0,0 +1,134 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+)
+
+// newSMTPStub принимает одно соединение и ведет минимальный SMTP диалог без STARTTLS и AUTH;
+// полученное письмо отправляется в канал
+func newSMTPStub(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case command == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				reply("250 queued")
+			case command == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+// newTestMailer создает SMTP отправителя для адреса addr
+func newTestMailer(t *testing.T, addr string) Mailer {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSMTPMailer(&config.Config{SMTPHost: host, SMTPPort: port, SMTPFrom: "noreply@example.com"})
+}
+
+func TestSMTPMailerSendsMessage(t *testing.T) {
+	addr, received := newSMTPStub(t)
+
+	if err := newTestMailer(t, addr).Send(context.Background(), "alice@example.com", "Вход с нового устройства", "Текст"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	select {
+	case message := <-received:
+		if !strings.Contains(message, "To: alice@example.com\r\n") || !strings.HasSuffix(message, "Текст\r\n") {
+			t.Errorf("письмо = %q", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("письмо не получено")
+	}
+}
+
+func TestSMTPMailerStopsOnContextDeadline(t *testing.T) {
+	// Сервер принимает соединение, но не отвечает
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(10 * time.Second)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err = newTestMailer(t, listener.Addr().String()).Send(ctx, "alice@example.com", "Тема", "Текст")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ожидалась context.DeadlineExceeded, получено %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("отправка прервана только через %v", elapsed)
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	err := newTestMailer(t, "127.0.0.1:1").Send(context.Background(), "alice@example.com\r\nBcc: eve@example.com", "Тема", "Текст")
+	if err == nil {
+		t.Fatal("адрес с переводом строки принят")
+	}
+}
@@ -3,32 +3,19 @@ package main
 
 import (
 	"log"
+	"os"
 
 	_ "AuthApplications/docs"
-	"AuthApplications/config"
-	"AuthApplications/routes"
-	
+	"AuthApplications/commands"
+
 )
 
 // @title API 🖥🚀
 // @version 1.0
 // @description API documentation
 func main() {
-	// Инициализация конфигурации
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		log.Fatalf("Error loading config: %v", err)
-	}
-
-	// Инициализация базы данных
-	db, err := config.InitDB(cfg)
-	if err != nil {
-		log.Fatalf("Error initializing database: %v", err)
-	}
-
-	// Настройка и запуск роутера
-	r := routes.SetupRouter(db, cfg)
-	if err := r.Run(":" + cfg.ServerPort); err != nil {
-		log.Fatalf("Error starting server: %v", err)
+	// Без подкоманды запускается HTTP сервер (serve)
+	if err := commands.NewApp().Run(os.Args); err != nil {
+		log.Fatalf("Error: %v", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,79 @@
+// metrics/gorm.go - замер длительности запросов GORM через callbacks
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// queryStartKey ключ времени начала запроса в экземпляре gorm.DB
+const queryStartKey = "metrics:query_start"
+
+// gormPlugin плагин GORM, записывающий длительность каждого запроса
+type gormPlugin struct {
+	metrics Metrics
+}
+
+// Name возвращает имя плагина
+func (p *gormPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize регистрирует callbacks до и после каждой операции GORM
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+
+	for _, processor := range processors {
+		if err := processor.before("metrics:before_"+processor.operation, startQuery); err != nil {
+			return err
+		}
+		if err := processor.after("metrics:after_"+processor.operation, p.finishQuery(processor.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startQuery запоминает время начала запроса
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+// finishQuery записывает длительность запроса. Отсутствие записи ошибкой запроса не считается.
+func (p *gormPlugin) finishQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		started, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+
+		err := db.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		p.metrics.ObserveDBQuery(operation, table, time.Since(started), err)
+	}
+}
@@ -0,0 +1,192 @@
+// metrics/metrics.go - метрики Prometheus: HTTP запросы, аутентификация и база данных
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+// namespace префикс имен метрик приложения
+const namespace = "auth"
+
+// Metrics интерфейс записи метрик приложения
+type Metrics interface {
+	ObserveHTTPRequest(method, route string, status int, duration time.Duration)
+	LoginSucceeded(method string)
+	LoginFailed(method, reason string)
+	UserRegistered(source string)
+	TokenIssued(kind string)
+	TokenValidated(kind string)
+	TokenRejected(kind, reason string)
+	ObserveDBQuery(operation, table string, duration time.Duration, err error)
+	InstrumentDB(db *gorm.DB) error
+	Handler() http.Handler
+}
+
+// prometheusMetrics реализация Metrics на client_golang
+type prometheusMetrics struct {
+	registry *prometheus.Registry
+
+	httpRequests    *prometheus.HistogramVec
+	logins          *prometheus.CounterVec
+	loginFailures   *prometheus.CounterVec
+	registrations   *prometheus.CounterVec
+	tokensIssued    *prometheus.CounterVec
+	tokensValidated *prometheus.CounterVec
+	tokensRejected  *prometheus.CounterVec
+	dbQueries       *prometheus.HistogramVec
+	dbQueryErrors   *prometheus.CounterVec
+}
+
+// New создает метрики и регистрирует их в registry.
+// Сервер передает новый prometheus.NewRegistry(); тесты могут передать свой и проверять значения.
+func New(registry *prometheus.Registry) Metrics {
+	m := &prometheusMetrics{
+		registry: registry,
+		httpRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Длительность HTTP запросов по шаблону маршрута.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_success_total",
+			Help:      "Успешные входы по способу аутентификации.",
+		}, []string{"method"}),
+		loginFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_failures_total",
+			Help:      "Неудачные попытки входа по способу аутентификации и причине.",
+		}, []string{"method", "reason"}),
+		registrations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registrations_total",
+			Help:      "Созданные учетные записи по источнику: local, ldap или saml.",
+		}, []string{"source"}),
+		tokensIssued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tokens_issued_total",
+			Help:      "Выпущенные JWT токены по виду: access, mfa или impersonation.",
+		}, []string{"kind"}),
+		tokensValidated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tokens_validated_total",
+			Help:      "JWT токены, прошедшие проверку.",
+		}, []string{"kind"}),
+		tokensRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tokens_rejected_total",
+			Help:      "Отклоненные JWT токены по причине.",
+		}, []string{"kind", "reason"}),
+		dbQueries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Длительность запросов GORM по операции и таблице.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		dbQueryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "Запросы GORM, завершившиеся ошибкой (кроме отсутствия записи).",
+		}, []string{"operation", "table"}),
+	}
+
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.logins,
+		m.loginFailures,
+		m.registrations,
+		m.tokensIssued,
+		m.tokensValidated,
+		m.tokensRejected,
+		m.dbQueries,
+		m.dbQueryErrors,
+	)
+	return m
+}
+
+// ObserveHTTPRequest записывает длительность HTTP запроса
+func (m *prometheusMetrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// LoginSucceeded учитывает успешный вход
+func (m *prometheusMetrics) LoginSucceeded(method string) {
+	m.logins.WithLabelValues(method).Inc()
+}
+
+// LoginFailed учитывает неудачную попытку входа
+func (m *prometheusMetrics) LoginFailed(method, reason string) {
+	m.loginFailures.WithLabelValues(method, reason).Inc()
+}
+
+// UserRegistered учитывает созданную учетную запись
+func (m *prometheusMetrics) UserRegistered(source string) {
+	m.registrations.WithLabelValues(source).Inc()
+}
+
+// TokenIssued учитывает выпущенный токен
+func (m *prometheusMetrics) TokenIssued(kind string) {
+	m.tokensIssued.WithLabelValues(kind).Inc()
+}
+
+// TokenValidated учитывает токен, прошедший проверку
+func (m *prometheusMetrics) TokenValidated(kind string) {
+	m.tokensValidated.WithLabelValues(kind).Inc()
+}
+
+// TokenRejected учитывает отклоненный токен
+func (m *prometheusMetrics) TokenRejected(kind, reason string) {
+	m.tokensRejected.WithLabelValues(kind, reason).Inc()
+}
+
+// ObserveDBQuery записывает длительность запроса к базе данных
+func (m *prometheusMetrics) ObserveDBQuery(operation, table string, duration time.Duration, err error) {
+	m.dbQueries.WithLabelValues(operation, table).Observe(duration.Seconds())
+	if err != nil {
+		m.dbQueryErrors.WithLabelValues(operation, table).Inc()
+	}
+}
+
+// InstrumentDB подключает замер запросов GORM и метрики пула соединений (go_sql_*)
+func (m *prometheusMetrics) InstrumentDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := m.registry.Register(collectors.NewDBStatsCollector(sqlDB, db.Migrator().CurrentDatabase())); err != nil {
+		return err
+	}
+
+	return db.Use(&gormPlugin{metrics: m})
+}
+
+// Handler отдает метрики в формате Prometheus
+func (m *prometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// nopMetrics отключает метрики (команды CLI)
+type nopMetrics struct{}
+
+// NewNop создает метрики, которые ничего не записывают
+func NewNop() Metrics {
+	return nopMetrics{}
+}
+
+func (nopMetrics) ObserveHTTPRequest(string, string, int, time.Duration) {}
+func (nopMetrics) LoginSucceeded(string)                                 {}
+func (nopMetrics) LoginFailed(string, string)                            {}
+func (nopMetrics) UserRegistered(string)                                 {}
+func (nopMetrics) TokenIssued(string)                                    {}
+func (nopMetrics) TokenValidated(string)                                 {}
+func (nopMetrics) TokenRejected(string, string)                          {}
+func (nopMetrics) ObserveDBQuery(string, string, time.Duration, error)   {}
+func (nopMetrics) InstrumentDB(*gorm.DB) error                           { return nil }
+func (nopMetrics) Handler() http.Handler                                 { return http.NotFoundHandler() }
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB открывает GORM поверх sqlmock с плагином метрик
+func newTestDB(t *testing.T, m Metrics) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(&gormPlugin{metrics: m}); err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+type testUser struct {
+	ID   int
+	Name string
+}
+
+func (testUser) TableName() string { return "users" }
+
+func TestGormPluginObservesQueries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+	db, mock := newTestDB(t, m)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users"`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Отсутствие записи ошибкой не считается, сбой соединения — считается
+	var user testUser
+	if err := db.First(&user).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("ожидалась ErrRecordNotFound, получено %v", err)
+	}
+	if err := db.First(&user).Error; err == nil {
+		t.Fatal("ожидалась ошибка запроса")
+	}
+	if err := db.Model(&testUser{ID: 1}).Update("name", "Анна").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if count := testutil.CollectAndCount(m.(*prometheusMetrics).dbQueries, "db_query_duration_seconds"); count != 2 {
+		t.Errorf("рядов длительности запросов = %d, ожидалось 2 (query и update)", count)
+	}
+
+	expected := `
+# HELP db_query_errors_total Запросы GORM, завершившиеся ошибкой (кроме отсутствия записи).
+# TYPE db_query_errors_total counter
+db_query_errors_total{operation="query",table="users"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "db_query_errors_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetricsObserveHTTPRequest(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+
+	m.ObserveHTTPRequest("GET", "/api/books/:id", 200, 30*time.Millisecond)
+	m.ObserveHTTPRequest("GET", "/api/books/:id", 200, 70*time.Millisecond)
+	m.ObserveHTTPRequest("POST", "/api/auth/login", 401, time.Millisecond)
+
+	if count := testutil.CollectAndCount(m.(*prometheusMetrics).httpRequests); count != 2 {
+		t.Errorf("рядов HTTP запросов = %d, ожидалось 2", count)
+	}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP http_request_duration_seconds Длительность HTTP запросов по шаблону маршрута.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="0.005"} 0
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="0.01"} 0
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="0.025"} 0
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="0.05"} 1
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="0.1"} 2
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="0.25"} 2
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="0.5"} 2
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="1"} 2
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="2.5"} 2
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="5"} 2
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="10"} 2
+http_request_duration_seconds_bucket{method="GET",route="/api/books/:id",status="200",le="+Inf"} 2
+http_request_duration_seconds_sum{method="GET",route="/api/books/:id",status="200"} 0.1
+http_request_duration_seconds_count{method="GET",route="/api/books/:id",status="200"} 2
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="0.005"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="0.01"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="0.025"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="0.05"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="0.1"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="0.25"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="0.5"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="1"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="2.5"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="5"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="10"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/auth/login",status="401",le="+Inf"} 1
+http_request_duration_seconds_sum{method="POST",route="/api/auth/login",status="401"} 0.001
+http_request_duration_seconds_count{method="POST",route="/api/auth/login",status="401"} 1
+`), "http_request_duration_seconds"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetricsHandlerExposesRegistry(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	m.LoginSucceeded("passkey")
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := recorder.Body.String()
+	for _, want := range []string{`auth_login_success_total{method="passkey"} 1`, "go_goroutines"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("страница метрик не содержит %q", want)
+		}
+	}
+}
@@ -2,8 +2,11 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 
+	"AuthApplications/dto"
+	"AuthApplications/logging"
 	"AuthApplications/services"
 	"github.com/gin-gonic/gin"
 )
@@ -40,7 +43,18 @@ func AuthMiddleware(authService services.AuthService) gin.HandlerFunc {
 		}
 
 		// Валидация токена
-		_, claims, err := authService.ValidateToken(tokenString)
+		_, claims, err := authService.ValidateToken(c.Request.Context(), tokenString)
+		var blocked *services.AccountBlockedError
+		if errors.As(err, &blocked) {
+			c.JSON(http.StatusForbidden, dto.AccountBlockedResponse{
+				Error:  blocked.Error(),
+				Status: blocked.Status,
+				Reason: blocked.Reason,
+				Until:  blocked.Until,
+			})
+			c.Abort()
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный токен: " + err.Error()})
 			c.Abort()
@@ -51,6 +65,13 @@ func AuthMiddleware(authService services.AuthService) gin.HandlerFunc {
 		c.Set("userID", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		c.Set("claims", claims)
+		c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), claims.UserID.String()))
+
+		// Токен имперсонации: администратор действует от имени пользователя
+		if claims.Act != nil {
+			c.Set("impersonatorID", claims.Act.UserID)
+		}
 
 		c.Next()
 	}
@@ -86,4 +107,17 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// ForbidImpersonation middleware запрещает чувствительные действия при имперсонации
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonating := c.Get("impersonatorID"); impersonating {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Действие недоступно в режиме имперсонации"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// fakeAuthService принимает токены из tokens; остальные методы не реализованы
+type fakeAuthService struct {
+	services.AuthService
+	tokens map[string]*services.JWTClaim
+	err    error
+}
+
+func (s *fakeAuthService) ValidateToken(ctx context.Context, tokenString string) (*jwt.Token, *services.JWTClaim, error) {
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	claims, ok := s.tokens[tokenString]
+	if !ok {
+		return nil, nil, errors.New("недействительный токен")
+	}
+	return &jwt.Token{Valid: true}, claims, nil
+}
+
+// newAuthRouter создает роутер с AuthMiddleware и обработчиками, запрещенными при имперсонации
+func newAuthRouter(authService services.AuthService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	protected := router.Group("/", AuthMiddleware(authService))
+	protected.GET("/profile", func(c *gin.Context) {
+		_, impersonating := c.Get("impersonatorID")
+		c.JSON(http.StatusOK, gin.H{"impersonating": impersonating})
+	})
+	protected.POST("/password", ForbidImpersonation(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return router
+}
+
+func TestForbidImpersonationBlocksImpersonationTokens(t *testing.T) {
+	userID := uuid.New()
+	authService := &fakeAuthService{tokens: map[string]*services.JWTClaim{
+		"user":          {UserID: userID, Role: "user"},
+		"impersonation": {UserID: userID, Role: "user", Act: &services.ActorClaim{UserID: uuid.New()}},
+	}}
+	router := newAuthRouter(authService)
+
+	tests := []struct {
+		token  string
+		method string
+		path   string
+		status int
+	}{
+		{token: "user", method: http.MethodPost, path: "/password", status: http.StatusNoContent},
+		{token: "impersonation", method: http.MethodGet, path: "/profile", status: http.StatusOK},
+		{token: "impersonation", method: http.MethodPost, path: "/password", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token+" "+tt.method+" "+tt.path, func(t *testing.T) {
+			recorder := serveWithToken(router, tt.method, tt.path, tt.token)
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, ожидался %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+		})
+	}
+
+	// Администратор, действующий от имени пользователя, виден обработчикам
+	if body := serveWithToken(router, http.MethodGet, "/profile", "impersonation").Body.String(); body != `{"impersonating":true}` {
+		t.Errorf("ответ = %s", body)
+	}
+}
+
+// serveWithToken выполняет запрос с токеном в заголовке Authorization
+func serveWithToken(router http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(AuthorizationHeaderKey, BearerSchema+token)
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestAuthMiddlewareRejectsBlockedAccount(t *testing.T) {
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	authService := &fakeAuthService{err: &services.AccountBlockedError{
+		Status: models.UserStatusSuspended,
+		Reason: "проверка",
+		Until:  &until,
+	}}
+
+	recorder := serveWithToken(newAuthRouter(authService), http.MethodGet, "/profile", "user")
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, ожидался 403", recorder.Code)
+	}
+
+	var body dto.AccountBlockedResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Status != models.UserStatusSuspended || body.Reason != "проверка" || body.Until == nil || !body.Until.Equal(until) {
+		t.Errorf("ответ = %+v", body)
+	}
+}
@@ -0,0 +1,57 @@
+// middleware/csrf.go - защита от CSRF для запросов, аутентифицированных cookie
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CSRFCookieName = "csrf_token"
+	CSRFHeaderKey  = "X-CSRF-Token"
+)
+
+// CSRF middleware проверяет double-submit токен для изменяющих запросов.
+// Проверка нужна только когда учетные данные берутся из cookie (как в AuthMiddleware):
+// заголовок Authorization браузер сам на чужой сайт не отправит.
+func CSRF(csrfService services.CSRFService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isSafeMethod(c.Request.Method) || !usesCookieCredential(c) {
+			c.Next()
+			return
+		}
+
+		cookieToken, _ := c.Cookie(CSRFCookieName)
+		headerToken := c.GetHeader(CSRFHeaderKey)
+		if cookieToken == "" || headerToken == "" ||
+			subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) != 1 ||
+			csrfService.Verify(headerToken) != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":         services.ErrInvalidCSRFToken.Error(),
+				"csrf_required": true,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// usesCookieCredential сообщает, будет ли запрос аутентифицирован cookie с токеном доступа
+func usesCookieCredential(c *gin.Context) bool {
+	token, err := c.Cookie(AccessTokenCookieName)
+	return err == nil && token != ""
+}
+
+// isSafeMethod возвращает true для методов, которые не изменяют состояние
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
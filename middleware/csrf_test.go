@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"AuthApplications/config"
+	"AuthApplications/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	csrfService := services.NewCSRFService(&config.Config{JWTSecret: "0123456789abcdef0123456789abcdef"})
+	token, _, err := csrfService.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := csrfService.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		accessCookie  bool
+		authorization bool
+		cookieToken   string
+		headerToken   string
+		status        int
+	}{
+		{name: "cookie with matching token", method: http.MethodPost, accessCookie: true, cookieToken: token, headerToken: token, status: http.StatusNoContent},
+		{name: "cookie without header", method: http.MethodPost, accessCookie: true, cookieToken: token, status: http.StatusForbidden},
+		{name: "cookie with mismatched header", method: http.MethodPost, accessCookie: true, cookieToken: token, headerToken: other, status: http.StatusForbidden},
+		{name: "cookie with unsigned token", method: http.MethodPost, accessCookie: true, cookieToken: "forged", headerToken: "forged", status: http.StatusForbidden},
+		{name: "cookie on safe method", method: http.MethodGet, accessCookie: true, status: http.StatusNoContent},
+		{name: "authorization header", method: http.MethodPost, authorization: true, status: http.StatusNoContent},
+		{name: "anonymous", method: http.MethodPost, status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(CSRF(csrfService))
+			router.Handle(tt.method, "/profile", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+			req := httptest.NewRequest(tt.method, "/profile", nil)
+			if tt.accessCookie {
+				req.AddCookie(&http.Cookie{Name: AccessTokenCookieName, Value: "access-token"})
+			}
+			if tt.authorization {
+				req.Header.Set("Authorization", "Bearer access-token")
+			}
+			if tt.cookieToken != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tt.cookieToken})
+			}
+			if tt.headerToken != "" {
+				req.Header.Set(CSRFHeaderKey, tt.headerToken)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, ожидался %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// middleware/logging.go - журнал доступа и перехват паник в структурированный журнал
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog middleware записывает одну строку журнала на запрос.
+// Строка запроса (query) не записывается: в ней могут быть токены и коды подтверждения.
+// request_id, trace_id и user_id добавляются журналом из контекста запроса.
+func AccessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", milliseconds(time.Since(started))),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+			attrs = append(attrs, slog.String("error", errs.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(c.Request.Context(), level, "HTTP запрос", attrs...)
+	}
+}
+
+// Recovery middleware перехватывает панику обработчика, записывает ее в журнал
+// и отвечает 500 без подробностей ошибки
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		logger.ErrorContext(c.Request.Context(), "Паника при обработке запроса",
+			slog.Any("panic", recovered),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+		)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Внутренняя ошибка сервера"})
+	})
+}
+
+// milliseconds переводит длительность в миллисекунды для журнала
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
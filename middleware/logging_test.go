@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"AuthApplications/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "from proxy", incoming: "edge-7f3a.42", keep: true},
+		{name: "missing", incoming: ""},
+		{name: "invalid characters", incoming: "id\r\nSet-Cookie: x=1"},
+		{name: "too long", incoming: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextID any
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/", func(c *gin.Context) {
+				contextID, _ = c.Get("requestID")
+				c.Status(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, tt.incoming)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			got := recorder.Header().Get(RequestIDHeader)
+			if tt.keep && got != tt.incoming {
+				t.Errorf("%s = %q, ожидался входящий идентификатор", RequestIDHeader, got)
+			}
+			if !tt.keep {
+				if _, err := uuid.Parse(got); err != nil {
+					t.Errorf("%s = %q, ожидался новый UUID", RequestIDHeader, got)
+				}
+			}
+			if contextID != got {
+				t.Errorf("идентификатор в контексте %v, в ответе %q", contextID, got)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(RequestID(), AccessLog(logging.New(&buf, logging.FormatJSON, slog.LevelInfo)))
+	router.GET("/api/books/:id", func(c *gin.Context) {
+		// Так AuthMiddleware передает пользователя в журнал
+		c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), "user-1"))
+		c.Status(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/books/42?token=secret-value", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("строка журнала %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level":      "WARN",
+		"route":      "/api/books/:id",
+		"path":       "/api/books/42",
+		"status":     float64(http.StatusNotFound),
+		"request_id": "req-1",
+		"user_id":    "user-1",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, ожидалось %v", key, record[key], value)
+		}
+	}
+	if strings.Contains(buf.String(), "secret-value") {
+		t.Errorf("строка запроса попала в журнал: %s", buf.String())
+	}
+}
+
+func TestRecoveryLogsPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(Recovery(logging.New(&buf, logging.FormatJSON, slog.LevelInfo)))
+	router.GET("/", func(c *gin.Context) { panic("password=hunter2") })
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if recorder.Code != http.StatusInternalServerError || strings.Contains(recorder.Body.String(), "hunter2") {
+		t.Errorf("ответ %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if !strings.Contains(buf.String(), "Паника") || strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("журнал: %s", buf.String())
+	}
+}
@@ -0,0 +1,51 @@
+// middleware/metrics.go - метрики HTTP запросов и защита страницы метрик
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"AuthApplications/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute метка маршрута для запросов, не совпавших ни с одним шаблоном.
+// Сам путь в метку не попадает, чтобы сканеры не создавали новые временные ряды.
+const unmatchedRoute = "unmatched"
+
+// Metrics middleware записывает длительность запроса по методу, шаблону маршрута и статусу
+func Metrics(m metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(started))
+	}
+}
+
+// MetricsToken middleware требует заголовок Authorization: Bearer <token> для страницы метрик.
+// Пустой token отключает проверку.
+func MetricsToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Требуется токен доступа к метрикам"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"AuthApplications/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsLabelsRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := prometheus.NewRegistry()
+
+	router := gin.New()
+	router.Use(Metrics(metrics.New(registry)))
+	router.GET("/api/books/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/api/books/1", "/api/books/2", "/wp-login.php"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Параметры пути и неизвестные адреса не создают новых временных рядов
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counts[labels["route"]+" "+labels["status"]] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+
+	want := map[string]uint64{"/api/books/:id 204": 2, "unmatched 404": 1}
+	if len(counts) != len(want) {
+		t.Fatalf("ряды = %v, ожидалось %v", counts, want)
+	}
+	for key, count := range want {
+		if counts[key] != count {
+			t.Errorf("%s: %d запросов, ожидалось %d", key, counts[key], count)
+		}
+	}
+}
+
+func TestMetricsTokenProtectsMetricsPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New(prometheus.NewRegistry())
+
+	router := gin.New()
+	router.GET("/metrics", MetricsToken("scrape-token"), gin.WrapH(m.Handler()))
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"без токена", "", http.StatusUnauthorized},
+		{"неверный токен", "Bearer other", http.StatusUnauthorized},
+		{"верный токен", "Bearer scrape-token", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				request.Header.Set("Authorization", tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != tt.status {
+				t.Fatalf("статус = %d, ожидался %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// middleware/request_id.go - идентификатор запроса для журналов и трассировки
+package middleware
+
+import (
+	"regexp"
+
+	"AuthApplications/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern ограничивает идентификаторы, принятые от клиента или прокси
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID middleware берет идентификатор запроса из заголовка или генерирует новый
+// и возвращает его в ответе. Идентификатор сохраняется в контексте запроса для журнала.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+// middleware/step_up.go - повторная аутентификация перед чувствительными операциями
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"AuthApplications/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRecentAuth middleware пропускает запрос, только если учетные данные
+// проверялись не раньше maxAge назад (claim auth_time)
+func RequireRecentAuth(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CheckRecentAuth(c, maxAge) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CheckRecentAuth проверяет давность аутентификации для обработчиков, которым повторная
+// аутентификация нужна не всегда. При устаревшем токене отвечает 401 и прерывает запрос.
+func CheckRecentAuth(c *gin.Context, maxAge time.Duration) bool {
+	value, _ := c.Get("claims")
+	claims, _ := value.(*services.JWTClaim)
+	if claims != nil && claims.AuthTime != nil && time.Since(claims.AuthTime.Time) <= maxAge {
+		return true
+	}
+
+	// RFC 9470: клиент должен пройти /api/auth/reauthenticate и повторить запрос с новым токеном
+	c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", max_age=%d`, int(maxAge.Seconds())))
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"error":            "Требуется повторная аутентификация",
+		"step_up_required": true,
+	})
+	c.Abort()
+	return false
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"AuthApplications/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestRequireRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		authTime *jwt.NumericDate
+		status   int
+	}{
+		{name: "fresh", authTime: jwt.NewNumericDate(time.Now().Add(-time.Minute)), status: http.StatusNoContent},
+		{name: "stale", authTime: jwt.NewNumericDate(time.Now().Add(-time.Hour)), status: http.StatusUnauthorized},
+		{name: "missing", authTime: nil, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.DELETE("/account",
+				func(c *gin.Context) { c.Set("claims", &services.JWTClaim{AuthTime: tt.authTime}) },
+				RequireRecentAuth(5*time.Minute),
+				func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/account", nil))
+
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, ожидался %d", recorder.Code, tt.status)
+			}
+			if tt.status == http.StatusUnauthorized {
+				// RFC 9470: клиент узнает, что нужна повторная аутентификация, и допустимую давность
+				want := `Bearer error="insufficient_user_authentication", max_age=300`
+				if header := recorder.Header().Get("WWW-Authenticate"); header != want {
+					t.Errorf("WWW-Authenticate = %q", header)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// middleware/timeout.go - срок обработки запроса
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeout middleware ограничивает время обработки запроса.
+// Срок передается через контекст запроса, поэтому по его истечении или при отключении клиента
+// отменяются запросы к базе данных. Если обработчик не успел ответить, возвращается 504.
+// Нулевой timeout отключает ограничение.
+func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "Превышено время обработки запроса"})
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		status  int
+	}{
+		{name: "deadline exceeded", timeout: 10 * time.Millisecond, status: http.StatusGatewayTimeout},
+		{name: "in time", timeout: time.Second, status: http.StatusNoContent},
+		{name: "disabled", timeout: 0, status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hasDeadline bool
+			router := gin.New()
+			router.Use(RequestTimeout(tt.timeout))
+			router.GET("/books", func(c *gin.Context) {
+				// Обработчик, как запрос к базе, прерывается по отмене контекста
+				_, hasDeadline = c.Request.Context().Deadline()
+				select {
+				case <-c.Request.Context().Done():
+				case <-time.After(50 * time.Millisecond):
+					c.Status(http.StatusNoContent)
+				}
+			})
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/books", nil))
+
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, ожидался %d", recorder.Code, tt.status)
+			}
+			if hasDeadline != (tt.timeout > 0) {
+				t.Errorf("срок в контексте запроса: %v", hasDeadline)
+			}
+		})
+	}
+}
+
+func TestRequestTimeoutKeepsWrittenResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestTimeout(10 * time.Millisecond))
+	router.GET("/books", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+		<-c.Request.Context().Done()
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/books", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("отправленный ответ заменен: status = %d", recorder.Code)
+	}
+}
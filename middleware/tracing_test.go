@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"AuthApplications/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans устанавливает глобальные провайдер и пропагатор и записывает завершенные спаны
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := recordSpans(t)
+
+	var handlerTraceID string
+	router := gin.New()
+	router.Use(Tracing())
+	router.GET("/api/books/:id", func(c *gin.Context) {
+		// Сервисы получают контекст запроса со спаном
+		handlerTraceID = tracing.TraceID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/books/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get(TraceIDHeader); got != traceID {
+		t.Errorf("%s = %q, ожидался идентификатор входящей трассировки", TraceIDHeader, got)
+	}
+	if handlerTraceID != traceID {
+		t.Errorf("контекст обработчика вне трассировки: %q", handlerTraceID)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("спаны: %v", spans)
+	}
+	// Имя спана по шаблону маршрута, а не по пути с идентификатором
+	if spans[0].Name() != "GET /api/books/:id" {
+		t.Errorf("имя спана = %q", spans[0].Name())
+	}
+	if spans[0].Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("родитель спана = %s", spans[0].Parent().SpanID())
+	}
+}
+
+func TestTracingMarksServerErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := recordSpans(t)
+
+	router := gin.New()
+	router.Use(Tracing())
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	router.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	for _, path := range []string{"/fail", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("спаны: %v", spans)
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Error("ответ 500 не отмечен ошибкой")
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Error("ответ 404 отмечен ошибкой сервера")
+	}
+}
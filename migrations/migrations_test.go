@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseRequiresBothDirections(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_init.up.sql":   {Data: []byte("CREATE TABLE a ();")},
+		"0001_init.down.sql": {Data: []byte("DROP TABLE a;")},
+		"0002_books.up.sql":  {Data: []byte("CREATE TABLE b ();")},
+		"README.md":          {Data: []byte("не миграция")},
+	}
+
+	if _, err := parse(fsys); err == nil || !strings.Contains(err.Error(), "2_books") {
+		t.Fatalf("ожидалась ошибка о миграции без отката, получено %v", err)
+	}
+}
+
+func TestParseRejectsDifferentNamesForOneVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_init.up.sql":      {Data: []byte("CREATE TABLE a ();")},
+		"0001_initial.down.sql": {Data: []byte("DROP TABLE a;")},
+	}
+
+	if _, err := parse(fsys); err == nil {
+		t.Fatal("приняты файлы одной версии с разными названиями")
+	}
+}
+
+func TestParseChecksumCoversOnlyUp(t *testing.T) {
+	first, err := parse(fstest.MapFS{
+		"0001_init.up.sql":   {Data: []byte("CREATE TABLE a ();")},
+		"0001_init.down.sql": {Data: []byte("DROP TABLE a;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := parse(fstest.MapFS{
+		"0001_init.up.sql":   {Data: []byte("CREATE TABLE a ();")},
+		"0001_init.down.sql": {Data: []byte("DROP TABLE IF EXISTS a;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first[0].Checksum != second[0].Checksum {
+		t.Error("исправление отката меняет контрольную сумму примененной миграции")
+	}
+}
+
+func TestCreateUsesNextVersion(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"0001_init.up.sql":   "CREATE TABLE a ();",
+		"0001_init.down.sql": "DROP TABLE a;",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	upPath, downPath, err := Create(dir, "Add Books-Index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(upPath) != "0002_add_books_index.up.sql" || filepath.Base(downPath) != "0002_add_books_index.down.sql" {
+		t.Errorf("созданы файлы %s, %s", upPath, downPath)
+	}
+
+	migrations, err := parse(os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Errorf("миграций после Create = %d", len(migrations))
+	}
+}
+
+func TestCreateRequiresName(t *testing.T) {
+	if _, _, err := Create(t.TempDir(), " -- "); err == nil {
+		t.Fatal("создана миграция без названия")
+	}
+}
@@ -0,0 +1,265 @@
+// migrations/migrator.go - применение и откат миграций с учетом уже примененных версий
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockKey ключ advisory-блокировки PostgreSQL: одновременно миграции выполняет только одна реплика
+const lockKey int64 = 7266891340257613
+
+// schemaMigrationsSQL создает таблицу примененных миграций
+const schemaMigrationsSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint NOT NULL PRIMARY KEY,
+	name text NOT NULL,
+	checksum text NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`
+
+// Состояния миграции в отчете Status
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified" // файл изменен после применения
+	StateMissing  = "missing"  // применена в БД, но отсутствует в этой сборке
+)
+
+// ErrChecksumMismatch возвращается, если SQL уже примененной миграции изменился
+var ErrChecksumMismatch = errors.New("контрольная сумма примененной миграции не совпадает с файлом")
+
+// schemaMigration запись таблицы schema_migrations
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// TableName возвращает имя таблицы примененных миграций
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus описывает состояние одной версии схемы
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	State     string
+	AppliedAt *time.Time
+}
+
+// Migrator интерфейс применения миграций
+type Migrator interface {
+	Up() ([]Migration, error)
+	Down(steps int) ([]Migration, error)
+	Status() ([]MigrationStatus, error)
+	Pending() ([]Migration, error)
+}
+
+// migrator реализация Migrator
+type migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator создает мигратор для встроенных миграций
+func NewMigrator(db *gorm.DB) (Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Up применяет все непримененные миграции по возрастанию версии, каждую в отдельной транзакции
+func (m *migrator) Up() ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(func(conn *gorm.DB) error {
+		records, err := appliedRecords(conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verifyChecksums(records); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					Checksum:  migration.Checksum,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("миграция %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down откатывает steps последних примененных миграций
+func (m *migrator) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(func(conn *gorm.DB) error {
+		var records []schemaMigration
+		if err := conn.Order("version DESC").Limit(steps).Find(&records).Error; err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			migration, ok := m.find(record.Version)
+			if !ok {
+				return fmt.Errorf("миграция %d_%s отсутствует в этой сборке, откат невозможен", record.Version, record.Name)
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{}, "version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("откат миграции %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status сопоставляет встроенные миграции с примененными в БД
+func (m *migrator) Status() ([]MigrationStatus, error) {
+	records, err := appliedRecords(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, State: StatePending}
+		if record, ok := records[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.State = StateApplied
+			if record.Checksum != migration.Checksum {
+				status.State = StateModified
+			}
+			delete(records, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	// Версии, примененные более новой сборкой
+	for _, record := range records {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   record.Version,
+			Name:      record.Name,
+			State:     StateMissing,
+			AppliedAt: &appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Pending возвращает непримененные миграции
+func (m *migrator) Pending() ([]Migration, error) {
+	records, err := appliedRecords(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := records[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// withLock выполняет fn на выделенном соединении под advisory-блокировкой.
+// Блокировка сессионная, поэтому все запросы идут через одно соединение пула.
+// statement_timeout на время миграций снимается: ожидание блокировки и построение
+// индексов на больших таблицах не должны прерываться ограничением для запросов API.
+func (m *migrator) withLock(fn func(conn *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SET statement_timeout = 0").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("RESET statement_timeout")
+
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+			return fmt.Errorf("не удалось получить блокировку миграций: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", lockKey)
+
+		if err := conn.Exec(schemaMigrationsSQL).Error; err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// verifyChecksums проверяет, что примененные миграции не изменялись
+func (m *migrator) verifyChecksums(records map[int64]schemaMigration) error {
+	for _, migration := range m.migrations {
+		record, ok := records[migration.Version]
+		if ok && record.Checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// find возвращает встроенную миграцию по версии
+func (m *migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// appliedRecords возвращает примененные миграции по версиям; до первого запуска таблицы нет
+func appliedRecords(db *gorm.DB) (map[int64]schemaMigration, error) {
+	records := make(map[int64]schemaMigration)
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return records, nil
+	}
+
+	var list []schemaMigration
+	if err := db.Order("version").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for _, record := range list {
+		records[record.Version] = record
+	}
+	return records, nil
+}
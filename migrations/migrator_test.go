@@ -0,0 +1,300 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// baselineUser модель пользователя до перехода на SQL миграции (AutoMigrate)
+type baselineUser struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Username  string
+	Email     string `gorm:"unique;not null"`
+	Password  string `gorm:"not null"`
+	FirstName string
+	LastName  string
+	Role      string `gorm:"default:user"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+// baselineBook модель книги до перехода на SQL миграции
+type baselineBook struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Title       string    `gorm:"not null"`
+	AuthorID    uuid.UUID `gorm:"not null"`
+	Description string
+	ISBN        string `gorm:"unique"`
+	PublishYear int
+	CoverURL    string
+	FileURL     string
+	Genre       string
+	Language    string
+	PageCount   int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (baselineBook) TableName() string { return "books" }
+
+// baselineAuthorBook модель связи пользователя и книги до перехода на SQL миграции
+type baselineAuthorBook struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID       uuid.UUID `gorm:"not null"`
+	BookID       uuid.UUID `gorm:"not null"`
+	IsFavorite   bool      `gorm:"default:false"`
+	ReadingState string    `gorm:"default:'not_started'"`
+	ReadProgress int       `gorm:"default:0"`
+	LastReadPage int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (baselineAuthorBook) TableName() string { return "author_books" }
+
+// baselineAuditEvent журнал аудита в первой версии (до IP, User-Agent и идентификатора запроса)
+type baselineAuditEvent struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Action    string     `gorm:"not null;index"`
+	ActorID   *uuid.UUID `gorm:"type:uuid;index"`
+	TargetID  *uuid.UUID `gorm:"type:uuid;index"`
+	Details   string     `gorm:"type:text"`
+	CreatedAt time.Time  `gorm:"index"`
+}
+
+func (baselineAuditEvent) TableName() string { return "audit_events" }
+
+// openTestDB подключается к TEST_DATABASE_URL и работает в отдельной временной схеме.
+// Без TEST_DATABASE_URL тест пропускается.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL не задан")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("подключение к базе: %v", err)
+	}
+	schema := "migrations_test_" + uuid.NewString()[:8]
+	if err := admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)).Error; err != nil {
+		t.Fatalf("создание схемы: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(fmt.Sprintf("DROP SCHEMA %s CASCADE", schema))
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("подключение к базе: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Одно соединение: search_path задается для сессии
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.Exec(fmt.Sprintf("SET search_path TO %s, public", schema)).Error; err != nil {
+		t.Fatalf("search_path: %v", err)
+	}
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatalf("uuid-ossp: %v", err)
+	}
+	return db
+}
+
+func TestLoadOrdersVersionsWithBothDirections(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("нет встроенных миграций")
+	}
+	for i, migration := range migrations {
+		if migration.Up == "" || migration.Down == "" || migration.Checksum == "" {
+			t.Errorf("миграция %d_%s: пустой SQL или контрольная сумма", migration.Version, migration.Name)
+		}
+		if i > 0 && migrations[i-1].Version >= migration.Version {
+			t.Errorf("версии не по возрастанию: %d, %d", migrations[i-1].Version, migration.Version)
+		}
+	}
+}
+
+func TestUpOnFreshDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	all, _ := Load()
+	if len(applied) != len(all) {
+		t.Fatalf("применено %d миграций, ожидалось %d", len(applied), len(all))
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("после Up остались непримененные миграции: %v", pending)
+	}
+}
+
+func TestUpOnBaselineAutoMigrateDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	// Схема, созданная AutoMigrate исходной версии, и журнал аудита первой версии
+	if err := db.AutoMigrate(&baselineUser{}, &baselineBook{}, &baselineAuthorBook{}, &baselineAuditEvent{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	user := baselineUser{Username: "Reader", Email: "Reader@Example.com", Password: "hash"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	book := baselineBook{Title: "Book", AuthorID: user.ID, ISBN: "978-0"}
+	if err := db.Create(&book).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up на базе AutoMigrate: %v", err)
+	}
+
+	columns := map[string][]string{
+		"users": {
+			"auth_source", "mfa_enabled", "status", "status_reason", "status_until",
+			"sessions_revoked_at", "deletion_scheduled_for", "deleted_at",
+		},
+		"books":        {"deleted_at"},
+		"audit_events": {"impersonator_id", "ip", "user_agent", "request_id"},
+	}
+	for table, names := range columns {
+		for _, name := range names {
+			if !db.Migrator().HasColumn(table, name) {
+				t.Errorf("нет столбца %s.%s", table, name)
+			}
+		}
+	}
+
+	var row struct {
+		Email      string
+		Status     string
+		AuthSource string
+		MFAEnabled bool `gorm:"column:mfa_enabled"`
+	}
+	if err := db.Raw("SELECT email, status, auth_source, mfa_enabled FROM users WHERE id = ?", user.ID).Scan(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+	if row.Email != "reader@example.com" || row.Status != "active" || row.AuthSource != "local" || row.MFAEnabled {
+		t.Errorf("существующий пользователь после миграции: %+v", row)
+	}
+}
+
+func TestUpReportsCaseInsensitiveIdentifierCollisions(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := db.AutoMigrate(&baselineUser{}, &baselineBook{}, &baselineAuthorBook{}, &baselineAuditEvent{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	for _, user := range []baselineUser{
+		{Username: "reader", Email: "Reader@Example.com", Password: "hash"},
+		{Username: "other", Email: "reader@example.com", Password: "hash"},
+	} {
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err == nil {
+		t.Fatal("миграция применена поверх совпадающих email")
+	}
+	if !strings.Contains(err.Error(), "reader@example.com") {
+		t.Errorf("ошибка не называет совпадающий email: %v", err)
+	}
+}
+
+func TestDownRevertsLastMigration(t *testing.T) {
+	db := openTestDB(t)
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	reverted, err := migrator.Down(1)
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	all, _ := Load()
+	if len(reverted) != 1 || reverted[0].Version != all[len(all)-1].Version {
+		t.Fatalf("откачены миграции %v", reverted)
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Version != reverted[0].Version {
+		t.Fatalf("после Down ожидается одна непримененная миграция, получено %v", pending)
+	}
+}
+
+func TestUpRefusesModifiedMigration(t *testing.T) {
+	db := openTestDB(t)
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := db.Exec("UPDATE schema_migrations SET checksum = 'edited' WHERE version = 1").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.Up(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("ожидалась ErrChecksumMismatch, получено %v", err)
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].State != StateModified {
+		t.Errorf("состояние измененной миграции = %q", statuses[0].State)
+	}
+}
@@ -0,0 +1,2 @@
+-- Генерация UUID по умолчанию для первичных ключей (uuid_generate_v4)
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
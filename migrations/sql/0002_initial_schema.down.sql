@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS data_exports;
+DROP TABLE IF EXISTS email_changes;
+DROP TABLE IF EXISTS login_events;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS web_authn_sessions;
+DROP TABLE IF EXISTS credentials;
+DROP TABLE IF EXISTS login_codes;
+DROP TABLE IF EXISTS author_books;
+DROP TABLE IF EXISTS books;
+DROP TABLE IF EXISTS users;
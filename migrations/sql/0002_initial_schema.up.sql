@@ -0,0 +1,251 @@
+-- Исходная схема. IF NOT EXISTS позволяет применить миграцию к базе,
+-- созданной ранее через AutoMigrate: существующие таблицы не пересоздаются,
+-- а недостающие в них столбцы добавляются через ADD COLUMN IF NOT EXISTS
+-- до создания индексов по этим столбцам.
+
+CREATE TABLE IF NOT EXISTS users (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	username text,
+	email text NOT NULL,
+	password text NOT NULL,
+	first_name text,
+	last_name text,
+	role text DEFAULT 'user',
+	auth_source text DEFAULT 'local',
+	mfa_enabled boolean DEFAULT false,
+	status text NOT NULL DEFAULT 'active',
+	status_reason text,
+	status_until timestamptz,
+	sessions_revoked_at timestamptz,
+	deletion_scheduled_for timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	CONSTRAINT users_pkey PRIMARY KEY (id),
+	CONSTRAINT uni_users_email UNIQUE (email)
+);
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS auth_source text DEFAULT 'local',
+	ADD COLUMN IF NOT EXISTS mfa_enabled boolean DEFAULT false,
+	ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'active',
+	ADD COLUMN IF NOT EXISTS status_reason text,
+	ADD COLUMN IF NOT EXISTS status_until timestamptz,
+	ADD COLUMN IF NOT EXISTS sessions_revoked_at timestamptz,
+	ADD COLUMN IF NOT EXISTS deletion_scheduled_for timestamptz,
+	ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_users_status ON users (status);
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_for ON users (deletion_scheduled_for);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS books (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	title text NOT NULL,
+	author_id uuid NOT NULL,
+	description text,
+	isbn text,
+	publish_year bigint,
+	cover_url text,
+	file_url text,
+	genre text,
+	language text,
+	page_count bigint,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	CONSTRAINT books_pkey PRIMARY KEY (id),
+	CONSTRAINT uni_books_isbn UNIQUE (isbn)
+);
+ALTER TABLE books
+	ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books (deleted_at);
+
+CREATE TABLE IF NOT EXISTS author_books (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	book_id uuid NOT NULL,
+	is_favorite boolean DEFAULT false,
+	reading_state text DEFAULT 'not_started',
+	read_progress bigint DEFAULT 0,
+	last_read_page bigint,
+	created_at timestamptz,
+	updated_at timestamptz,
+	CONSTRAINT author_books_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_author_books_book FOREIGN KEY (book_id) REFERENCES books (id),
+	CONSTRAINT fk_author_books_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS login_codes (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	kind text NOT NULL,
+	code_hash text NOT NULL,
+	device_hash text NOT NULL,
+	attempts bigint DEFAULT 0,
+	expires_at timestamptz NOT NULL,
+	used_at timestamptz,
+	created_at timestamptz,
+	CONSTRAINT login_codes_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_login_codes_user_id ON login_codes (user_id);
+CREATE INDEX IF NOT EXISTS idx_login_codes_code_hash ON login_codes (code_hash);
+
+CREATE TABLE IF NOT EXISTS credentials (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	name text,
+	credential_id bytea NOT NULL,
+	public_key bytea NOT NULL,
+	attestation_type text,
+	aa_guid bytea,
+	sign_count bigint DEFAULT 0,
+	flags smallint,
+	transports text,
+	last_used_at timestamptz,
+	created_at timestamptz,
+	CONSTRAINT credentials_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_credentials_user_id ON credentials (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credentials_credential_id ON credentials (credential_id);
+
+CREATE TABLE IF NOT EXISTS web_authn_sessions (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	user_id uuid,
+	purpose text NOT NULL,
+	data text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz,
+	CONSTRAINT web_authn_sessions_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_web_authn_sessions_user_id ON web_authn_sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_web_authn_sessions_expires_at ON web_authn_sessions (expires_at);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	action text NOT NULL,
+	actor_id uuid,
+	impersonator_id uuid,
+	target_id uuid,
+	ip text,
+	user_agent text,
+	request_id text,
+	details text,
+	created_at timestamptz,
+	CONSTRAINT audit_events_pkey PRIMARY KEY (id)
+);
+ALTER TABLE audit_events
+	ADD COLUMN IF NOT EXISTS impersonator_id uuid,
+	ADD COLUMN IF NOT EXISTS ip text,
+	ADD COLUMN IF NOT EXISTS user_agent text,
+	ADD COLUMN IF NOT EXISTS request_id text;
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events (action);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target_id ON audit_events (target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_request_id ON audit_events (request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	id text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz,
+	CONSTRAINT revoked_tokens_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+	id uuid NOT NULL,
+	event_type text NOT NULL,
+	aggregate_id uuid NOT NULL,
+	payload text NOT NULL,
+	processed_at timestamptz,
+	created_at timestamptz,
+	CONSTRAINT outbox_events_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_event_type ON outbox_events (event_type);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_processed_at ON outbox_events (processed_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON outbox_events (created_at);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	url text NOT NULL,
+	secret text NOT NULL,
+	event_types text,
+	description text,
+	active boolean NOT NULL DEFAULT true,
+	created_at timestamptz,
+	updated_at timestamptz,
+	CONSTRAINT webhooks_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	webhook_id uuid NOT NULL,
+	event_id uuid NOT NULL,
+	status text NOT NULL DEFAULT 'pending',
+	attempts bigint NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz,
+	last_status_code bigint,
+	last_error text,
+	delivered_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE,
+	CONSTRAINT fk_webhook_deliveries_event FOREIGN KEY (event_id) REFERENCES outbox_events (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS login_events (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	success boolean NOT NULL,
+	method text NOT NULL,
+	ip text,
+	user_agent text,
+	device_fingerprint text,
+	new_device boolean NOT NULL DEFAULT false,
+	created_at timestamptz,
+	CONSTRAINT login_events_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_login_events_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_login_events_user_created ON login_events (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_events_device_fingerprint ON login_events (device_fingerprint);
+
+CREATE TABLE IF NOT EXISTS email_changes (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	old_email text NOT NULL,
+	new_email text NOT NULL,
+	confirm_token_hash text NOT NULL,
+	cancel_token_hash text NOT NULL,
+	revoke_sessions boolean NOT NULL DEFAULT false,
+	expires_at timestamptz NOT NULL,
+	cancel_expires_at timestamptz NOT NULL,
+	confirmed_at timestamptz,
+	cancelled_at timestamptz,
+	created_at timestamptz,
+	CONSTRAINT email_changes_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_email_changes_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_email_changes_user_id ON email_changes (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_changes_confirm_token_hash ON email_changes (confirm_token_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_changes_cancel_token_hash ON email_changes (cancel_token_hash);
+
+CREATE TABLE IF NOT EXISTS data_exports (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	status text NOT NULL,
+	archive bytea,
+	error text,
+	locked_until timestamptz,
+	expires_at timestamptz,
+	completed_at timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz,
+	CONSTRAINT data_exports_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_data_exports_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports (user_id);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports (status);
+CREATE INDEX IF NOT EXISTS idx_data_exports_expires_at ON data_exports (expires_at);
@@ -0,0 +1,27 @@
+-- Email и имена пользователей уникальны без учета регистра.
+-- Если существующие данные уже содержат совпадения, миграция прерывается со списком
+-- учетных записей: их нужно объединить или переименовать вручную.
+DO $$
+DECLARE
+	collisions text;
+BEGIN
+	SELECT string_agg(format('%s %L: %s', field, value, ids), E'\n')
+	INTO collisions
+	FROM (
+		SELECT 'email' AS field, lower(trim(email)) AS value, string_agg(id::text, ', ' ORDER BY created_at) AS ids
+		FROM users GROUP BY lower(trim(email)) HAVING count(*) > 1
+		UNION ALL
+		SELECT 'username', lower(trim(username)), string_agg(id::text, ', ' ORDER BY created_at)
+		FROM users WHERE trim(username) <> '' GROUP BY lower(trim(username)) HAVING count(*) > 1
+	) AS duplicates;
+
+	IF collisions IS NOT NULL THEN
+		RAISE EXCEPTION E'users with case-insensitively equal identifiers:\n%', collisions;
+	END IF;
+END;
+$$;
+
+UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email));
+UPDATE users SET username = trim(username) WHERE username <> trim(username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users (lower(username)) WHERE username <> '';
@@ -0,0 +1,8 @@
+-- ID принятых SAML утверждений хранятся до окончания их срока действия для защиты от повторного предъявления
+CREATE TABLE IF NOT EXISTS saml_assertions (
+	id text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz,
+	CONSTRAINT saml_assertions_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_saml_assertions_expires_at ON saml_assertions (expires_at);
@@ -0,0 +1,51 @@
+// models/audit_event.go - журнал событий безопасности
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// События журнала аудита
+const (
+	AuditLoginSuccess       = "login.success"
+	AuditLoginFailure       = "login.failure"
+	AuditLoginMFARequired   = "login.mfa_required"
+	AuditRegister           = "user.register"
+	AuditLogout             = "user.logout"
+	AuditUserUpdate         = "user.update"
+	AuditRoleChange         = "user.role_change"
+	AuditUserDelete         = "user.delete"
+	AuditUserRestore        = "user.restore"
+	AuditUserPurge          = "user.purge"
+	AuditUserSuspend        = "user.suspend"
+	AuditUserUnsuspend      = "user.unsuspend"
+	AuditTokenRevoked       = "token.revoked"
+	AuditStepUp             = "auth.step_up"
+	AuditSessionsRevoked    = "user.sessions_revoked"
+	AuditPasswordReset      = "user.password_reset"
+	AuditSigningKeyRotated  = "auth.signing_key_rotated"
+	AuditEmailChangeRequest = "email_change.requested"
+	AuditEmailChangeConfirm = "email_change.confirmed"
+	AuditEmailChangeCancel  = "email_change.cancelled"
+	AuditEmailChangeRevert  = "email_change.reverted"
+	AuditDataExportRequest  = "data_export.requested"
+	AuditDataExportDownload = "data_export.downloaded"
+	AuditImpersonationStart = "impersonation.start"
+	AuditImpersonationEnd   = "impersonation.end"
+)
+
+// AuditEvent представляет запись журнала аудита (только добавление)
+type AuditEvent struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Action         string     `gorm:"not null;index" json:"action"`
+	ActorID        *uuid.UUID `gorm:"type:uuid;index" json:"actor_id"`
+	ImpersonatorID *uuid.UUID `gorm:"type:uuid" json:"impersonator_id,omitempty"`
+	TargetID       *uuid.UUID `gorm:"type:uuid;index" json:"target_id"`
+	IP             string     `json:"ip"`
+	UserAgent      string     `json:"user_agent"`
+	RequestID      string     `gorm:"index" json:"request_id"`
+	Details        string     `gorm:"type:text" json:"details"`
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`
+}
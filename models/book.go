@@ -4,6 +4,7 @@ import (
 	"time"
 	
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Book struct {
@@ -20,6 +21,7 @@ type Book struct {
 	PageCount   int       `json:"page_count"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 	
 	// Связи с другими моделями
 	UserBooks  []AuthorBook `gorm:"foreignKey:BookID" json:"user_books,omitempty"`
@@ -0,0 +1,42 @@
+// models/credential.go - ключи доступа (WebAuthn / passkeys) пользователя
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Credential представляет зарегистрированный ключ WebAuthn
+type Credential struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name            string     `json:"name"`
+	CredentialID    []byte     `gorm:"not null;uniqueIndex" json:"-"`
+	PublicKey       []byte     `gorm:"not null" json:"-"`
+	AttestationType string     `json:"attestation_type"`
+	AAGUID          []byte     `json:"-"`
+	SignCount       uint32     `gorm:"default:0" json:"sign_count"`
+	Flags           uint8      `json:"-"` // сырые флаги аутентификатора (UV, BE, BS)
+	Transports      string     `json:"transports"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Назначения церемоний WebAuthn
+const (
+	WebAuthnPurposeRegistration = "registration"
+	WebAuthnPurposeLogin        = "login"
+	WebAuthnPurposeMFA          = "mfa"
+	WebAuthnPurposeReauth       = "reauth"
+)
+
+// WebAuthnSession хранит challenge незавершенной церемонии WebAuthn
+type WebAuthnSession struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	Purpose   string     `gorm:"not null" json:"purpose"`
+	Data      string     `gorm:"type:text;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
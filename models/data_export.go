@@ -0,0 +1,33 @@
+// models/data_export.go - выгрузка персональных данных пользователя
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы выгрузки
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportReady      = "ready"
+	DataExportFailed     = "failed"
+)
+
+// DataExport представляет запрос пользователя на выгрузку своих данных.
+// ZIP архив собирается фоновым обработчиком и хранится до истечения ссылки на скачивание.
+type DataExport struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Status      string     `gorm:"not null;index" json:"status"`
+	Archive     []byte     `gorm:"type:bytea" json:"-"`
+	Error       string     `json:"error,omitempty"`
+	LockedUntil *time.Time `json:"-"` // аренда обработчика; по истечении выгрузка собирается заново
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
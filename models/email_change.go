@@ -0,0 +1,28 @@
+// models/email_change.go - подтверждение смены email
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailChange представляет запрос на смену email, ожидающий подтверждения с нового адреса.
+// Ссылка отмены отправляется на старый адрес и действует дольше ссылки подтверждения,
+// чтобы владелец мог откатить уже подтвержденную смену.
+type EmailChange struct {
+	ID               uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	OldEmail         string     `gorm:"not null" json:"old_email"`
+	NewEmail         string     `gorm:"not null" json:"new_email"`
+	ConfirmTokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	CancelTokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	RevokeSessions   bool       `gorm:"not null;default:false" json:"revoke_sessions"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expires_at"`
+	CancelExpiresAt  time.Time  `gorm:"not null" json:"cancel_expires_at"`
+	ConfirmedAt      *time.Time `json:"confirmed_at"`
+	CancelledAt      *time.Time `json:"cancelled_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,27 @@
+// models/login_code.go - одноразовые коды и ссылки для входа без пароля
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Виды одноразовых кодов входа
+const (
+	LoginCodeMagicLink = "magic_link"
+	LoginCodeOTP       = "otp"
+)
+
+// LoginCode хранит HMAC одноразового кода или ссылки, привязанный к устройству
+type LoginCode struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Kind       string     `gorm:"not null" json:"kind"`
+	CodeHash   string     `gorm:"not null;index" json:"-"`
+	DeviceHash string     `gorm:"not null" json:"-"`
+	Attempts   int        `gorm:"default:0" json:"attempts"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
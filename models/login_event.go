@@ -0,0 +1,37 @@
+// models/login_event.go - история входов пользователя
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Способы входа
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodMagicLink = "magic_link"
+	LoginMethodOTP       = "otp"
+	LoginMethodPasskey   = "passkey"
+	LoginMethodMFA       = "password+passkey"
+	LoginMethodSAML      = "saml"
+
+	// Повторная аутентификация для чувствительных операций
+	LoginMethodStepUpPassword = "step_up_password"
+	LoginMethodStepUpPasskey  = "step_up_passkey"
+)
+
+// LoginEvent представляет успешную или неудачную попытку входа пользователя
+type LoginEvent struct {
+	ID                uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID            uuid.UUID `gorm:"type:uuid;not null;index:idx_login_events_user_created,priority:1" json:"user_id"`
+	Success           bool      `gorm:"not null" json:"success"`
+	Method            string    `gorm:"not null" json:"method"`
+	IP                string    `json:"ip"`
+	UserAgent         string    `json:"user_agent"`
+	DeviceFingerprint string    `gorm:"index" json:"device_fingerprint"`
+	NewDevice         bool      `gorm:"not null;default:false" json:"new_device"`
+	CreatedAt         time.Time `gorm:"index:idx_login_events_user_created,priority:2" json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
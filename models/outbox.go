@@ -0,0 +1,89 @@
+// models/outbox.go - исходящие доменные события и их доставка во внешние системы
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы доменных событий
+const (
+	EventUserRegistered = "user.registered"
+	EventUserUpdated    = "user.updated"
+	EventUserDeleted    = "user.deleted"
+	EventUserRestored   = "user.restored"
+	EventBookCreated    = "book.created"
+	EventBookUpdated    = "book.updated"
+)
+
+// EventTypes перечисляет все типы событий, на которые можно подписать webhook
+var EventTypes = []string{
+	EventUserRegistered,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventUserRestored,
+	EventBookCreated,
+	EventBookUpdated,
+}
+
+// Статусы доставки события
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// OutboxEvent представляет доменное событие, записанное в той же транзакции, что и изменение данных
+type OutboxEvent struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	EventType   string     `gorm:"not null;index" json:"event_type"`
+	AggregateID uuid.UUID  `gorm:"type:uuid;not null" json:"aggregate_id"`
+	Payload     string     `gorm:"type:text;not null" json:"payload"`
+	ProcessedAt *time.Time `gorm:"index" json:"processed_at"`
+	CreatedAt   time.Time  `gorm:"index" json:"created_at"`
+}
+
+// Webhook представляет зарегистрированный адрес для доставки событий
+type Webhook struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	URL         string    `gorm:"not null" json:"url"`
+	Secret      string    `gorm:"not null" json:"-"`
+	EventTypes  string    `json:"event_types"` // через запятую; пусто — все события
+	Description string    `json:"description"`
+	Active      bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subscribes проверяет, подписан ли webhook на тип события
+func (w *Webhook) Subscribes(eventType string) bool {
+	if w.EventTypes == "" {
+		return true
+	}
+	for _, t := range strings.Split(w.EventTypes, ",") {
+		if strings.TrimSpace(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery представляет доставку одного события на один webhook
+type WebhookDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	WebhookID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	EventID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"event_id"`
+	Status         string     `gorm:"not null;default:pending;index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Webhook Webhook     `gorm:"foreignKey:WebhookID;constraint:OnDelete:CASCADE" json:"-"`
+	Event   OutboxEvent `gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,11 @@
+// models/revoked_token.go - отозванные JWT токены
+package models
+
+import "time"
+
+// RevokedToken хранит идентификатор (jti) отозванного токена до истечения его срока
+type RevokedToken struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+// models/saml_assertion.go - использованные SAML утверждения
+package models
+
+import "time"
+
+// SAMLAssertion хранит ID принятого SAML утверждения до окончания его срока действия,
+// чтобы перехваченный ответ IdP нельзя было предъявить повторно
+type SAMLAssertion struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -2,6 +2,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -18,24 +19,85 @@ type User struct {
 	FirstName string    `json:"first_name"`
 	LastName  string    `json:"last_name"`
 	Role      string    `gorm:"default:user" json:"role"`
+	AuthSource string   `gorm:"default:local" json:"auth_source"` // local, ldap или saml
+	MFAEnabled bool     `gorm:"default:false" json:"mfa_enabled"` // требовать ключ доступа после пароля
+	Status       string     `gorm:"not null;default:active;index" json:"status"` // active, suspended или banned
+	StatusReason string     `json:"status_reason,omitempty"`
+	StatusUntil  *time.Time `json:"status_until,omitempty"` // окончание временной блокировки
+	SessionsRevokedAt *time.Time `json:"-"` // токены, выпущенные до этого момента, недействительны
+	DeletionScheduledFor *time.Time `gorm:"index" json:"deletion_scheduled_for,omitempty"` // окончательное удаление после отсрочки
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	passwordHashed bool // Password уже содержит хеш; задается через SetPasswordHash
 }
 
-// BeforeSave хеширует пароль перед сохранением пользователя
+// Источники учетных записей пользователей
+const (
+	AuthSourceLocal = "local"
+	AuthSourceLDAP  = "ldap"
+	AuthSourceSAML  = "saml"
+)
+
+// Статусы учетной записи
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
+// BlockedAt сообщает, заблокирован ли пользователь в момент now.
+// Истекшая временная блокировка не действует, даже если фоновое снятие еще не выполнено.
+func (u *User) BlockedAt(now time.Time) bool {
+	if u.Status == "" || u.Status == UserStatusActive {
+		return false
+	}
+	return u.StatusUntil == nil || now.Before(*u.StatusUntil)
+}
+
+// NormalizeEmail приводит email к виду, в котором он хранится и сравнивается
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// SetPasswordHash задает готовое значение пароля, которое BeforeSave сохранит без хеширования.
+// Используется при создании пользователей внешних провайдеров, у которых нет локального пароля.
+func (u *User) SetPasswordHash(hash string) {
+	u.Password = hash
+	u.passwordHashed = true
+}
+
+// BeforeSave нормализует email и имя пользователя и хеширует пароль перед сохранением.
+// Уникальность email и имени пользователя без учета регистра обеспечивается индексами lower(...).
+// Пароль хешируется, только если запрос записывает столбец password: обновление других
+// столбцов загруженного пользователя (PatchUser) не трогает сохраненный хеш. Вид значения
+// не проверяется, поэтому пароль, похожий на bcrypt-хеш, тоже будет захеширован.
 func (u *User) BeforeSave(tx *gorm.DB) error {
-	// Хешируем пароль только если он был изменен
-	if u.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
-		if err != nil {
-			return err
-		}
-		u.Password = string(hashedPassword)
+	u.Email = NormalizeEmail(u.Email)
+	u.Username = strings.TrimSpace(u.Username)
+
+	if u.Password == "" || u.passwordHashed || !savesColumn(tx, "password") {
+		return nil
 	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hashedPassword)
+	u.passwordHashed = true
 	return nil
 }
 
+// savesColumn сообщает, записывает ли текущий запрос столбец с учетом Select и Omit
+func savesColumn(tx *gorm.DB, column string) bool {
+	columns, restricted := tx.Statement.SelectAndOmitColumns(false, false)
+	if selected, ok := columns[column]; ok {
+		return selected
+	}
+	return !restricted
+}
+
 // CheckPassword проверяет, совпадает ли введенный пароль с хешированным
 func (u *User) CheckPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
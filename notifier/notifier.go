@@ -0,0 +1,70 @@
+// notifier/notifier.go - уведомления пользователей о событиях безопасности
+package notifier
+
+import (
+	"context"
+	"log/slog"
+
+	"AuthApplications/config"
+	"AuthApplications/mailer"
+	"AuthApplications/models"
+)
+
+// Notifier интерфейс отправки уведомления пользователю
+type Notifier interface {
+	Notify(ctx context.Context, user *models.User, subject, message string) error
+}
+
+// New выбирает способ уведомления по LOGIN_ALERT_NOTIFIER: email (по умолчанию), log или none
+func New(cfg *config.Config, m mailer.Mailer) Notifier {
+	switch cfg.LoginAlertNotifier {
+	case "log":
+		return NewLogNotifier()
+	case "none":
+		return NewNopNotifier()
+	default:
+		return NewMailNotifier(m)
+	}
+}
+
+// mailNotifier отправляет уведомления письмом на email пользователя
+type mailNotifier struct {
+	mailer mailer.Mailer
+}
+
+// NewMailNotifier создает уведомления по email
+func NewMailNotifier(m mailer.Mailer) Notifier {
+	return &mailNotifier{mailer: m}
+}
+
+// Notify отправляет письмо пользователю
+func (n *mailNotifier) Notify(ctx context.Context, user *models.User, subject, message string) error {
+	return n.mailer.Send(ctx, user.Email, subject, message)
+}
+
+// logNotifier пишет уведомления в лог (для разработки)
+type logNotifier struct{}
+
+// NewLogNotifier создает уведомления, которые только пишутся в лог
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+// Notify пишет уведомление в лог
+func (n *logNotifier) Notify(ctx context.Context, user *models.User, subject, message string) error {
+	slog.InfoContext(ctx, "Уведомление пользователя", "account_id", user.ID, "subject", subject, "message", message)
+	return nil
+}
+
+// nopNotifier отключает уведомления
+type nopNotifier struct{}
+
+// NewNopNotifier создает отключенные уведомления
+func NewNopNotifier() Notifier {
+	return &nopNotifier{}
+}
+
+// Notify ничего не делает
+func (n *nopNotifier) Notify(context.Context, *models.User, string, string) error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository интерфейс для работы с журналом аудита.
+// Журнал только пополняется: методов изменения и удаления записей нет.
+type AuditRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+	Find(ctx context.Context, filter dto.AuditFilter) ([]models.AuditEvent, int64, error)
+	Export(ctx context.Context, filter dto.AuditFilter, fn func(event *models.AuditEvent) error) error
+}
+
+// auditRepository реализация AuditRepository
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository создает новый репозиторий журнала аудита
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Create добавляет запись в журнал
+func (r *auditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// Find возвращает страницу записей по фильтрам (новые первыми) и общее количество
+func (r *auditRepository) Find(ctx context.Context, filter dto.AuditFilter) ([]models.AuditEvent, int64, error) {
+	var total int64
+	if err := r.where(ctx, filter).Model(&models.AuditEvent{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	err := r.where(ctx, filter).
+		Order("created_at DESC, id").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&events).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// Export построчно передает в fn все записи по фильтрам в хронологическом порядке,
+// не загружая весь результат в память
+func (r *auditRepository) Export(ctx context.Context, filter dto.AuditFilter, fn func(event *models.AuditEvent) error) error {
+	rows, err := r.where(ctx, filter).Model(&models.AuditEvent{}).Order("created_at, id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.AuditEvent
+		if err := r.db.ScanRows(rows, &event); err != nil {
+			return err
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// where применяет фильтры журнала
+func (r *auditRepository) where(ctx context.Context, filter dto.AuditFilter) *gorm.DB {
+	db := r.db.WithContext(ctx)
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != nil {
+		db = db.Where("(actor_id = ? OR impersonator_id = ?)", *filter.ActorID, *filter.ActorID)
+	}
+	if filter.TargetID != nil {
+		db = db.Where("target_id = ?", *filter.TargetID)
+	}
+	if filter.UserID != nil {
+		db = db.Where("(actor_id = ? OR impersonator_id = ? OR target_id = ?)", *filter.UserID, *filter.UserID, *filter.UserID)
+	}
+	if filter.IP != "" {
+		db = db.Where("ip = ?", filter.IP)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		db = db.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("created_at < ?", *filter.To)
+	}
+	return db
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"AuthApplications/dto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestAuditRepositoryFindFiltersByID(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name   string
+		filter dto.AuditFilter
+		where  string
+		args   []driver.Value
+	}{
+		{
+			name:   "actor_id",
+			filter: dto.AuditFilter{ActorID: &id},
+			where:  `WHERE (actor_id = $1 OR impersonator_id = $2)`,
+			args:   []driver.Value{id.String(), id.String()},
+		},
+		{
+			name:   "target_id",
+			filter: dto.AuditFilter{TargetID: &id},
+			where:  `WHERE target_id = $1`,
+			args:   []driver.Value{id.String()},
+		},
+		{
+			name:   "user_id",
+			filter: dto.AuditFilter{UserID: &id},
+			where:  `WHERE (actor_id = $1 OR impersonator_id = $2 OR target_id = $3)`,
+			args:   []driver.Value{id.String(), id.String(), id.String()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewAuditRepository(db)
+
+			where := regexp.QuoteMeta(tt.where)
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "audit_events" ` + where).
+				WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery(`SELECT \* FROM "audit_events" ` + where + ` ORDER BY created_at DESC, id LIMIT \$\d+`).
+				WithArgs(append(tt.args, 50)...).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "action", "actor_id"}).AddRow(uuid.New(), "login.success", id))
+
+			tt.filter.Page, tt.filter.PageSize = 1, 50
+			events, total, err := repo.Find(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 1 || len(events) != 1 {
+				t.Fatalf("total = %d, events = %d", total, len(events))
+			}
+		})
+	}
+}
@@ -6,19 +6,25 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"context"
 	"strings"
 	"errors"
+	"time"
 )
 
 
 type BookRepository interface {
-	Create(book *models.Book) error
-	FindByID(id uuid.UUID) (*models.Book, error)
-	FindAll() ([]models.Book, error)
-	FindByGenre(genre string) ([]models.Book, error)
-	Search(query string) ([]models.Book, error)
-	Patch(book *models.Book) error
-	DeleteByID(id uuid.UUID) error
+	Create(ctx context.Context, book *models.Book) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Book, error)
+	FindAll(ctx context.Context) ([]models.Book, error)
+	FindByGenre(ctx context.Context, genre string) ([]models.Book, error)
+	FindByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.Book, error)
+	FindReadingRecords(ctx context.Context, userID uuid.UUID) ([]models.AuthorBook, error)
+	Search(ctx context.Context, query string) ([]models.Book, error)
+	Patch(ctx context.Context, book *models.Book) error
+	DeleteByID(ctx context.Context, id uuid.UUID) error
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error)
+	WithTx(tx *gorm.DB) BookRepository
 }
 
 type bookRepository struct {
@@ -30,23 +36,28 @@ func NewBookRepository(db *gorm.DB) BookRepository {
 
 }
 
-func (r *bookRepository) Create(book *models.Book) error {
-	return r.db.Create(book).Error
+// WithTx возвращает репозиторий, работающий в переданной транзакции
+func (r *bookRepository) WithTx(tx *gorm.DB) BookRepository {
+	return &bookRepository{db: tx}
+}
+
+func (r *bookRepository) Create(ctx context.Context, book *models.Book) error {
+	return r.db.WithContext(ctx).Create(book).Error
 }
 
 
-func (r *bookRepository) FindAll() ([]models.Book, error) {
+func (r *bookRepository) FindAll(ctx context.Context) ([]models.Book, error) {
 	var books []models.Book
-	err := r.db.Find(&books).Error
+	err := r.db.WithContext(ctx).Find(&books).Error
 	if err != nil {
 		return nil, err
 	}
 	return books, nil
 }
 
-func (r *bookRepository) FindByID(id uuid.UUID) (*models.Book, error) {
+func (r *bookRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Book, error) {
     var bookID models.Book
-    err := r.db.First(&bookID, id).Error
+    err := r.db.WithContext(ctx).First(&bookID, id).Error
     if err != nil {
         if errors.Is(err, gorm.ErrRecordNotFound) {
             return nil, errors.New("book not found")
@@ -57,22 +68,36 @@ func (r *bookRepository) FindByID(id uuid.UUID) (*models.Book, error) {
 }
 
 
-func (r *bookRepository) FindByGenre(genre string) ([]models.Book, error) {
+func (r *bookRepository) FindByGenre(ctx context.Context, genre string) ([]models.Book, error) {
 	var bookGenre []models.Book
-    err := r.db.Where("genre = ?", genre).Find(&bookGenre).Error
+    err := r.db.WithContext(ctx).Where("genre = ?", genre).Find(&bookGenre).Error
     if err != nil {
         return nil, err
     }
     return bookGenre, nil
 }
 
-func (r *bookRepository) Search(query string) ([]models.Book, error) {
+// FindByAuthorID возвращает книги автора
+func (r *bookRepository) FindByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.Book, error) {
+	var books []models.Book
+	err := r.db.WithContext(ctx).Where("author_id = ?", authorID).Order("created_at").Find(&books).Error
+	return books, err
+}
+
+// FindReadingRecords возвращает записи о чтении книг пользователем
+func (r *bookRepository) FindReadingRecords(ctx context.Context, userID uuid.UUID) ([]models.AuthorBook, error) {
+	var records []models.AuthorBook
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&records).Error
+	return records, err
+}
+
+func (r *bookRepository) Search(ctx context.Context, query string) ([]models.Book, error) {
 	var books []models.Book
 	// Преобразуем поисковый запрос в нижний регистр для регистронезависимого поиска
 	queryLower := strings.ToLower(query)
 
 	// Выполняем поиск по нескольким полям: название, автор, жанр
-	err := r.db.Where("lower(title) LIKE ? OR lower(author) LIKE ? OR lower(genre) LIKE ?",
+	err := r.db.WithContext(ctx).Where("lower(title) LIKE ? OR lower(author) LIKE ? OR lower(genre) LIKE ?",
 		"%"+queryLower+"%", "%"+queryLower+"%", "%"+queryLower+"%").Find(&books).Error
 
 	if err != nil {
@@ -82,13 +107,13 @@ func (r *bookRepository) Search(query string) ([]models.Book, error) {
 	return books, nil
 }
 
-func (r *bookRepository) Patch(bookPatch *models.Book) error {
-    return r.db.Model(&models.Book{}).Where("id = ?", bookPatch.ID).Updates(bookPatch).Error
+func (r *bookRepository) Patch(ctx context.Context, bookPatch *models.Book) error {
+    return r.db.WithContext(ctx).Model(&models.Book{}).Where("id = ?", bookPatch.ID).Updates(bookPatch).Error
 }
 
-
-func (r *bookRepository) DeleteByID(id uuid.UUID) error {
-    result := r.db.Delete(&models.Book{}, id)
+// DeleteByID мягко удаляет книгу; окончательно она удаляется после отсрочки
+func (r *bookRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
+    result := r.db.WithContext(ctx).Delete(&models.Book{}, id)
     if result.Error != nil {
         return result.Error
     }
@@ -96,4 +121,17 @@ func (r *bookRepository) DeleteByID(id uuid.UUID) error {
         return errors.New("book not found")
     }
     return nil
-}
\ No newline at end of file
+}
+
+// PurgeDeletedBefore окончательно удаляет книги, мягко удаленные раньше before,
+// вместе с записями о чтении. Должен вызываться внутри транзакции.
+func (r *bookRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	db := r.db.WithContext(ctx)
+	books := db.Unscoped().Model(&models.Book{}).Select("id").Where("deleted_at < ?", before)
+	if err := db.Where("book_id IN (?)", books).Delete(&models.AuthorBook{}).Error; err != nil {
+		return 0, err
+	}
+
+	result := db.Unscoped().Where("deleted_at < ?", before).Delete(&models.Book{})
+	return result.RowsAffected, result.Error
+}
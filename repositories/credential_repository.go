@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CredentialRepository интерфейс для работы с ключами WebAuthn и сессиями церемоний
+type CredentialRepository interface {
+	Create(ctx context.Context, credential *models.Credential) error
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.Credential, error)
+	UpdateUsage(ctx context.Context, credential *models.Credential) error
+	Delete(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	CreateSession(ctx context.Context, session *models.WebAuthnSession) error
+	TakeSession(ctx context.Context, id uuid.UUID, purpose string) (*models.WebAuthnSession, error)
+}
+
+// credentialRepository реализация CredentialRepository
+type credentialRepository struct {
+	db *gorm.DB
+}
+
+// NewCredentialRepository создает новый репозиторий ключей WebAuthn
+func NewCredentialRepository(db *gorm.DB) CredentialRepository {
+	return &credentialRepository{db: db}
+}
+
+// Create сохраняет новый ключ
+func (r *credentialRepository) Create(ctx context.Context, credential *models.Credential) error {
+	return r.db.WithContext(ctx).Create(credential).Error
+}
+
+// FindByUserID возвращает все ключи пользователя
+func (r *credentialRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.Credential, error) {
+	var credentials []models.Credential
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// UpdateUsage сохраняет счетчик подписей, флаги и время последнего использования
+func (r *credentialRepository) UpdateUsage(ctx context.Context, credential *models.Credential) error {
+	return r.db.WithContext(ctx).Model(&models.Credential{}).
+		Where("id = ?", credential.ID).
+		Updates(map[string]interface{}{
+			"sign_count":   credential.SignCount,
+			"flags":        credential.Flags,
+			"last_used_at": credential.LastUsedAt,
+		}).Error
+}
+
+// Delete удаляет ключ пользователя; false означает, что ключ не найден
+func (r *credentialRepository) Delete(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.Credential{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountByUserID возвращает количество ключей пользователя
+func (r *credentialRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Credential{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// CreateSession сохраняет challenge церемонии и удаляет истекшие сессии
+func (r *credentialRepository) CreateSession(ctx context.Context, session *models.WebAuthnSession) error {
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnSession{}).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+// TakeSession атомарно извлекает и удаляет сессию, чтобы challenge нельзя было использовать повторно
+func (r *credentialRepository) TakeSession(ctx context.Context, id uuid.UUID, purpose string) (*models.WebAuthnSession, error) {
+	var sessions []models.WebAuthnSession
+	result := r.db.WithContext(ctx).Clauses(clause.Returning{}).
+		Where("id = ? AND purpose = ? AND expires_at > ?", id, purpose, time.Now()).
+		Delete(&sessions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(sessions) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &sessions[0], nil
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DataExportRepository интерфейс для работы с выгрузками персональных данных
+type DataExportRepository interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error)
+	FindInProgress(ctx context.Context, userID uuid.UUID) (*models.DataExport, error)
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]models.DataExport, error)
+	Update(ctx context.Context, export *models.DataExport) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// dataExportRepository реализация DataExportRepository
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+// NewDataExportRepository создает новый репозиторий выгрузок
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+// Create сохраняет запрос на выгрузку
+func (r *dataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	return r.db.WithContext(ctx).Omit("User").Create(export).Error
+}
+
+// FindByID находит выгрузку по ID
+func (r *dataExportRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.WithContext(ctx).First(&export, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// FindInProgress возвращает еще не собранную выгрузку пользователя
+func (r *dataExportRepository) FindInProgress(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status IN ?", userID, []string{models.DataExportPending, models.DataExportProcessing}).
+		Order("created_at DESC").
+		First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ClaimPending захватывает до limit ожидающих выгрузок, а также выгрузки,
+// аренда которых истекла (обработчик завершился, не успев собрать архив).
+// Захват продлевает аренду на lease, поэтому другие экземпляры приложения их не возьмут.
+func (r *dataExportRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]models.DataExport, error) {
+	var ids []uuid.UUID
+	now := time.Now()
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE data_exports SET status = ?, locked_until = ?
+		WHERE id IN (
+			SELECT id FROM data_exports
+			WHERE status = ? OR (status = ? AND locked_until <= ?)
+			ORDER BY created_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		models.DataExportProcessing, now.Add(lease),
+		models.DataExportPending, models.DataExportProcessing, now, limit,
+	).Scan(&ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var exports []models.DataExport
+	err = r.db.WithContext(ctx).Where("id IN ?", ids).Order("created_at").Find(&exports).Error
+	return exports, err
+}
+
+// Update сохраняет изменения выгрузки
+func (r *dataExportRepository) Update(ctx context.Context, export *models.DataExport) error {
+	return r.db.WithContext(ctx).Omit("User").Save(export).Error
+}
+
+// DeleteExpired удаляет выгрузки, ссылки на которые истекли, вместе с архивами
+func (r *dataExportRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.DataExport{})
+	return result.RowsAffected, result.Error
+}
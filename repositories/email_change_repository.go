@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailChangeRepository интерфейс для работы с запросами на смену email
+type EmailChangeRepository interface {
+	Create(ctx context.Context, change *models.EmailChange) error
+	CancelPending(ctx context.Context, userID uuid.UUID) error
+	FindPendingByConfirmHash(ctx context.Context, hash string) (*models.EmailChange, error)
+	FindByCancelHash(ctx context.Context, hash string) (*models.EmailChange, error)
+	Update(ctx context.Context, change *models.EmailChange) error
+	WithTx(tx *gorm.DB) EmailChangeRepository
+}
+
+// emailChangeRepository реализация EmailChangeRepository
+type emailChangeRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailChangeRepository создает новый репозиторий запросов на смену email
+func NewEmailChangeRepository(db *gorm.DB) EmailChangeRepository {
+	return &emailChangeRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в переданной транзакции
+func (r *emailChangeRepository) WithTx(tx *gorm.DB) EmailChangeRepository {
+	return &emailChangeRepository{db: tx}
+}
+
+// Create сохраняет запрос на смену email
+func (r *emailChangeRepository) Create(ctx context.Context, change *models.EmailChange) error {
+	return r.db.WithContext(ctx).Omit("User").Create(change).Error
+}
+
+// CancelPending отменяет неподтвержденные запросы пользователя
+func (r *emailChangeRepository) CancelPending(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.EmailChange{}).
+		Where("user_id = ? AND confirmed_at IS NULL AND cancelled_at IS NULL", userID).
+		Update("cancelled_at", time.Now()).Error
+}
+
+// FindPendingByConfirmHash находит неподтвержденный, неотмененный и неистекший запрос
+func (r *emailChangeRepository) FindPendingByConfirmHash(ctx context.Context, hash string) (*models.EmailChange, error) {
+	var change models.EmailChange
+	err := r.db.WithContext(ctx).
+		Where("confirm_token_hash = ? AND confirmed_at IS NULL AND cancelled_at IS NULL AND expires_at > ?", hash, time.Now()).
+		First(&change).Error
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// FindByCancelHash находит неотмененный запрос, ссылка отмены которого еще действует
+func (r *emailChangeRepository) FindByCancelHash(ctx context.Context, hash string) (*models.EmailChange, error) {
+	var change models.EmailChange
+	err := r.db.WithContext(ctx).
+		Where("cancel_token_hash = ? AND cancelled_at IS NULL AND cancel_expires_at > ?", hash, time.Now()).
+		First(&change).Error
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// Update сохраняет изменения запроса
+func (r *emailChangeRepository) Update(ctx context.Context, change *models.EmailChange) error {
+	return r.db.WithContext(ctx).Omit("User").Save(change).Error
+}
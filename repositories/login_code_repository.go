@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginCodeRepository интерфейс для работы с одноразовыми кодами входа
+type LoginCodeRepository interface {
+	Create(ctx context.Context, code *models.LoginCode) error
+	FindActiveByHash(ctx context.Context, kind, codeHash string) (*models.LoginCode, error)
+	FindLatestActive(ctx context.Context, userID uuid.UUID, kind string) (*models.LoginCode, error)
+	RegisterFailedAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) error
+	CountIssuedSince(ctx context.Context, userID uuid.UUID, kind string, since time.Time) (int64, error)
+	Consume(ctx context.Context, id uuid.UUID) (bool, error)
+	InvalidateActive(ctx context.Context, userID uuid.UUID, kind string) error
+}
+
+// loginCodeRepository реализация LoginCodeRepository
+type loginCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginCodeRepository создает новый репозиторий одноразовых кодов
+func NewLoginCodeRepository(db *gorm.DB) LoginCodeRepository {
+	return &loginCodeRepository{db: db}
+}
+
+// Create сохраняет новый код
+func (r *loginCodeRepository) Create(ctx context.Context, code *models.LoginCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+// FindActiveByHash находит неиспользованный и не истекший код по его хешу
+func (r *loginCodeRepository) FindActiveByHash(ctx context.Context, kind, codeHash string) (*models.LoginCode, error) {
+	var code models.LoginCode
+	err := r.db.WithContext(ctx).Where("kind = ? AND code_hash = ? AND used_at IS NULL AND expires_at > ?", kind, codeHash, time.Now()).
+		First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// FindLatestActive находит последний действующий код пользователя
+func (r *loginCodeRepository) FindLatestActive(ctx context.Context, userID uuid.UUID, kind string) (*models.LoginCode, error) {
+	var code models.LoginCode
+	err := r.db.WithContext(ctx).Where("user_id = ? AND kind = ? AND used_at IS NULL AND expires_at > ?", userID, kind, time.Now()).
+		Order("created_at DESC").
+		First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// RegisterFailedAttempt одним запросом увеличивает счетчик попыток и расходует код
+// на попытке номер maxAttempts; параллельные неверные попытки не теряются
+func (r *loginCodeRepository) RegisterFailedAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) error {
+	return r.db.WithContext(ctx).Exec(
+		"UPDATE login_codes SET attempts = attempts + 1, "+
+			"used_at = CASE WHEN attempts + 1 >= ? THEN now() ELSE used_at END "+
+			"WHERE id = ? AND used_at IS NULL",
+		maxAttempts, id,
+	).Error
+}
+
+// CountIssuedSince возвращает число кодов вида kind, выпущенных пользователю начиная с since
+func (r *loginCodeRepository) CountIssuedSince(ctx context.Context, userID uuid.UUID, kind string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.LoginCode{}).
+		Where("user_id = ? AND kind = ? AND created_at >= ?", userID, kind, since).
+		Count(&count).Error
+	return count, err
+}
+
+// Consume атомарно помечает код использованным; false означает, что код уже был использован
+func (r *loginCodeRepository) Consume(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.LoginCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidateActive помечает все действующие коды пользователя использованными
+func (r *loginCodeRepository) InvalidateActive(ctx context.Context, userID uuid.UUID, kind string) error {
+	return r.db.WithContext(ctx).Model(&models.LoginCode{}).
+		Where("user_id = ? AND kind = ? AND used_at IS NULL", userID, kind).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"AuthApplications/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestLoginCodeRepositoryRegisterFailedAttemptIsSingleUpdate(t *testing.T) {
+	db, mock := newMockDB(t)
+	id := uuid.New()
+
+	// Счетчик увеличивается и код расходуется в базе, без чтения и перезаписи всей строки
+	mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE login_codes SET attempts = attempts + 1, `+
+			`used_at = CASE WHEN attempts + 1 >= $1 THEN now() ELSE used_at END `+
+			`WHERE id = $2 AND used_at IS NULL`,
+	)).
+		WithArgs(5, id.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := NewLoginCodeRepository(db).RegisterFailedAttempt(context.Background(), id, 5); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoginCodeRepositoryCountIssuedSince(t *testing.T) {
+	db, mock := newMockDB(t)
+	userID := uuid.New()
+	since := time.Now().Add(-15 * time.Minute)
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT count(*) FROM "login_codes" WHERE user_id = $1 AND kind = $2 AND created_at >= $3`,
+	)).
+		WithArgs(userID.String(), models.LoginCodeOTP, since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	count, err := NewLoginCodeRepository(db).CountIssuedSince(context.Background(), userID, models.LoginCodeOTP, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("count = %d, ожидалось 2", count)
+	}
+}
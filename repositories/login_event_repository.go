@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginEventRepository интерфейс для работы с историей входов
+type LoginEventRepository interface {
+	Create(ctx context.Context, event *models.LoginEvent) error
+	HasSuccessfulLogin(ctx context.Context, userID uuid.UUID) (bool, error)
+	HasSuccessfulLoginFromDevice(ctx context.Context, userID uuid.UUID, fingerprint string) (bool, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]models.LoginEvent, int64, error)
+	FindAllByUserID(ctx context.Context, userID uuid.UUID) ([]models.LoginEvent, error)
+}
+
+// loginEventRepository реализация LoginEventRepository
+type loginEventRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginEventRepository создает новый репозиторий истории входов
+func NewLoginEventRepository(db *gorm.DB) LoginEventRepository {
+	return &loginEventRepository{db: db}
+}
+
+// Create сохраняет попытку входа
+func (r *loginEventRepository) Create(ctx context.Context, event *models.LoginEvent) error {
+	return r.db.WithContext(ctx).Omit("User").Create(event).Error
+}
+
+// HasSuccessfulLogin проверяет, входил ли пользователь раньше
+func (r *loginEventRepository) HasSuccessfulLogin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.LoginEvent{}).
+		Where("user_id = ? AND success", userID).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// HasSuccessfulLoginFromDevice проверяет, входил ли пользователь раньше с этого устройства
+func (r *loginEventRepository) HasSuccessfulLoginFromDevice(ctx context.Context, userID uuid.UUID, fingerprint string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.LoginEvent{}).
+		Where("user_id = ? AND success AND device_fingerprint = ?", userID, fingerprint).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindByUserID возвращает страницу истории входов пользователя (новые первыми) и общее количество
+func (r *loginEventRepository) FindByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]models.LoginEvent, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.LoginEvent{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.LoginEvent
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC, id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&events).Error
+	return events, total, err
+}
+
+// FindAllByUserID возвращает всю историю входов пользователя в хронологическом порядке
+func (r *loginEventRepository) FindAllByUserID(ctx context.Context, userID uuid.UUID) ([]models.LoginEvent, error) {
+	var events []models.LoginEvent
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at, id").Find(&events).Error
+	return events, err
+}
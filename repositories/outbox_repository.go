@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxRepository интерфейс для работы с исходящими доменными событиями
+type OutboxRepository interface {
+	Create(ctx context.Context, event *models.OutboxEvent) error
+	LockUnprocessed(ctx context.Context, limit int) ([]models.OutboxEvent, error)
+	MarkProcessed(ctx context.Context, ids []uuid.UUID) error
+	WithTx(tx *gorm.DB) OutboxRepository
+}
+
+// outboxRepository реализация OutboxRepository
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository создает новый репозиторий исходящих событий
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в переданной транзакции
+func (r *outboxRepository) WithTx(tx *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: tx}
+}
+
+// Create записывает событие
+func (r *outboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// LockUnprocessed блокирует до limit необработанных событий в порядке появления.
+// Уже заблокированные другим экземпляром приложения строки пропускаются.
+// Должен вызываться внутри транзакции.
+func (r *outboxRepository) LockUnprocessed(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("processed_at IS NULL").
+		Order("created_at, id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// MarkProcessed отмечает события как разосланные по webhook
+func (r *outboxRepository) MarkProcessed(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id IN ?", ids).
+		Update("processed_at", time.Now()).Error
+}
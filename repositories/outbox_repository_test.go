@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestOutboxRepositoryLockUnprocessedSkipsLockedRows(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	id := uuid.New()
+	mock.ExpectQuery(`SELECT \* FROM "outbox_events" WHERE processed_at IS NULL ORDER BY created_at, id LIMIT \$1 FOR UPDATE SKIP LOCKED`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type"}).AddRow(id, "user.registered"))
+
+	events, err := repo.LockUnprocessed(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ID != id {
+		t.Fatalf("events = %+v", events)
+	}
+}
+
+func TestOutboxRepositoryMarkProcessed(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	mock.ExpectExec(`UPDATE "outbox_events" SET "processed_at"=\$1 WHERE id IN \(\$2,\$3\)`).
+		WithArgs(sqlmock.AnyArg(), ids[0], ids[1]).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if err := repo.MarkProcessed(context.Background(), ids); err != nil {
+		t.Fatal(err)
+	}
+
+	// Пустой список не отправляет запрос
+	if err := repo.MarkProcessed(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"AuthApplications/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SAMLAssertionRepository интерфейс для учета использованных SAML утверждений
+type SAMLAssertionRepository interface {
+	Consume(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
+
+// samlAssertionRepository реализация SAMLAssertionRepository
+type samlAssertionRepository struct {
+	db *gorm.DB
+}
+
+// NewSAMLAssertionRepository создает новый репозиторий использованных SAML утверждений
+func NewSAMLAssertionRepository(db *gorm.DB) SAMLAssertionRepository {
+	return &samlAssertionRepository{db: db}
+}
+
+// Consume отмечает утверждение использованным и удаляет записи об уже истекших.
+// Возвращает false, если утверждение с таким ID уже было принято; вставка с
+// ON CONFLICT DO NOTHING атомарна и для одновременных запросов с одним ответом.
+func (r *samlAssertionRepository) Consume(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.SAMLAssertion{}).Error; err != nil {
+		return false, err
+	}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.SAMLAssertion{ID: id, ExpiresAt: expiresAt})
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSAMLAssertionRepositoryConsume(t *testing.T) {
+	expiresAt := time.Now().Add(5 * time.Minute)
+
+	tests := []struct {
+		name     string
+		inserted int64
+		want     bool
+	}{
+		{"первое предъявление", 1, true},
+		{"повторное предъявление", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+
+			mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "saml_assertions" WHERE expires_at < $1`)).
+				WithArgs(sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(
+				`INSERT INTO "saml_assertions" ("id","expires_at","created_at") VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`,
+			)).
+				WithArgs("id-assertion", expiresAt, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, tt.inserted))
+
+			consumed, err := NewSAMLAssertionRepository(db).Consume(context.Background(), "id-assertion", expiresAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if consumed != tt.want {
+				t.Errorf("consumed = %v, ожидалось %v", consumed, tt.want)
+			}
+		})
+	}
+}
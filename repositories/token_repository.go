@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"AuthApplications/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenRepository интерфейс для работы с отозванными токенами
+type TokenRepository interface {
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// tokenRepository реализация TokenRepository
+type tokenRepository struct {
+	db *gorm.DB
+}
+
+// NewTokenRepository создает новый репозиторий отозванных токенов
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+// Revoke добавляет токен в список отозванных и удаляет записи об уже истекших токенах
+func (r *tokenRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{ID: tokenID, ExpiresAt: expiresAt}).Error
+}
+
+// IsRevoked проверяет, отозван ли токен
+func (r *tokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.RevokedToken{}).Where("id = ?", tokenID).Count(&count).Error
+	return count > 0, err
+}
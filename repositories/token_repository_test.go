@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTokenRepositoryIsRevoked(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "revoked_tokens" WHERE id = $1`)).
+		WithArgs("token-id").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	revoked, err := NewTokenRepository(db).IsRevoked(context.Background(), "token-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Error("отозванный токен не обнаружен")
+	}
+}
+
+func TestTokenRepositoryIsRevokedHonorsContext(t *testing.T) {
+	db, _ := newMockDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Запрос отмененного HTTP-запроса не отправляется в базу
+	if _, err := NewTokenRepository(db).IsRevoked(ctx, "token-id"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ожидалась context.Canceled, получено %v", err)
+	}
+}
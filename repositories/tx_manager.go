@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// TxManager выполняет функцию в транзакции базы данных.
+// Репозитории привязываются к транзакции через WithTx; транзакция выполняется с контекстом ctx.
+type TxManager interface {
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+}
+
+// txManager реализация TxManager
+type txManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager создает новый менеджер транзакций
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db}
+}
+
+// Transaction выполняет fn в транзакции: фиксирует ее при успехе и откатывает при ошибке
+func (m *txManager) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Transaction(fn)
+}
@@ -1,6 +1,10 @@
 package repositories
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"AuthApplications/models"
 
 	"github.com/google/uuid"
@@ -9,12 +13,24 @@ import (
 
 // UserRepository интерфейс для работы с пользователями
 type UserRepository interface {
-	Create(user *models.User) error
-	FindByID(id uuid.UUID) (*models.User, error)
-	FindByEmail(email string) (*models.User, error)
-	FindAll() ([]models.User, error) 
-	PatchUser(user *models.User) error
-	DeleteByID(id uuid.UUID) error
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByIdentifier(ctx context.Context, identifier string) (*models.User, error)
+	UsernameTaken(ctx context.Context, username string, exceptID uuid.UUID) (bool, error)
+	FindAll(ctx context.Context) ([]models.User, error) 
+	PatchUser(ctx context.Context, user *models.User, columns ...string) error
+	ScheduleDeletion(ctx context.Context, id uuid.UUID, purgeAt time.Time) error
+	FindDeletedByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	FindPendingDeletionByIdentifier(ctx context.Context, identifier string) (*models.User, error)
+	Restore(ctx context.Context, id uuid.UUID) error
+	FindDueForPurge(ctx context.Context, now time.Time, limit int) ([]models.User, error)
+	Purge(ctx context.Context, id uuid.UUID) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status, reason string, until *time.Time) error
+	LiftExpiredSuspensions(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	RevokeAllSessions(ctx context.Context, at time.Time) (int64, error)
+	WithTx(tx *gorm.DB) UserRepository
 }
 
 // userRepository реализация UserRepository
@@ -27,47 +43,213 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// WithTx возвращает репозиторий, работающий в переданной транзакции
+func (r *userRepository) WithTx(tx *gorm.DB) UserRepository {
+	return &userRepository{db: tx}
+}
+
 // Create создает нового пользователя
-func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
 // FindAll возвращает всех пользователей
-func (r *userRepository) FindAll() ([]models.User, error) {
+func (r *userRepository) FindAll(ctx context.Context) ([]models.User, error) {
     var users []models.User
-    err := r.db.Find(&users).Error // GORM метод для выборки всех записей
+    err := r.db.WithContext(ctx).Find(&users).Error // GORM метод для выборки всех записей
     if err != nil {
         return nil, err
     }
     return users, nil
 }
 
-// FindByEmail находит пользователя по email
-func (r *userRepository) FindByEmail(email string) (*models.User, error) {
+// FindByEmail находит пользователя по email без учета регистра
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("lower(email) = ?", models.NormalizeEmail(email)).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByUsername находит пользователя по имени без учета регистра
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	// У внешних пользователей имя может быть пустым; пустое имя не идентифицирует никого
+	if strings.TrimSpace(username) == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("lower(username) = lower(?)", strings.TrimSpace(username)).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// UsernameTaken проверяет, занято ли имя другим пользователем, включая ожидающих удаления:
+// уникальный индекс lower(username) распространяется и на них
+func (r *userRepository) UsernameTaken(ctx context.Context, username string, exceptID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).
+		Where("lower(username) = lower(?) AND id <> ?", strings.TrimSpace(username), exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindByIdentifier находит пользователя по email или имени пользователя.
+// Имя пользователя не может содержать "@", поэтому вид идентификатора однозначен.
+func (r *userRepository) FindByIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	if strings.Contains(identifier, "@") {
+		return r.FindByEmail(ctx, identifier)
+	}
+	return r.FindByUsername(ctx, identifier)
+}
+
 // FindByID находит пользователя по ID
-func (r *userRepository) FindByID(id uuid.UUID) (*models.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// Update обновляет пользователя в базе данных
-func (r *userRepository) PatchUser(user *models.User) error {
-    return r.db.Save(user).Error
+// PatchUser сохраняет только перечисленные столбцы пользователя. Остальные столбцы
+// (статус, время отзыва сессий, пароль) не перезаписываются значениями, прочитанными ранее.
+func (r *userRepository) PatchUser(ctx context.Context, user *models.User, columns ...string) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(user).Select(columns).Updates(user).Error
+}
+
+// ScheduleDeletion мягко удаляет пользователя и назначает окончательное удаление на purgeAt.
+// Ранее выданные токены отзываются и не станут действительными после восстановления.
+func (r *userRepository) ScheduleDeletion(ctx context.Context, id uuid.UUID, purgeAt time.Time) error {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"deletion_scheduled_for": purgeAt,
+		"sessions_revoked_at":    now,
+		"deleted_at":             now,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// FindDeletedByID находит мягко удаленного пользователя по ID
+func (r *userRepository) FindDeletedByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindPendingDeletionByIdentifier находит по email или имени мягко удаленного пользователя,
+// отсрочка удаления которого еще не истекла
+func (r *userRepository) FindPendingDeletionByIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	db := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL AND deletion_scheduled_for > ?", time.Now())
+	if strings.Contains(identifier, "@") {
+		db = db.Where("lower(email) = ?", models.NormalizeEmail(identifier))
+	} else if strings.TrimSpace(identifier) != "" {
+		db = db.Where("lower(username) = lower(?)", strings.TrimSpace(identifier))
+	} else {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var user models.User
+	err := db.First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Restore отменяет мягкое удаление пользователя
+func (r *userRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"deletion_scheduled_for": nil,
+		"deleted_at":             nil,
+	}).Error
+}
+
+// FindDueForPurge возвращает до limit мягко удаленных пользователей, отсрочка удаления которых истекла
+func (r *userRepository) FindDueForPurge(ctx context.Context, now time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deletion_scheduled_for <= ?", now).
+		Order("deletion_scheduled_for").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// Purge окончательно удаляет пользователя вместе с записями о чтении, книгами автора,
+// кодами входа и ключами доступа. История входов, запросы на смену email и выгрузки
+// удаляются каскадно внешними ключами; журнал аудита сохраняется.
+// Должен вызываться внутри транзакции.
+func (r *userRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	db := r.db.WithContext(ctx)
+	books := db.Unscoped().Model(&models.Book{}).Select("id").Where("author_id = ?", id)
+	if err := db.Where("user_id = ? OR book_id IN (?)", id, books).Delete(&models.AuthorBook{}).Error; err != nil {
+		return err
+	}
+	if err := db.Unscoped().Where("author_id = ?", id).Delete(&models.Book{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("user_id = ?", id).Delete(&models.LoginCode{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("user_id = ?", id).Delete(&models.Credential{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("user_id = ?", id).Delete(&models.WebAuthnSession{}).Error; err != nil {
+		return err
+	}
+	return db.Unscoped().Where("id = ?", id).Delete(&models.User{}).Error
+}
+
+// UpdateStatus меняет статус учетной записи
+func (r *userRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status, reason string, until *time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        status,
+		"status_reason": reason,
+		"status_until":  until,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// LiftExpiredSuspensions возвращает в статус active пользователей, срок блокировки которых истек,
+// и возвращает их ID
+func (r *userRepository) LiftExpiredSuspensions(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE users SET status = ?, status_reason = '', status_until = NULL, updated_at = ?
+		WHERE status <> ? AND status_until <= ? AND deleted_at IS NULL
+		RETURNING id`,
+		models.UserStatusActive, now, models.UserStatusActive, now,
+	).Scan(&ids).Error
+	return ids, err
 }
 
-// DeleteByID удаляет пользователя из базы данных по ID
-func (r *userRepository) DeleteByID(id uuid.UUID) error {
-    return r.db.Where("id = ?", id).Delete(&models.User{}).Error
+// RevokeAllSessions делает недействительными токены всех пользователей, выпущенные до at,
+// и возвращает число затронутых учетных записей
+func (r *userRepository) RevokeAllSessions(ctx context.Context, at time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("1 = 1").Update("sessions_revoked_at", at)
+	return result.RowsAffected, result.Error
 }
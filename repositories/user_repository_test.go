@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"AuthApplications/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func TestUserRepositoryPatchUserUpdatesOnlyGivenColumns(t *testing.T) {
+	db, mock := newMockDB(t)
+	revokedAt := time.Now()
+	user := &models.User{
+		ID:                uuid.New(),
+		Email:             "user@example.com",
+		FirstName:         "Анна",
+		Status:            models.UserStatusActive,
+		SessionsRevokedAt: &revokedAt,
+	}
+
+	// Статус и время отзыва сессий, прочитанные до изменения, не записываются обратно
+	mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE "users" SET "first_name"=$1,"mfa_enabled"=$2,"updated_at"=$3 WHERE "users"."deleted_at" IS NULL AND "id" = $4`,
+	)).
+		WithArgs("Анна", false, sqlmock.AnyArg(), user.ID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := NewUserRepository(db).PatchUser(context.Background(), user, "first_name", "mfa_enabled"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRepositoryPatchUserWithoutColumns(t *testing.T) {
+	db, _ := newMockDB(t)
+
+	// Без столбцов запрос не выполняется; sqlmock отклонит любой неожиданный запрос
+	if err := NewUserRepository(db).PatchUser(context.Background(), &models.User{ID: uuid.New()}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// bcryptOf сопоставляет аргумент запроса с bcrypt-хешем пароля
+type bcryptOf string
+
+func (password bcryptOf) Match(value driver.Value) bool {
+	hash, ok := value.(string)
+	return ok && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// expectUserInsert ожидает вставку пользователя и возвращает сгенерированный идентификатор
+func expectUserInsert(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New().String()))
+}
+
+func TestUserRepositoryCreateHashesPassword(t *testing.T) {
+	db, mock := newMockDB(t)
+	expectUserInsert(mock)
+
+	user := &models.User{Email: "user@example.com", Password: "plain-password"}
+	if err := NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	if user.CheckPassword("plain-password") != nil {
+		t.Errorf("пароль сохранен без хеширования: %q", user.Password)
+	}
+}
+
+func TestUserRepositoryCreateHashesBcryptLookingPassword(t *testing.T) {
+	db, mock := newMockDB(t)
+	expectUserInsert(mock)
+
+	// Значение, похожее на bcrypt-хеш, — обычный пароль: подставить готовый хеш через API нельзя
+	hash, err := bcrypt.GenerateFromPassword([]byte("other"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Email: "user@example.com", Password: string(hash)}
+	if err := NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Password == string(hash) || user.CheckPassword(string(hash)) != nil {
+		t.Errorf("значение в формате bcrypt сохранено как готовый хеш")
+	}
+}
+
+func TestUserRepositoryCreateKeepsPresetPasswordHash(t *testing.T) {
+	db, mock := newMockDB(t)
+	expectUserInsert(mock)
+
+	user := &models.User{Email: "user@example.com"}
+	user.SetPasswordHash("!external")
+	if err := NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Password != "!external" {
+		t.Errorf("password = %q", user.Password)
+	}
+}
+
+func TestUserRepositoryPatchUserHashesPasswordOnlyWhenWritten(t *testing.T) {
+	db, mock := newMockDB(t)
+	user := &models.User{ID: uuid.New(), Email: "user@example.com", Password: "new-password"}
+
+	// Столбец password не записывается — значение в памяти не трогается
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "first_name"=$1,"updated_at"=$2`)).
+		WithArgs("", sqlmock.AnyArg(), user.ID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"=$1,"updated_at"=$2`)).
+		WithArgs(bcryptOf("new-password"), sqlmock.AnyArg(), user.ID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewUserRepository(db)
+	if err := repo.PatchUser(context.Background(), user, "first_name"); err != nil {
+		t.Fatal(err)
+	}
+	if user.Password != "new-password" {
+		t.Fatalf("пароль изменен запросом без столбца password: %q", user.Password)
+	}
+	if err := repo.PatchUser(context.Background(), user, "password"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRepositoryUsernameTakenIncludesDeletedUsers(t *testing.T) {
+	db, mock := newMockDB(t)
+	exceptID := uuid.New()
+
+	// Условия deleted_at нет: уникальный индекс распространяется на удаленных пользователей
+	mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT count(*) FROM "users" WHERE lower(username) = lower($1) AND id <> $2`,
+	)).
+		WithArgs("alice", exceptID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	taken, err := NewUserRepository(db).UsernameTaken(context.Background(), " alice ", exceptID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !taken {
+		t.Error("занятое имя не обнаружено")
+	}
+}
+
+func TestUserRepositoryScheduleDeletionRevokesSessions(t *testing.T) {
+	db, mock := newMockDB(t)
+	id := uuid.New()
+	purgeAt := time.Now().Add(30 * 24 * time.Hour)
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE "users" SET "deleted_at"=$1,"deletion_scheduled_for"=$2,"sessions_revoked_at"=$3,"updated_at"=$4 WHERE id = $5 AND "users"."deleted_at" IS NULL`,
+	)).
+		WithArgs(sqlmock.AnyArg(), purgeAt, sqlmock.AnyArg(), sqlmock.AnyArg(), id.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := NewUserRepository(db).ScheduleDeletion(context.Background(), id, purgeAt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRepositoryFindPendingDeletionSkipsExpiredGracePeriod(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// Отсрочка истекла — пользователь ждет окончательного удаления и вход его не восстановит
+	mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "users" WHERE (deleted_at IS NOT NULL AND deletion_scheduled_for > $1) AND lower(email) = $2 ORDER BY "users"."id" LIMIT $3`,
+	)).
+		WithArgs(sqlmock.AnyArg(), "alice@example.com", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := NewUserRepository(db).FindPendingDeletionByIdentifier(context.Background(), "Alice@Example.com")
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+}
+
+func TestUserRepositoryFindDueForPurge(t *testing.T) {
+	db, mock := newMockDB(t)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "users" WHERE deleted_at IS NOT NULL AND deletion_scheduled_for <= $1 ORDER BY deletion_scheduled_for LIMIT $2`,
+	)).
+		WithArgs(now, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(uuid.New().String(), "due@example.com"))
+
+	users, err := NewUserRepository(db).FindDueForPurge(context.Background(), now, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Email != "due@example.com" {
+		t.Fatalf("users = %+v", users)
+	}
+}
+
+func TestUserRepositoryFindByIdentifierIgnoresCase(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier string
+		query      string
+		arg        string
+	}{
+		{
+			name:       "email",
+			identifier: " Reader@Example.COM ",
+			query:      `SELECT * FROM "users" WHERE lower(email) = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`,
+			arg:        "reader@example.com",
+		},
+		{
+			name:       "username",
+			identifier: " Reader ",
+			query:      `SELECT * FROM "users" WHERE lower(username) = lower($1) AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT $2`,
+			arg:        "Reader",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.ExpectQuery(regexp.QuoteMeta(tt.query)).
+				WithArgs(tt.arg, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(uuid.New(), "reader@example.com"))
+
+			if _, err := NewUserRepository(db).FindByIdentifier(context.Background(), tt.identifier); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryFindByUsernameSkipsEmptyName(t *testing.T) {
+	db, _ := newMockDB(t)
+
+	// Пустое имя внешнего пользователя не должно совпадать с другими пустыми именами
+	_, err := NewUserRepository(db).FindByUsername(context.Background(), "  ")
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("ожидалась ErrRecordNotFound, получено %v", err)
+	}
+}
+
+func TestUserRepositoryStopsOnCanceledContext(t *testing.T) {
+	db, _ := newMockDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Запрос к базе не выполняется: клиент уже отключился
+	_, err := NewUserRepository(db).FindByID(ctx, uuid.New())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ожидалась context.Canceled, получено %v", err)
+	}
+}
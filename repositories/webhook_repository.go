@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository интерфейс для работы с webhook и их доставками
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	FindAll(ctx context.Context) ([]models.Webhook, error)
+	FindActive(ctx context.Context) ([]models.Webhook, error)
+	Delete(ctx context.Context, id uuid.UUID) (bool, error)
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	FindDeliveries(ctx context.Context, status string, webhookID *uuid.UUID, page, pageSize int) ([]models.WebhookDelivery, int64, error)
+	RequeueDelivery(ctx context.Context, id uuid.UUID) (bool, error)
+	WithTx(tx *gorm.DB) WebhookRepository
+}
+
+// webhookRepository реализация WebhookRepository
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository создает новый репозиторий webhook
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в переданной транзакции
+func (r *webhookRepository) WithTx(tx *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: tx}
+}
+
+// Create сохраняет новый webhook
+func (r *webhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+// FindAll возвращает все webhook
+func (r *webhookRepository) FindAll(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).Order("created_at").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// FindActive возвращает включенные webhook
+func (r *webhookRepository) FindActive(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).Where("active = ?", true).Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Delete удаляет webhook вместе с его доставками; возвращает false, если webhook не найден
+func (r *webhookRepository) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&models.Webhook{}, "id = ?", id)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateDeliveries сохраняет доставки событий
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Omit("Webhook", "Event").Create(&deliveries).Error
+}
+
+// ClaimDueDeliveries захватывает до limit доставок, время попытки которых наступило.
+// Захват сдвигает next_attempt_at на lease, поэтому другие экземпляры приложения
+// не отправят то же событие одновременно, а зависшая доставка будет повторена после lease.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		time.Now().Add(lease), models.DeliveryPending, time.Now(), limit,
+	).Scan(&ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	err = r.db.WithContext(ctx).Preload("Webhook").Preload("Event").Where("id IN ?", ids).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Omit("Webhook", "Event").Save(delivery).Error
+}
+
+// FindDeliveries возвращает страницу доставок (новые первыми) и их общее количество
+func (r *webhookRepository) FindDeliveries(ctx context.Context, status string, webhookID *uuid.UUID, page, pageSize int) ([]models.WebhookDelivery, int64, error) {
+	filter := func() *gorm.DB {
+		query := r.db.WithContext(ctx).Model(&models.WebhookDelivery{})
+		if status != "" {
+			query = query.Where("status = ?", status)
+		}
+		if webhookID != nil {
+			query = query.Where("webhook_id = ?", *webhookID)
+		}
+		return query
+	}
+
+	var total int64
+	if err := filter().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := filter().Preload("Event").
+		Order("updated_at DESC, id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// RequeueDelivery возвращает недоставленное событие в очередь с обнулением счетчика попыток
+func (r *webhookRepository) RequeueDelivery(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ?", id, models.DeliveryDead).
+		Updates(map[string]interface{}{
+			"status":          models.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
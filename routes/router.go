@@ -4,10 +4,17 @@ package routes
 import (
 	"AuthApplications/config"
 	"AuthApplications/controllers"
+	"AuthApplications/dto"
+	"AuthApplications/mailer"
+	"AuthApplications/metrics"
 	"AuthApplications/middleware"
+	"AuthApplications/migrations"
+	"AuthApplications/notifier"
 	"AuthApplications/repositories"
 	"AuthApplications/services"
+	"log/slog"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,12 +23,23 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// SetupRouter настраивает и возвращает Gin router
-func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
-	r := gin.Default()
+// SetupRouter настраивает и возвращает Gin router.
+// Фоновые уведомления, отправляемые обработчиками, учитываются в notifications.
+func SetupRouter(db *gorm.DB, cfg *config.Config, m metrics.Metrics, notifications *sync.WaitGroup, logger *slog.Logger) (*gin.Engine, error) {
+	if err := dto.RegisterValidators(); err != nil {
+		return nil, err
+	}
+
+	r := gin.New()
+	r.Use(middleware.AccessLog(logger), middleware.Recovery(logger))
+	r.Use(middleware.RequestID(), middleware.Tracing(), middleware.Metrics(m), middleware.RequestTimeout(cfg.RequestTimeout))
 
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	if cfg.MetricsEnabled {
+		r.GET("/metrics", middleware.MetricsToken(cfg.MetricsToken), gin.WrapH(m.Handler()))
+	}
+
 	r.GET("", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, "GET /")
 	})
@@ -29,32 +47,150 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 	// Инициализация репозиториев
 	userRepo := repositories.NewUserRepository(db)
 	bookRepo := repositories.NewBookRepository(db)
+	loginCodeRepo := repositories.NewLoginCodeRepository(db)
+	credentialRepo := repositories.NewCredentialRepository(db)
+	tokenRepo := repositories.NewTokenRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	loginEventRepo := repositories.NewLoginEventRepository(db)
+	emailChangeRepo := repositories.NewEmailChangeRepository(db)
+	dataExportRepo := repositories.NewDataExportRepository(db)
+	samlAssertionRepo := repositories.NewSAMLAssertionRepository(db)
+	txManager := repositories.NewTxManager(db)
+
+	// Отправка писем
+	mail := mailer.New(cfg)
+	notify := notifier.New(cfg, mail)
 
 	// Инициализация сервисов
-	authService := services.NewAuthService(userRepo, cfg)
-	userService := services.NewUserService(userRepo)
-	bookService := services.NewBookService(bookRepo)
+	auditService := services.NewAuditService(auditRepo)
+	loginHistoryService := services.NewLoginHistoryService(loginEventRepo, notify, notifications, logger)
+	authService := services.NewTracedAuthService(services.NewAuthService(userRepo, tokenRepo, outboxRepo, txManager, auditService, loginHistoryService, m, logger, cfg))
+	userService := services.NewTracedUserService(services.NewUserService(userRepo, outboxRepo, txManager, auditService, cfg))
+	bookService := services.NewTracedBookService(services.NewBookService(bookRepo, outboxRepo, txManager))
+	passwordlessService := services.NewPasswordlessService(userRepo, loginCodeRepo, authService, mail, cfg)
+	webhookService := services.NewWebhookService(webhookRepo)
+	emailChangeService := services.NewEmailChangeService(userRepo, emailChangeRepo, outboxRepo, txManager, auditService, mail, cfg)
+	dataExportService := services.NewDataExportService(dataExportRepo, auditService, cfg)
+	csrfService := services.NewCSRFService(cfg)
+	webAuthnService, err := services.NewWebAuthnService(cfg, userRepo, credentialRepo, authService)
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверки состояния: обязательные определяют готовность (/readyz)
+	healthService := services.NewHealthService(cfg.HealthCheckTimeout)
+	healthService.Register(services.NewDatabaseHealthCheck(db))
+	healthService.Register(services.NewMigrationsHealthCheck(migrator))
+	healthService.Register(services.NewSigningKeysHealthCheck(authService))
 
 	// Инициализация контроллеров
-	authController := controllers.NewAuthController(authService)
-	userController := controllers.NewUserController(userService)
+	authController := controllers.NewAuthController(authService, cfg)
+	userController := controllers.NewUserController(userService, loginHistoryService)
 	bookController := controllers.NewBookController(bookService)
+	passwordlessController := controllers.NewPasswordlessController(passwordlessService, cfg)
+	webAuthnController := controllers.NewWebAuthnController(webAuthnService, cfg)
+	impersonationController := controllers.NewImpersonationController(authService, cfg)
+	auditController := controllers.NewAuditController(auditService, logger)
+	stepUpController := controllers.NewStepUpController(authService, webAuthnService, cfg)
+	webhookController := controllers.NewWebhookController(webhookService)
+	emailChangeController := controllers.NewEmailChangeController(emailChangeService)
+	dataExportController := controllers.NewDataExportController(dataExportService)
+	csrfController := controllers.NewCSRFController(csrfService, cfg)
+	healthController := controllers.NewHealthController(healthService)
+
+	// Проверки живости и готовности для оркестратора
+	r.GET("/healthz", healthController.Liveness)
+	r.GET("/readyz", healthController.Readiness)
 
 	// Публичные маршруты
 	r.POST("/api/auth/register", authController.Register)
 	r.POST("/api/auth/login", authController.Login)
-	r.POST("/api/auth/logout", authController.Logout)
+	r.POST("/api/auth/logout", middleware.CSRF(csrfService), authController.Logout)
+	r.GET("/api/auth/csrf", csrfController.Token)
+
+	// Вход без пароля: ссылка из письма и одноразовый код
+	r.POST("/api/auth/magic-link", passwordlessController.RequestMagicLink)
+	r.GET("/api/auth/magic-link/verify", passwordlessController.VerifyMagicLink)
+	r.POST("/api/auth/otp", passwordlessController.RequestOTP)
+	r.POST("/api/auth/otp/verify", passwordlessController.VerifyOTP)
+
+	// Вход по ключу доступа и подтверждение входа вторым фактором
+	r.POST("/api/auth/webauthn/login/begin", webAuthnController.BeginLogin)
+	r.POST("/api/auth/webauthn/login/finish", webAuthnController.FinishLogin)
+	r.POST("/api/auth/webauthn/mfa/begin", webAuthnController.BeginSecondFactor)
+	r.POST("/api/auth/webauthn/mfa/finish", webAuthnController.FinishSecondFactor)
+
+	// Ссылки из писем о смене email
+	r.GET("/api/users/email/confirm", emailChangeController.Confirm)
+	r.GET("/api/users/email/cancel", emailChangeController.Cancel)
+
+	// Скачивание выгрузки данных по подписанной ссылке
+	r.GET("/api/users/profile/export/:id/download", dataExportController.Download)
+
+	// Вход через SAML 2.0 IdP
+	if cfg.SAMLEnabled {
+		samlService, err := services.NewSAMLService(cfg, userRepo, outboxRepo, txManager, auditService, samlAssertionRepo, authService, m)
+		if err != nil {
+			return nil, err
+		}
+		samlController := controllers.NewSAMLController(samlService, cfg)
+
+		r.GET("/api/auth/saml/metadata", samlController.Metadata)
+		r.GET("/api/auth/saml/login", samlController.Login)
+		r.POST("/api/auth/saml/acs", samlController.ACS)
+	}
 
 	// Группа защищенных маршрутов
 	protected := r.Group("/api")
-	protected.Use(middleware.AuthMiddleware(authService))
+	protected.Use(middleware.AuthMiddleware(authService), middleware.CSRF(csrfService))
 	{
 		// Маршруты пользователя
 		protected.GET("/users/profile", userController.GetProfile)
+		protected.GET("/users/profile/logins", userController.GetLoginHistory)
 		protected.GET("/users/all", userController.GetAllUsers)
 		protected.GET("/users/:id", userController.GetByID)
-		protected.PATCH("/users/:id", userController.PatchUser)
-		protected.DELETE("/users/:id", userController.DeleteUser)
+		protected.PATCH("/users/:id", middleware.ForbidImpersonation(), userController.PatchUser)
+		protected.POST("/users/profile/email",
+			middleware.ForbidImpersonation(),
+			middleware.RequireRecentAuth(cfg.StepUpMaxAge),
+			emailChangeController.RequestChange,
+		)
+		protected.POST("/users/profile/export", middleware.ForbidImpersonation(), dataExportController.Request)
+		protected.GET("/users/profile/export/:id", dataExportController.Get)
+		protected.DELETE("/users/:id",
+			middleware.ForbidImpersonation(),
+			middleware.RequireRecentAuth(cfg.StepUpMaxAge),
+			userController.DeleteUser,
+		)
+
+		// Повторная аутентификация перед чувствительными операциями
+		protected.POST("/auth/reauthenticate", middleware.ForbidImpersonation(), stepUpController.Reauthenticate)
+		protected.POST("/auth/reauthenticate/webauthn/begin", middleware.ForbidImpersonation(), stepUpController.BeginWebAuthn)
+		protected.POST("/auth/reauthenticate/webauthn/finish", middleware.ForbidImpersonation(), stepUpController.FinishWebAuthn)
+
+		// Управление ключами доступа (недоступно в режиме имперсонации)
+		protected.POST("/auth/webauthn/register/begin", middleware.ForbidImpersonation(), webAuthnController.BeginRegistration)
+		protected.POST("/auth/webauthn/register/finish", middleware.ForbidImpersonation(), webAuthnController.FinishRegistration)
+		protected.GET("/auth/webauthn/credentials", webAuthnController.ListCredentials)
+		protected.DELETE("/auth/webauthn/credentials/:id",
+			middleware.ForbidImpersonation(),
+			middleware.RequireRecentAuth(cfg.StepUpMaxAge),
+			webAuthnController.DeleteCredential,
+		)
+		protected.PUT("/auth/webauthn/mfa",
+			middleware.ForbidImpersonation(),
+			middleware.RequireRecentAuth(cfg.StepUpMaxAge),
+			webAuthnController.SetSecondFactor,
+		)
+
+		// Завершение имперсонации
+		protected.POST("/auth/impersonation/end", impersonationController.End)
 		
 		// Маршруты книги
 		protected.POST("/books", bookController.CreateBook)
@@ -77,9 +213,24 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		admin := protected.Group("/admin")
 		admin.Use(middleware.RoleMiddleware("admin"))
 		{
-			// Здесь будут маршруты только для администраторов
+			admin.GET("/health", healthController.Details)
+			admin.POST("/users/:id/impersonate", impersonationController.Start)
+			admin.POST("/users/:id/restore", userController.RestoreUser)
+			admin.POST("/users/:id/suspend", userController.SuspendUser)
+			admin.POST("/users/:id/unsuspend", userController.UnsuspendUser)
+
+			// Журнал аудита
+			admin.GET("/audit", auditController.List)
+			admin.GET("/audit/export", auditController.Export)
+
+			// Webhook и очередь доставок событий
+			admin.POST("/webhooks", webhookController.Create)
+			admin.GET("/webhooks", webhookController.List)
+			admin.DELETE("/webhooks/:id", webhookController.Delete)
+			admin.GET("/webhooks/deliveries", webhookController.ListDeliveries)
+			admin.POST("/webhooks/deliveries/:id/retry", webhookController.RetryDelivery)
 		}
 	}
 
-	return r
+	return r, nil
 }
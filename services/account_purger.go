@@ -0,0 +1,135 @@
+// services/account_purger.go - обслуживание учетных записей: окончательное удаление после отсрочки и снятие истекших блокировок
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"gorm.io/gorm"
+)
+
+// purgeBatchSize ограничивает число пользователей, удаляемых за один проход
+const purgeBatchSize = 100
+
+// AccountPurger интерфейс фонового обработчика окончательного удаления
+type AccountPurger interface {
+	Run(ctx context.Context)
+}
+
+// accountPurger реализация AccountPurger
+type accountPurger struct {
+	userRepo     repositories.UserRepository
+	bookRepo     repositories.BookRepository
+	txManager    repositories.TxManager
+	auditService AuditService
+	logger       *slog.Logger
+	cfg          *config.Config
+}
+
+// NewAccountPurger создает обработчик, который окончательно удаляет пользователей
+// и книги, мягко удаленные дольше ACCOUNT_DELETION_GRACE_PERIOD дней,
+// и снимает временные блокировки пользователей по истечении срока
+func NewAccountPurger(
+	userRepo repositories.UserRepository,
+	bookRepo repositories.BookRepository,
+	txManager repositories.TxManager,
+	auditService AuditService,
+	logger *slog.Logger,
+	cfg *config.Config,
+) AccountPurger {
+	return &accountPurger{
+		userRepo:     userRepo,
+		bookRepo:     bookRepo,
+		txManager:    txManager,
+		auditService: auditService,
+		logger:       logger.With("worker", "purge"),
+		cfg:          cfg,
+	}
+}
+
+// Run выполняет очистку с интервалом PURGE_INTERVAL до отмены контекста
+func (p *accountPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.purgeUsers(ctx); err != nil {
+			p.logger.ErrorContext(ctx, "Ошибка удаления пользователей", "error", err)
+		}
+		if err := p.purgeBooks(ctx); err != nil {
+			p.logger.ErrorContext(ctx, "Ошибка удаления книг", "error", err)
+		}
+		if err := p.liftSuspensions(ctx); err != nil {
+			p.logger.ErrorContext(ctx, "Ошибка снятия истекших блокировок", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeUsers удаляет пользователей, отсрочка удаления которых истекла.
+// Каждый пользователь удаляется в отдельной транзакции, чтобы ошибка не блокировала остальных.
+func (p *accountPurger) purgeUsers(ctx context.Context) error {
+	users, err := p.userRepo.FindDueForPurge(ctx, time.Now(), purgeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range users {
+		if ctx.Err() != nil {
+			return nil
+		}
+		user := &users[i]
+
+		err := p.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+			return p.userRepo.WithTx(tx).Purge(ctx, user.ID)
+		})
+		if err != nil {
+			p.logger.ErrorContext(ctx, "Ошибка удаления пользователя", "account_id", user.ID, "error", err)
+			continue
+		}
+
+		if err := p.auditService.Record(ctx, dto.RequestMeta{}, models.AuditUserPurge, nil, &user.ID, map[string]interface{}{
+			"email": user.Email,
+		}); err != nil {
+			p.logger.ErrorContext(ctx, "Ошибка записи в журнал аудита", "error", err)
+		}
+	}
+	return nil
+}
+
+// purgeBooks удаляет книги, мягко удаленные раньше начала отсрочки
+func (p *accountPurger) purgeBooks(ctx context.Context) error {
+	before := time.Now().Add(-p.cfg.AccountDeletionGracePeriod)
+	return p.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		_, err := p.bookRepo.WithTx(tx).PurgeDeletedBefore(ctx, before)
+		return err
+	})
+}
+
+// liftSuspensions возвращает в статус active пользователей с истекшей временной блокировкой
+func (p *accountPurger) liftSuspensions(ctx context.Context) error {
+	ids, err := p.userRepo.LiftExpiredSuspensions(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range ids {
+		if err := p.auditService.Record(ctx, dto.RequestMeta{}, models.AuditUserUnsuspend, nil, &ids[i], map[string]interface{}{
+			"by": "expiry",
+		}); err != nil {
+			p.logger.ErrorContext(ctx, "Ошибка записи в журнал аудита", "error", err)
+		}
+	}
+	return nil
+}
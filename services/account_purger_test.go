@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/logging"
+	"AuthApplications/models"
+)
+
+func TestAccountPurgerRemovesUsersAfterGracePeriod(t *testing.T) {
+	due := newLocalUser(t, "due@example.com", "secret-password")
+	pending := newLocalUser(t, "pending@example.com", "secret-password")
+	active := newLocalUser(t, "active@example.com", "secret-password")
+	users := newFakeUserRepository(due, pending, active)
+	ctx := context.Background()
+	if err := users.ScheduleDeletion(ctx, due.ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.ScheduleDeletion(ctx, pending.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := &fakeAuditService{}
+	purger := NewAccountPurger(users, nil, fakeTxManager{}, audit, logging.Nop(), &config.Config{}).(*accountPurger)
+	if err := purger.purgeUsers(ctx); err != nil {
+		t.Fatalf("purgeUsers: %v", err)
+	}
+
+	if _, ok := users.users[due.ID]; ok {
+		t.Error("пользователь с истекшей отсрочкой не удален")
+	}
+	if _, ok := users.users[pending.ID]; !ok {
+		t.Error("пользователь удален до окончания отсрочки")
+	}
+	if _, ok := users.users[active.ID]; !ok {
+		t.Error("удален активный пользователь")
+	}
+	if actions := audit.recorded(); !slices.Equal(actions, []string{models.AuditUserPurge}) {
+		t.Errorf("аудит = %v", actions)
+	}
+}
@@ -0,0 +1,160 @@
+// services/admin_service.go - операции администратора сервиса (командная строка)
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+)
+
+// ErrNotLocalAccount возвращается при сбросе пароля пользователю LDAP или SAML
+var ErrNotLocalAccount = errors.New("пароль хранится во внешнем каталоге, сбросьте его у провайдера")
+
+// AdminService интерфейс сервиса операций администратора
+type AdminService interface {
+	CreateAdmin(ctx context.Context, req dto.RegisterRequest, meta dto.RequestMeta) (*models.User, error)
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest, meta dto.RequestMeta) (*models.User, error)
+	RevokeSessions(ctx context.Context, identifier string, meta dto.RequestMeta) (*models.User, error)
+	RevokeAllSessions(ctx context.Context, meta dto.RequestMeta) (int64, error)
+	RotateSigningKey(ctx context.Context, compromised bool, meta dto.RequestMeta) (*dto.KeyRotation, error)
+}
+
+// adminService реализация AdminService
+type adminService struct {
+	userRepo     repositories.UserRepository
+	outboxRepo   repositories.OutboxRepository
+	txManager    repositories.TxManager
+	auditService AuditService
+	cfg          *config.Config
+}
+
+// NewAdminService создает новый сервис операций администратора
+func NewAdminService(
+	userRepo repositories.UserRepository,
+	outboxRepo repositories.OutboxRepository,
+	txManager repositories.TxManager,
+	auditService AuditService,
+	cfg *config.Config,
+) AdminService {
+	return &adminService{
+		userRepo:     userRepo,
+		outboxRepo:   outboxRepo,
+		txManager:    txManager,
+		auditService: auditService,
+		cfg:          cfg,
+	}
+}
+
+// CreateAdmin создает локального пользователя с ролью admin
+func (s *adminService) CreateAdmin(ctx context.Context, req dto.RegisterRequest, meta dto.RequestMeta) (*models.User, error) {
+	user, err := createLocalUser(ctx, s.userRepo, s.outboxRepo, s.txManager, req, "admin")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditRegister, nil, &user.ID, map[string]interface{}{
+		"role": user.Role,
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ResetPassword задает новый пароль локальному пользователю и завершает все его сессии
+func (s *adminService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest, meta dto.RequestMeta) (*models.User, error) {
+	user, err := s.userRepo.FindByIdentifier(ctx, req.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	if user.AuthSource != "" && user.AuthSource != models.AuthSourceLocal {
+		return nil, ErrNotLocalAccount
+	}
+
+	now := time.Now()
+	user.Password = req.Password
+	user.SessionsRevokedAt = &now
+	if err := s.userRepo.PatchUser(ctx, user, "password", "sessions_revoked_at"); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditPasswordReset, nil, &user.ID, nil); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RevokeSessions делает недействительными все ранее выданные токены пользователя
+func (s *adminService) RevokeSessions(ctx context.Context, identifier string, meta dto.RequestMeta) (*models.User, error) {
+	user, err := s.userRepo.FindByIdentifier(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.SessionsRevokedAt = &now
+	if err := s.userRepo.PatchUser(ctx, user, "sessions_revoked_at"); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditSessionsRevoked, nil, &user.ID, nil); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RevokeAllSessions делает недействительными токены всех пользователей
+func (s *adminService) RevokeAllSessions(ctx context.Context, meta dto.RequestMeta) (int64, error) {
+	count, err := s.userRepo.RevokeAllSessions(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditSessionsRevoked, nil, nil, map[string]interface{}{
+		"users": count,
+	}); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RotateSigningKey выпускает новый секрет подписи JWT. Новый секрет начинает действовать после
+// перезапуска с обновленными JWT_SECRET и JWT_PREVIOUS_SECRETS; до истечения выданных токенов
+// старый секрет остается в JWT_PREVIOUS_SECRETS. Если секрет скомпрометирован, старый секрет
+// не сохраняется, а все сессии завершаются.
+func (s *adminService) RotateSigningKey(ctx context.Context, compromised bool, meta dto.RequestMeta) (*dto.KeyRotation, error) {
+	secret, err := randomToken(48)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &dto.KeyRotation{
+		KeyID:  JWTKeyID(secret),
+		Secret: secret,
+	}
+	if compromised {
+		if rotation.SessionsRevoked, err = s.RevokeAllSessions(ctx, meta); err != nil {
+			return nil, err
+		}
+	} else {
+		rotation.PreviousSecrets = []string{s.cfg.JWTSecret}
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditSigningKeyRotated, nil, nil, map[string]interface{}{
+		"kid":          rotation.KeyID,
+		"previous_kid": JWTKeyID(s.cfg.JWTSecret),
+		"compromised":  compromised,
+	}); err != nil {
+		return nil, err
+	}
+
+	return rotation, nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/logging"
+	"AuthApplications/metrics"
+	"AuthApplications/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// adminHarness сервис операций администратора поверх хранилищ сервиса аутентификации,
+// чтобы проверять действие операций на выданные токены
+type adminHarness struct {
+	*authHarness
+	admin AdminService
+}
+
+func newAdminHarness(t *testing.T, users ...*models.User) *adminHarness {
+	t.Helper()
+
+	h := newAuthHarness(t, users...)
+	return &adminHarness{
+		authHarness: h,
+		admin:       NewAdminService(h.users, h.outbox, fakeTxManager{}, h.audit, h.cfg),
+	}
+}
+
+// issueToken выдает пользователю токен доступа, выпущенный до операции администратора
+func (h *adminHarness) issueToken(t *testing.T, user *models.User) string {
+	t.Helper()
+
+	response, err := h.service.CompleteLogin(context.Background(), user, models.LoginMethodPassword, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	return backdateToken(t, h.authHarness, response.Token, time.Second)
+}
+
+func TestAdminCreateAdmin(t *testing.T) {
+	h := newAdminHarness(t)
+
+	user, err := h.admin.CreateAdmin(context.Background(), dto.RegisterRequest{
+		Email:    "Root@Example.com",
+		Username: "root",
+		Password: "admin-password",
+	}, cliTestMeta())
+	if err != nil {
+		t.Fatalf("CreateAdmin: %v", err)
+	}
+	if user.Role != "admin" || user.AuthSource != models.AuthSourceLocal {
+		t.Errorf("role = %q, auth_source = %q", user.Role, user.AuthSource)
+	}
+	if !slices.Contains(h.audit.actions, models.AuditRegister) {
+		t.Errorf("в журнале аудита нет регистрации: %v", h.audit.actions)
+	}
+
+	// Повторный запуск с тем же email не создает второго администратора
+	if _, err := h.admin.CreateAdmin(context.Background(), dto.RegisterRequest{
+		Email:    "root@example.com",
+		Password: "admin-password",
+	}, cliTestMeta()); err == nil {
+		t.Fatal("создан второй пользователь с тем же email")
+	}
+}
+
+func TestAdminResetPasswordRevokesSessions(t *testing.T) {
+	user := newLocalUser(t, "reader@example.com", "old-password")
+	user.Username = "reader"
+	h := newAdminHarness(t, user)
+	token := h.issueToken(t, user)
+
+	updated, err := h.admin.ResetPassword(context.Background(), dto.ResetPasswordRequest{
+		Identifier: "READER",
+		Password:   "new-password",
+	}, cliTestMeta())
+	if err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if updated.ID != user.ID {
+		t.Fatalf("пароль сброшен другому пользователю")
+	}
+	if want := []string{"password", "sessions_revoked_at"}; len(h.users.patched) != 1 || !slices.Equal(h.users.patched[0], want) {
+		t.Errorf("PatchUser вызван со столбцами %v", h.users.patched)
+	}
+	if _, _, err := h.service.ValidateToken(context.Background(), token); err == nil {
+		t.Error("токен, выданный до сброса пароля, принят")
+	}
+}
+
+func TestAdminResetPasswordRejectsExternalAccount(t *testing.T) {
+	user := newLocalUser(t, "reader@example.com", "old-password")
+	user.AuthSource = models.AuthSourceLDAP
+	h := newAdminHarness(t, user)
+
+	_, err := h.admin.ResetPassword(context.Background(), dto.ResetPasswordRequest{
+		Identifier: "reader@example.com",
+		Password:   "new-password",
+	}, cliTestMeta())
+	if !errors.Is(err, ErrNotLocalAccount) {
+		t.Fatalf("ожидалась ErrNotLocalAccount, получено %v", err)
+	}
+	if len(h.users.patched) != 0 {
+		t.Error("пользователь каталога изменен")
+	}
+}
+
+func TestAdminRevokeSessions(t *testing.T) {
+	alice := newLocalUser(t, "alice@example.com", "password")
+	bob := newLocalUser(t, "bob@example.com", "password")
+	h := newAdminHarness(t, alice, bob)
+	aliceToken := h.issueToken(t, alice)
+	bobToken := h.issueToken(t, bob)
+
+	if _, err := h.admin.RevokeSessions(context.Background(), "alice@example.com", cliTestMeta()); err != nil {
+		t.Fatalf("RevokeSessions: %v", err)
+	}
+	if _, _, err := h.service.ValidateToken(context.Background(), aliceToken); err == nil {
+		t.Error("токен пользователя принят после завершения его сессий")
+	}
+	if _, _, err := h.service.ValidateToken(context.Background(), bobToken); err != nil {
+		t.Errorf("токен другого пользователя отклонен: %v", err)
+	}
+
+	count, err := h.admin.RevokeAllSessions(context.Background(), cliTestMeta())
+	if err != nil {
+		t.Fatalf("RevokeAllSessions: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("сессии завершены у %d пользователей", count)
+	}
+	if _, _, err := h.service.ValidateToken(context.Background(), bobToken); err == nil {
+		t.Error("токен принят после завершения сессий всех пользователей")
+	}
+}
+
+func TestAdminRotateSigningKeyKeepsIssuedTokensValid(t *testing.T) {
+	user := newLocalUser(t, "reader@example.com", "password")
+	h := newAdminHarness(t, user)
+	token := h.issueToken(t, user)
+
+	rotation, err := h.admin.RotateSigningKey(context.Background(), false, cliTestMeta())
+	if err != nil {
+		t.Fatalf("RotateSigningKey: %v", err)
+	}
+	if rotation.Secret == testJWTSecret || rotation.KeyID != JWTKeyID(rotation.Secret) {
+		t.Fatalf("новый ключ: %+v", rotation)
+	}
+	if !slices.Equal(rotation.PreviousSecrets, []string{testJWTSecret}) {
+		t.Fatalf("previous secrets = %v", rotation.PreviousSecrets)
+	}
+
+	// Сервис после перезапуска с новыми переменными окружения
+	rotated := NewAuthService(h.users, h.tokens, h.outbox, fakeTxManager{}, h.audit, h.loginHistory, metrics.New(prometheus.NewRegistry()), logging.Nop(), &config.Config{
+		JWTSecret:          rotation.Secret,
+		JWTPreviousSecrets: rotation.PreviousSecrets,
+		AccessTokenTTL:     time.Hour,
+	})
+	if _, _, err := rotated.ValidateToken(context.Background(), token); err != nil {
+		t.Errorf("токен, подписанный прежним ключом, отклонен: %v", err)
+	}
+}
+
+func TestAdminRotateCompromisedSigningKey(t *testing.T) {
+	h := newAdminHarness(t, newLocalUser(t, "reader@example.com", "password"))
+
+	rotation, err := h.admin.RotateSigningKey(context.Background(), true, cliTestMeta())
+	if err != nil {
+		t.Fatalf("RotateSigningKey: %v", err)
+	}
+	if len(rotation.PreviousSecrets) != 0 {
+		t.Errorf("скомпрометированный секрет сохранен: %v", rotation.PreviousSecrets)
+	}
+	if rotation.SessionsRevoked != 1 {
+		t.Errorf("сессии завершены у %d пользователей", rotation.SessionsRevoked)
+	}
+	if !slices.Contains(h.audit.actions, models.AuditSigningKeyRotated) {
+		t.Errorf("в журнале аудита нет ротации: %v", h.audit.actions)
+	}
+}
+
+// cliTestMeta сведения об операции, выполненной из командной строки
+func cliTestMeta() dto.RequestMeta {
+	return dto.RequestMeta{UserAgent: "cli"}
+}
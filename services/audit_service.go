@@ -0,0 +1,103 @@
+// services/audit_service.go - журнал аудита событий безопасности
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/google/uuid"
+)
+
+// AuditService интерфейс сервиса журнала аудита
+type AuditService interface {
+	Record(ctx context.Context, meta dto.RequestMeta, action string, actorID, targetID *uuid.UUID, details map[string]interface{}) error
+	Find(ctx context.Context, filter dto.AuditFilter) (*dto.AuditListResponse, error)
+	Export(ctx context.Context, filter dto.AuditFilter, fn func(event dto.AuditEventResponse) error) error
+}
+
+// auditService реализация AuditService
+type auditService struct {
+	auditRepo repositories.AuditRepository
+}
+
+// NewAuditService создает новый сервис журнала аудита
+func NewAuditService(auditRepo repositories.AuditRepository) AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+// Record добавляет событие в журнал. Если actorID не задан, используется пользователь из запроса.
+func (s *auditService) Record(ctx context.Context, meta dto.RequestMeta, action string, actorID, targetID *uuid.UUID, details map[string]interface{}) error {
+	if actorID == nil {
+		actorID = meta.ActorID
+	}
+
+	event := &models.AuditEvent{
+		Action:         action,
+		ActorID:        actorID,
+		ImpersonatorID: meta.ImpersonatorID,
+		TargetID:       targetID,
+		IP:             meta.IP,
+		UserAgent:      meta.UserAgent,
+		RequestID:      meta.RequestID,
+	}
+
+	if len(details) > 0 {
+		data, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		event.Details = string(data)
+	}
+
+	return s.auditRepo.Create(ctx, event)
+}
+
+// Find возвращает страницу журнала по фильтрам
+func (s *auditService) Find(ctx context.Context, filter dto.AuditFilter) (*dto.AuditListResponse, error) {
+	events, total, err := s.auditRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.AuditEventResponse, 0, len(events))
+	for i := range events {
+		items = append(items, toAuditEventResponse(&events[i]))
+	}
+
+	return &dto.AuditListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}, nil
+}
+
+// Export передает в fn все записи журнала по фильтрам
+func (s *auditService) Export(ctx context.Context, filter dto.AuditFilter, fn func(event dto.AuditEventResponse) error) error {
+	return s.auditRepo.Export(ctx, filter, func(event *models.AuditEvent) error {
+		return fn(toAuditEventResponse(event))
+	})
+}
+
+// toAuditEventResponse преобразует запись журнала в DTO
+func toAuditEventResponse(event *models.AuditEvent) dto.AuditEventResponse {
+	response := dto.AuditEventResponse{
+		ID:             event.ID,
+		Action:         event.Action,
+		ActorID:        event.ActorID,
+		ImpersonatorID: event.ImpersonatorID,
+		TargetID:       event.TargetID,
+		IP:             event.IP,
+		UserAgent:      event.UserAgent,
+		RequestID:      event.RequestID,
+		CreatedAt:      event.CreatedAt,
+	}
+	if event.Details != "" {
+		response.Details = json.RawMessage(event.Details)
+	}
+	return response
+}
@@ -2,25 +2,100 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"log/slog"
+	"strings"
 	"time"
 
 	"AuthApplications/config"
 	"AuthApplications/dto"
+	"AuthApplications/metrics"
 	"AuthApplications/models"
 	"AuthApplications/repositories"
+	"AuthApplications/tracing"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 )
 
 // AuthService интерфейс сервиса аутентификации
 type AuthService interface {
-	Register(req dto.RegisterRequest) (*models.User, error)
-	Login(req dto.LoginRequest) (string, error)
-	Logout() error
-	ValidateToken(tokenString string) (*jwt.Token, *JWTClaim, error)
+	Register(ctx context.Context, req dto.RegisterRequest, meta dto.RequestMeta) (*models.User, error)
+	Login(ctx context.Context, req dto.LoginRequest, meta dto.RequestMeta) (*dto.AuthResponse, error)
+	Logout(ctx context.Context, tokenString string, meta dto.RequestMeta) error
+	ValidateToken(ctx context.Context, tokenString string) (*jwt.Token, *JWTClaim, error)
+	ValidateMFAToken(ctx context.Context, tokenString string) (*JWTClaim, error)
+	CompleteLogin(ctx context.Context, user *models.User, method string, meta dto.RequestMeta) (*dto.AuthResponse, error)
+	RecordLoginFailure(ctx context.Context, user *models.User, method string, cause error, meta dto.RequestMeta) error
+	Reauthenticate(ctx context.Context, claims *JWTClaim, password string, meta dto.RequestMeta) (string, error)
+	ElevateToken(ctx context.Context, claims *JWTClaim, amr []string, meta dto.RequestMeta) (string, error)
+	Impersonate(ctx context.Context, actorID, targetID uuid.UUID, reason string, meta dto.RequestMeta) (string, error)
+	EndImpersonation(ctx context.Context, claims *JWTClaim, meta dto.RequestMeta) error
+	CheckSigningKeys(ctx context.Context) error
+}
+
+// TokenScopeMFA ограничивает токен подтверждением второго фактора после пароля
+const TokenScopeMFA = "mfa"
+
+// ErrInvalidMFAToken возвращается для истекшего или чужого токена подтверждения входа
+var ErrInvalidMFAToken = errors.New("недействительный токен подтверждения входа")
+
+// mfaTokenLifetime время на подтверждение входа ключом доступа
+const mfaTokenLifetime = 5 * time.Minute
+
+// ErrReauthenticationFailed возвращается при неверном пароле или ключе во время повторной аутентификации
+var ErrReauthenticationFailed = errors.New("повторная аутентификация не пройдена")
+
+// Ошибки имперсонации
+var (
+	ErrImpersonationForbidden = errors.New("имперсонация этого пользователя запрещена")
+	ErrNotImpersonating       = errors.New("токен не является токеном имперсонации")
+)
+
+// ErrAccountBlocked возвращается для приостановленных и заблокированных учетных записей
+var ErrAccountBlocked = errors.New("учетная запись заблокирована")
+
+// AccountBlockedError описывает действующую блокировку учетной записи
+type AccountBlockedError struct {
+	Status string
+	Reason string
+	Until  *time.Time
+}
+
+// Error возвращает сообщение для пользователя
+func (e *AccountBlockedError) Error() string {
+	if e.Status == models.UserStatusSuspended {
+		return "учетная запись приостановлена"
+	}
+	return ErrAccountBlocked.Error()
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrAccountBlocked)
+func (e *AccountBlockedError) Is(target error) bool {
+	return target == ErrAccountBlocked
+}
+
+// checkAccountStatus возвращает AccountBlockedError, если пользователь сейчас заблокирован
+func checkAccountStatus(user *models.User) error {
+	if !user.BlockedAt(time.Now()) {
+		return nil
+	}
+	return &AccountBlockedError{
+		Status: user.Status,
+		Reason: user.StatusReason,
+		Until:  user.StatusUntil,
+	}
+}
+
+// ActorClaim описывает администратора, действующего от имени пользователя (claim "act", RFC 8693)
+type ActorClaim struct {
+	UserID uuid.UUID `json:"sub"`
+	Email  string    `json:"email"`
 }
 
 // JWTClaim представляет структуру JWT токена
@@ -28,32 +103,110 @@ type JWTClaim struct {
 	UserID   uuid.UUID    `json:"user_id"`
 	Email string `json:"email"`
 	Role     string `json:"role"`
+	Scope    string `json:"scope,omitempty"` // пусто для полного доступа к API
+	Act      *ActorClaim `json:"act,omitempty"` // заполнен для токенов имперсонации
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"` // время последней проверки учетных данных
+	AMR      []string         `json:"amr,omitempty"`       // способы аутентификации (RFC 8176)
 	jwt.RegisteredClaims
 }
 
 // authService реализация AuthService
 type authService struct {
-	userRepo  repositories.UserRepository
-	jwtSecret string
+	userRepo         repositories.UserRepository
+	tokenRepo        repositories.TokenRepository
+	outboxRepo       repositories.OutboxRepository
+	txManager        repositories.TxManager
+	auditService     AuditService
+	loginHistory     LoginHistoryService
+	authenticator    Authenticator
+	metrics          metrics.Metrics
+	logger           *slog.Logger
+	jwtSecret        string
+	jwtKeyID         string
+	jwtKeys          map[string]string // kid -> секрет, включая предыдущие секреты после ротации
+	accessTokenTTL   time.Duration
+	impersonationTTL time.Duration
 }
 
 // NewAuthService создает новый сервис аутентификации
-func NewAuthService(userRepo repositories.UserRepository, cfg *config.Config) AuthService {
+func NewAuthService(
+	userRepo repositories.UserRepository,
+	tokenRepo repositories.TokenRepository,
+	outboxRepo repositories.OutboxRepository,
+	txManager repositories.TxManager,
+	auditService AuditService,
+	loginHistory LoginHistoryService,
+	m metrics.Metrics,
+	logger *slog.Logger,
+	cfg *config.Config,
+) AuthService {
+	// Локальные пароли проверяются первыми, затем внешние каталоги
+	authenticators := []Authenticator{NewLocalAuthenticator(userRepo)}
+	if cfg.LDAPEnabled {
+		authenticators = append(authenticators, NewLDAPAuthenticator(cfg, userRepo, outboxRepo, txManager, auditService, m))
+	}
+
+	// Токены, подписанные предыдущими секретами, принимаются до истечения их срока
+	jwtKeys := map[string]string{JWTKeyID(cfg.JWTSecret): cfg.JWTSecret}
+	for _, secret := range cfg.JWTPreviousSecrets {
+		jwtKeys[JWTKeyID(secret)] = secret
+	}
+
 	return &authService{
-		userRepo:  userRepo,
-		jwtSecret: cfg.JWTSecret,
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		outboxRepo:       outboxRepo,
+		txManager:        txManager,
+		auditService:     auditService,
+		loginHistory:     loginHistory,
+		authenticator:    NewAuthenticatorChain(authenticators...),
+		metrics:          m,
+		logger:           logger,
+		jwtSecret:        cfg.JWTSecret,
+		jwtKeyID:         JWTKeyID(cfg.JWTSecret),
+		jwtKeys:          jwtKeys,
+		accessTokenTTL:   cfg.AccessTokenTTL,
+		impersonationTTL: cfg.ImpersonationTTL,
 	}
 }
 
 // Register регистрирует нового пользователя
-func (s *authService) Register(req dto.RegisterRequest) (*models.User, error) {
-	// Проверка, существует ли пользователь с таким email
-	_, err := s.userRepo.FindByEmail(req.Email)
+func (s *authService) Register(ctx context.Context, req dto.RegisterRequest, meta dto.RequestMeta) (*models.User, error) {
+	newUser, err := createLocalUser(ctx, s.userRepo, s.outboxRepo, s.txManager, req, "user") // По умолчанию обычный пользователь
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditRegister, &newUser.ID, &newUser.ID, nil); err != nil {
+		return nil, err
+	}
+	s.metrics.UserRegistered(models.AuthSourceLocal)
+	s.logger.InfoContext(ctx, "Пользователь зарегистрирован", "account_id", newUser.ID)
+
+	return newUser, nil
+}
+
+// createLocalUser создает локального пользователя с заданной ролью вместе с событием user.registered
+func createLocalUser(
+	ctx context.Context,
+	userRepo repositories.UserRepository,
+	outboxRepo repositories.OutboxRepository,
+	txManager repositories.TxManager,
+	req dto.RegisterRequest,
+	role string,
+) (*models.User, error) {
+	// Проверка, существует ли пользователь с таким email или именем (без учета регистра)
+	_, err := userRepo.FindByEmail(ctx, req.Email)
 	if err == nil {
 		return nil, errors.New("пользователь с таким email уже существует")
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
+	if _, err := userRepo.FindByUsername(ctx, req.Username); err == nil {
+		return nil, ErrUsernameTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
 
 	// Создание нового пользователя
 	newUser := &models.User{
@@ -62,44 +215,406 @@ func (s *authService) Register(req dto.RegisterRequest) (*models.User, error) {
 		Password:  req.Password,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
-		Role:      "user", // По умолчанию обычный пользователь
+		Role:      role,
+		AuthSource: models.AuthSourceLocal,
+		Status:    models.UserStatusActive,
 	}
 
-	if err := s.userRepo.Create(newUser); err != nil {
+	// Пользователь и событие user.registered сохраняются в одной транзакции
+	err = txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := userRepo.WithTx(tx).Create(ctx, newUser); err != nil {
+			// Email или имя может быть занято учетной записью, ожидающей удаления
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("пользователь с таким email или именем уже существует")
+			}
+			return err
+		}
+
+		event, err := newOutboxEvent(models.EventUserRegistered, newUser.ID, toUserResponse(newUser))
+		if err != nil {
+			return err
+		}
+		return outboxRepo.WithTx(tx).Create(ctx, event)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return newUser, nil
 }
 
-// Login аутентифицирует пользователя и выдает JWT токен
-func (s *authService) Login(req dto.LoginRequest) (string, error) {
-	user, err := s.userRepo.FindByEmail(req.Email)
+// Login аутентифицирует пользователя и выдает JWT токен.
+// Если у пользователя включен второй фактор, выдается короткоживущий токен
+// только для подтверждения входа ключом доступа (см. CompleteLogin).
+func (s *authService) Login(ctx context.Context, req dto.LoginRequest, meta dto.RequestMeta) (*dto.AuthResponse, error) {
+	identifier := strings.TrimSpace(req.LoginIdentifier())
+
+	// Вход по паролю в период отсрочки отменяет удаление учетной записи
+	if err := s.cancelScheduledDeletion(ctx, identifier, req.Password, meta); err != nil {
+		return nil, err
+	}
+
+	// Проверка учетных данных цепочкой бэкендов (локальный пароль, LDAP)
+	user, err := s.authenticate(ctx, identifier, req.Password, meta)
+	if err != nil {
+		existing, findErr := s.userRepo.FindByIdentifier(ctx, identifier)
+		if findErr != nil {
+			existing = nil
+		}
+		if recordErr := s.loginFailed(ctx, identifier, existing, models.LoginMethodPassword, err, meta); recordErr != nil {
+			return nil, recordErr
+		}
+		return nil, err
+	}
+
+	return s.CompleteLogin(ctx, user, models.LoginMethodPassword, meta)
+}
+
+// authenticate проверяет учетные данные цепочкой бэкендов в отдельном спане,
+// чтобы в трассировке было видно время bcrypt и запросов к LDAP
+func (s *authService) authenticate(ctx context.Context, identifier, password string, meta dto.RequestMeta) (*models.User, error) {
+	_, span := tracing.Start(ctx, "Authenticator.Authenticate")
+	user, err := s.authenticator.Authenticate(ctx, identifier, password, meta)
+	if errors.Is(err, ErrInvalidCredentials) {
+		// Неверный пароль - ожидаемый исход, а не сбой
+		span.SetAttributes(attribute.Bool("auth.invalid_credentials", true))
+		tracing.End(span, nil)
+		return user, err
+	}
+	tracing.End(span, err)
+	return user, err
+}
+
+// cancelScheduledDeletion восстанавливает локального пользователя, ожидающего удаления,
+// если пароль верен. Неверный пароль здесь не считается ошибкой: вход завершится
+// обычной проверкой учетных данных.
+func (s *authService) cancelScheduledDeletion(ctx context.Context, identifier, password string, meta dto.RequestMeta) error {
+	user, err := s.userRepo.FindPendingDeletionByIdentifier(ctx, identifier)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", errors.New("неверное имя пользователя или пароль")
+			return nil
+		}
+		return err
+	}
+
+	if user.AuthSource != "" && user.AuthSource != models.AuthSourceLocal {
+		return nil
+	}
+	if err := user.CheckPassword(password); err != nil {
+		return nil
+	}
+
+	if err := restoreUser(ctx, s.txManager, s.userRepo, s.outboxRepo, user); err != nil {
+		return err
+	}
+	return s.auditService.Record(ctx, meta, models.AuditUserRestore, &user.ID, &user.ID, map[string]interface{}{
+		"by": "login",
+	})
+}
+
+// CompleteLogin выпускает JWT токен для уже аутентифицированного пользователя
+// и записывает успешный вход в историю входов и журнал аудита.
+// Через него проходят все способы входа, поэтому здесь же проверяется второй фактор:
+// если он включен, а вход выполнен не ключом доступа (пароль, ссылка, код, SAML),
+// выдается только токен подтверждения входа ключом. Заблокированный пользователь
+// не получает и его.
+func (s *authService) CompleteLogin(ctx context.Context, user *models.User, method string, meta dto.RequestMeta) (*dto.AuthResponse, error) {
+	if err := checkAccountStatus(user); err != nil {
+		if recordErr := s.RecordLoginFailure(ctx, user, method, err, meta); recordErr != nil {
+			return nil, recordErr
+		}
+		return nil, err
+	}
+
+	if user.MFAEnabled && !satisfiesSecondFactor(method) {
+		return s.requireSecondFactor(ctx, user, method, meta)
+	}
+
+	claims := s.newClaims(user, "", s.accessTokenTTL)
+	claims.AuthTime = claims.IssuedAt
+	claims.AMR = loginMethodAMR(method)
+
+	token, err := s.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loginHistory.RecordSuccess(ctx, user, method, meta); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.Record(ctx, meta, models.AuditLoginSuccess, &user.ID, &user.ID, map[string]interface{}{
+		"method":      method,
+		"auth_source": user.AuthSource,
+	}); err != nil {
+		return nil, err
+	}
+	s.metrics.LoginSucceeded(method)
+	s.logger.InfoContext(ctx, "Вход выполнен", "account_id", user.ID, "method", method)
+
+	return &dto.AuthResponse{Token: token}, nil
+}
+
+// requireSecondFactor выдает короткоживущий токен для подтверждения входа ключом доступа
+func (s *authService) requireSecondFactor(ctx context.Context, user *models.User, method string, meta dto.RequestMeta) (*dto.AuthResponse, error) {
+	token, err := s.issueToken(user, TokenScopeMFA, mfaTokenLifetime)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.auditService.Record(ctx, meta, models.AuditLoginMFARequired, &user.ID, &user.ID, map[string]interface{}{
+		"method": method,
+	}); err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "Требуется второй фактор", "account_id", user.ID, "method", method)
+
+	return &dto.AuthResponse{Token: token, SecondFactorRequired: true}, nil
+}
+
+// satisfiesSecondFactor сообщает, что способ входа уже включает ключ доступа
+func satisfiesSecondFactor(method string) bool {
+	return method == models.LoginMethodPasskey || method == models.LoginMethodMFA
+}
+
+// Reauthenticate повторно проверяет пароль текущего пользователя и выпускает токен
+// с обновленным auth_time для доступа к чувствительным операциям
+func (s *authService) Reauthenticate(ctx context.Context, claims *JWTClaim, password string, meta dto.RequestMeta) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	authenticated, err := s.authenticate(ctx, user.Email, password, meta)
+	if err != nil || authenticated.ID != user.ID {
+		if errors.Is(err, ErrInvalidCredentials) || err == nil {
+			if recordErr := s.RecordLoginFailure(ctx, user, models.LoginMethodStepUpPassword, ErrReauthenticationFailed, meta); recordErr != nil {
+				return "", recordErr
+			}
+			return "", ErrReauthenticationFailed
+		}
+		return "", err
+	}
+
+	return s.ElevateToken(ctx, claims, []string{"pwd"}, meta)
+}
+
+// ElevateToken выпускает токен с auth_time = сейчас после повторной проверки пароля или ключа.
+// Срок действия исходного токена сохраняется, чтобы повторная аутентификация не продлевала сессию.
+func (s *authService) ElevateToken(ctx context.Context, claims *JWTClaim, amr []string, meta dto.RequestMeta) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	elevated := s.newClaims(user, "", 0)
+	elevated.ExpiresAt = claims.ExpiresAt
+	elevated.AuthTime = elevated.IssuedAt
+	elevated.AMR = mergeAMR(claims.AMR, amr)
+
+	token, err := s.sign(elevated)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditStepUp, &user.ID, &user.ID, map[string]interface{}{
+		"amr":      amr,
+		"token_id": elevated.ID,
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// loginMethodAMR сопоставляет способ входа со значениями claim "amr" (RFC 8176)
+func loginMethodAMR(method string) []string {
+	switch method {
+	case models.LoginMethodPassword:
+		return []string{"pwd"}
+	case models.LoginMethodMagicLink, models.LoginMethodOTP:
+		return []string{"otp"}
+	case models.LoginMethodPasskey:
+		return []string{"hwk", "user"}
+	case models.LoginMethodMFA:
+		return []string{"pwd", "hwk", "mfa"}
+	case models.LoginMethodSAML:
+		return []string{"fed"}
+	default:
+		return nil
+	}
+}
+
+// mergeAMR объединяет способы аутентификации без повторов
+func mergeAMR(current, added []string) []string {
+	merged := append([]string{}, current...)
+	for _, value := range added {
+		found := false
+		for _, existing := range merged {
+			if existing == value {
+				found = true
+				break
+			}
 		}
+		if !found {
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
+
+// RecordLoginFailure записывает неудачную попытку входа известного пользователя
+func (s *authService) RecordLoginFailure(ctx context.Context, user *models.User, method string, cause error, meta dto.RequestMeta) error {
+	return s.loginFailed(ctx, user.Email, user, method, cause, meta)
+}
+
+// loginFailed записывает неудачную попытку входа в журнал аудита и,
+// если пользователь существует, в его историю входов
+func (s *authService) loginFailed(ctx context.Context, identifier string, user *models.User, method string, cause error, meta dto.RequestMeta) error {
+	reason := loginFailureReason(cause)
+	s.metrics.LoginFailed(method, reason)
+	s.logger.WarnContext(ctx, "Неудачная попытка входа", "identifier", identifier, "method", method, "reason", reason)
+
+	var targetID *uuid.UUID
+	if user != nil {
+		targetID = &user.ID
+		if err := s.loginHistory.RecordFailure(ctx, user.ID, method, meta); err != nil {
+			return err
+		}
+	}
+
+	return s.auditService.Record(ctx, meta, models.AuditLoginFailure, nil, targetID, map[string]interface{}{
+		"identifier": identifier,
+		"method":     method,
+		"error":      cause.Error(),
+	})
+}
+
+// loginFailureReason сводит ошибку входа к причине с ограниченным набором значений для метрик
+func loginFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		return "invalid_credentials"
+	case errors.Is(err, ErrAccountBlocked):
+		return "account_blocked"
+	case errors.Is(err, ErrInvalidLoginCode):
+		return "invalid_code"
+	case errors.Is(err, ErrWebAuthnVerification), errors.Is(err, ErrWebAuthnSession):
+		return "webauthn_failed"
+	case errors.Is(err, ErrReauthenticationFailed):
+		return "reauthentication_failed"
+	case errors.Is(err, ErrExternalAccountConflict):
+		return "account_conflict"
+	default:
+		return "error"
+	}
+}
+
+// Impersonate выпускает короткоживущий токен от имени пользователя для администратора.
+// Токен несет роль целевого пользователя и claim "act" с данными администратора.
+func (s *authService) Impersonate(ctx context.Context, actorID, targetID uuid.UUID, reason string, meta dto.RequestMeta) (string, error) {
+	if actorID == targetID {
+		return "", ErrImpersonationForbidden
+	}
+
+	actor, err := s.userRepo.FindByID(ctx, actorID)
+	if err != nil {
+		return "", err
+	}
+	target, err := s.userRepo.FindByID(ctx, targetID)
+	if err != nil {
 		return "", err
 	}
 
-	// Проверка пароля
-	if err := user.CheckPassword(req.Password); err != nil {
-		return "", errors.New("неверное имя пользователя или пароль")
+	// Администраторы не могут действовать от имени других администраторов
+	if target.Role == "admin" {
+		return "", ErrImpersonationForbidden
 	}
 
-	// Создание JWT токена
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &JWTClaim{
-		UserID:   user.ID,
-		Email:    user.Email,
-		Role:     user.Role,
+	claims := s.newClaims(target, "", s.impersonationTTL)
+	claims.Act = &ActorClaim{UserID: actor.ID, Email: actor.Email}
+
+	token, err := s.sign(claims)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditImpersonationStart, &actor.ID, &target.ID, map[string]interface{}{
+		"reason":     reason,
+		"token_id":   claims.ID,
+		"expires_at": claims.ExpiresAt.Time,
+	}); err != nil {
+		return "", err
+	}
+	s.logger.InfoContext(ctx, "Начата имперсонация", "account_id", target.ID, "jti", claims.ID)
+
+	return token, nil
+}
+
+// EndImpersonation отзывает токен имперсонации до истечения его срока
+func (s *authService) EndImpersonation(ctx context.Context, claims *JWTClaim, meta dto.RequestMeta) error {
+	if claims.Act == nil {
+		return ErrNotImpersonating
+	}
+
+	if err := s.revokeToken(ctx, claims, meta); err != nil {
+		return err
+	}
+
+	return s.auditService.Record(ctx, meta, models.AuditImpersonationEnd, &claims.Act.UserID, &claims.UserID, map[string]interface{}{
+		"token_id": claims.ID,
+	})
+}
+
+// revokeToken добавляет токен в список отозванных и записывает это в журнал
+func (s *authService) revokeToken(ctx context.Context, claims *JWTClaim, meta dto.RequestMeta) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	if err := s.tokenRepo.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	return s.auditService.Record(ctx, meta, models.AuditTokenRevoked, nil, &claims.UserID, map[string]interface{}{
+		"token_id": claims.ID,
+	})
+}
+
+// issueToken выпускает подписанный JWT токен с указанной областью действия и временем жизни
+func (s *authService) issueToken(user *models.User, scope string, lifetime time.Duration) (string, error) {
+	return s.sign(s.newClaims(user, scope, lifetime))
+}
+
+// newClaims формирует claims токена с уникальным идентификатором (jti) для возможности отзыва
+func (s *authService) newClaims(user *models.User, scope string, lifetime time.Duration) *JWTClaim {
+	now := time.Now()
+	return &JWTClaim{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   user.Role,
+		Scope:  scope,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
+}
+
+// sign подписывает claims секретом приложения и учитывает выпущенный токен
+func (s *authService) sign(claims *JWTClaim) (string, error) {
+	tokenString, err := s.signClaims(claims)
+	if err != nil {
+		return "", err
+	}
 
+	s.metrics.TokenIssued(tokenKind(claims))
+	return tokenString, nil
+}
+
+// signClaims подписывает claims текущим секретом с заголовком kid
+func (s *authService) signClaims(claims *JWTClaim) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = s.jwtKeyID
 	tokenString, err := token.SignedString([]byte(s.jwtSecret))
 	if err != nil {
 		return "", err
@@ -108,31 +623,177 @@ func (s *authService) Login(req dto.LoginRequest) (string, error) {
 	return tokenString, nil
 }
 
-// Logout реализация метода для выхода из системы
-func (s *authService) Logout() error {
-    // Здесь можно настроить дополнительную логику, например очистку данных пользователя
-    // В данном случае достаточно удалить токен из cookies (будет обработано в контроллере)
-    return nil
+// CheckSigningKeys проверяет ключ подписи: пробный токен подписывается текущим секретом
+// и проверяется так же, как токены клиентов
+func (s *authService) CheckSigningKeys(ctx context.Context) error {
+	if s.jwtSecret == "" {
+		return errors.New("секрет подписи токенов не задан")
+	}
+
+	tokenString, err := s.signClaims(&JWTClaim{
+		Scope: "health",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = jwt.ParseWithClaims(tokenString, &JWTClaim{}, s.verificationKey)
+	return err
+}
+
+// verificationKey выбирает секрет для проверки подписи по заголовку kid.
+// Токены без kid выпущены до появления ротации и проверяются текущим секретом.
+func (s *authService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("неожиданный алгоритм подписи")
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return []byte(s.jwtSecret), nil
+	}
+	secret, ok := s.jwtKeys[kid]
+	if !ok {
+		return nil, errors.New("неизвестный ключ подписи")
+	}
+	return []byte(secret), nil
+}
+
+// JWTKeyID возвращает идентификатор ключа подписи (kid): начало SHA-256 секрета, сам секрет не раскрывается
+func JWTKeyID(secret string) string {
+	sum := sha256.Sum256([]byte("jwt-kid:" + secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Logout отзывает текущий токен, чтобы его нельзя было использовать после выхода.
+// Отсутствующий или уже недействительный токен не считается ошибкой.
+func (s *authService) Logout(ctx context.Context, tokenString string, meta dto.RequestMeta) error {
+	if tokenString == "" {
+		return nil
+	}
+
+	_, claims, err := s.ValidateToken(ctx, tokenString)
+	if err != nil {
+		return nil
+	}
+
+	if meta.ActorID == nil {
+		meta.ActorID = &claims.UserID
+	}
+	if meta.ImpersonatorID == nil && claims.Act != nil {
+		meta.ImpersonatorID = &claims.Act.UserID
+	}
+
+	if err := s.revokeToken(ctx, claims, meta); err != nil {
+		return err
+	}
+
+	return s.auditService.Record(ctx, meta, models.AuditLogout, &claims.UserID, &claims.UserID, nil)
 }
 
 // ValidateToken проверяет и валидирует JWT токен
-func (s *authService) ValidateToken(tokenString string) (*jwt.Token, *JWTClaim, error) {
+func (s *authService) ValidateToken(ctx context.Context, tokenString string) (*jwt.Token, *JWTClaim, error) {
+	token, claims, reason, err := s.validateToken(ctx, tokenString)
+	if err != nil {
+		s.metrics.TokenRejected(tokenKindAccess, reason)
+		s.logger.DebugContext(ctx, "Токен отклонен", "reason", reason, "error", err)
+		return nil, nil, err
+	}
+
+	s.metrics.TokenValidated(tokenKind(claims))
+	return token, claims, nil
+}
+
+// validateToken выполняет проверки токена доступа; reason — причина отказа для метрик
+func (s *authService) validateToken(ctx context.Context, tokenString string) (*jwt.Token, *JWTClaim, string, error) {
 	claims := &JWTClaim{}
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		claims,
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(s.jwtSecret), nil
-		},
+		s.verificationKey,
 	)
 
 	if err != nil {
-		return nil, nil, errors.New("ошибка при разборе токена: " + err.Error())
+		reason := "invalid"
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			reason = "expired"
+		}
+		return nil, nil, reason, errors.New("ошибка при разборе токена: " + err.Error())
 	}
 
 	if !token.Valid {
-		return nil, nil, errors.New("недействительный токен")
+		return nil, nil, "invalid", errors.New("недействительный токен")
 	}
 
-	return token, claims, nil
-}
\ No newline at end of file
+	// Токены с ограниченной областью действия не дают доступа к API
+	if claims.Scope != "" {
+		return nil, nil, "scope", errors.New("токен не предназначен для доступа к API")
+	}
+
+	// Проверка отзыва (завершенная имперсонация)
+	if claims.ID != "" {
+		revoked, err := s.tokenRepo.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, nil, "error", err
+		}
+		if revoked {
+			return nil, nil, "revoked", errors.New("токен отозван")
+		}
+	}
+
+	// Токены, выпущенные до массового завершения сессий пользователя, недействительны.
+	// iat хранится с точностью до секунды, поэтому момент отзыва тоже округляется вниз:
+	// иначе токен, выданный в ту же секунду сразу после отзыва, был бы отклонен.
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, nil, "user_not_found", errors.New("пользователь не найден")
+	}
+	if user.SessionsRevokedAt != nil && claims.IssuedAt != nil && claims.IssuedAt.Before(user.SessionsRevokedAt.Truncate(time.Second)) {
+		return nil, nil, "revoked", errors.New("токен отозван")
+	}
+
+	// Блокировка действует и для уже выданных токенов
+	if err := checkAccountStatus(user); err != nil {
+		return nil, nil, "account_blocked", err
+	}
+
+	return token, claims, "", nil
+}
+
+// ValidateMFAToken проверяет токен, выданный после пароля для подтверждения вторым фактором
+func (s *authService) ValidateMFAToken(ctx context.Context, tokenString string) (*JWTClaim, error) {
+	claims := &JWTClaim{}
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		claims,
+		s.verificationKey,
+	)
+	if err != nil || !token.Valid || claims.Scope != TokenScopeMFA {
+		s.metrics.TokenRejected(TokenScopeMFA, "invalid")
+		return nil, ErrInvalidMFAToken
+	}
+
+	s.metrics.TokenValidated(TokenScopeMFA)
+	return claims, nil
+}
+
+// Виды токенов в метриках
+const (
+	tokenKindAccess        = "access"
+	tokenKindImpersonation = "impersonation"
+)
+
+// tokenKind определяет вид токена по claims: access, impersonation или область действия (mfa)
+func tokenKind(claims *JWTClaim) string {
+	switch {
+	case claims.Scope != "":
+		return claims.Scope
+	case claims.Act != nil:
+		return tokenKindImpersonation
+	default:
+		return tokenKindAccess
+	}
+}
@@ -0,0 +1,479 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/logging"
+	"AuthApplications/metrics"
+	"AuthApplications/models"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+// authHarness сервис аутентификации с фейковыми зависимостями
+type authHarness struct {
+	service      AuthService
+	users        *fakeUserRepository
+	tokens       *fakeTokenRepository
+	outbox       *fakeOutboxRepository
+	audit        *fakeAuditService
+	loginHistory *fakeLoginHistory
+	registry     *prometheus.Registry
+	cfg          *config.Config
+}
+
+func newAuthHarness(t *testing.T, users ...*models.User) *authHarness {
+	t.Helper()
+
+	h := &authHarness{
+		users:        newFakeUserRepository(users...),
+		tokens:       newFakeTokenRepository(),
+		outbox:       &fakeOutboxRepository{},
+		audit:        &fakeAuditService{},
+		loginHistory: &fakeLoginHistory{},
+		registry:     prometheus.NewRegistry(),
+		cfg: &config.Config{
+			JWTSecret:        testJWTSecret,
+			AccessTokenTTL:   time.Hour,
+			ImpersonationTTL: 30 * time.Minute,
+		},
+	}
+	h.service = NewAuthService(h.users, h.tokens, h.outbox, fakeTxManager{}, h.audit, h.loginHistory, metrics.New(h.registry), logging.Nop(), h.cfg)
+	return h
+}
+
+// newLocalUser создает активного локального пользователя с паролем
+func newLocalUser(t *testing.T, email, password string) *models.User {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.User{
+		Email:      email,
+		Username:   email,
+		Password:   string(hash),
+		Role:       "user",
+		AuthSource: models.AuthSourceLocal,
+		Status:     models.UserStatusActive,
+	}
+}
+
+// backdateToken переподписывает токен, сдвигая iat в прошлое: iat хранится с точностью
+// до секунды, поэтому токен, выпущенный в тесте, иначе совпал бы по времени с отзывом сессий
+func backdateToken(t *testing.T, h *authHarness, token string, by time.Duration) string {
+	t.Helper()
+
+	_, claims, err := h.service.ValidateToken(context.Background(), token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	claims.IssuedAt = jwt.NewNumericDate(claims.IssuedAt.Add(-by))
+	backdated, err := h.service.(*authService).signClaims(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backdated
+}
+
+func TestCompleteLoginRequiresSecondFactorForNonPasskeyMethods(t *testing.T) {
+	methods := []string{
+		models.LoginMethodPassword,
+		models.LoginMethodMagicLink,
+		models.LoginMethodOTP,
+		models.LoginMethodSAML,
+	}
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			user := newLocalUser(t, "mfa@example.com", "secret-password")
+			user.MFAEnabled = true
+			h := newAuthHarness(t, user)
+
+			response, err := h.service.CompleteLogin(context.Background(), user, method, dto.RequestMeta{})
+			if err != nil {
+				t.Fatalf("CompleteLogin: %v", err)
+			}
+			if !response.SecondFactorRequired {
+				t.Fatal("ожидался запрос второго фактора")
+			}
+
+			// Выданный токен годится только для подтверждения входа
+			claims, err := h.service.ValidateMFAToken(context.Background(), response.Token)
+			if err != nil {
+				t.Fatalf("ValidateMFAToken: %v", err)
+			}
+			if claims.UserID != user.ID {
+				t.Errorf("user_id = %s, ожидался %s", claims.UserID, user.ID)
+			}
+			if _, _, err := h.service.ValidateToken(context.Background(), response.Token); err == nil {
+				t.Error("токен подтверждения входа принят как токен доступа")
+			}
+
+			if len(h.loginHistory.successes) != 0 {
+				t.Errorf("вход записан как успешный до второго фактора: %v", h.loginHistory.successes)
+			}
+			if actions := h.audit.recorded(); !slices.Equal(actions, []string{models.AuditLoginMFARequired}) {
+				t.Errorf("события аудита = %v", actions)
+			}
+		})
+	}
+}
+
+func TestCompleteLoginIssuesAccessTokenForPasskeyMethods(t *testing.T) {
+	methods := map[string][]string{
+		models.LoginMethodPasskey: {"hwk", "user"},
+		models.LoginMethodMFA:     {"pwd", "hwk", "mfa"},
+	}
+	for method, amr := range methods {
+		t.Run(method, func(t *testing.T) {
+			user := newLocalUser(t, "mfa@example.com", "secret-password")
+			user.MFAEnabled = true
+			h := newAuthHarness(t, user)
+
+			response, err := h.service.CompleteLogin(context.Background(), user, method, dto.RequestMeta{})
+			if err != nil {
+				t.Fatalf("CompleteLogin: %v", err)
+			}
+			if response.SecondFactorRequired {
+				t.Fatal("вход ключом доступа не должен требовать второго фактора")
+			}
+
+			_, claims, err := h.service.ValidateToken(context.Background(), response.Token)
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			if !slices.Equal(claims.AMR, amr) {
+				t.Errorf("amr = %v, ожидался %v", claims.AMR, amr)
+			}
+			if !slices.Equal(h.loginHistory.successes, []string{method}) {
+				t.Errorf("история входов = %v", h.loginHistory.successes)
+			}
+		})
+	}
+}
+
+func TestCompleteLoginWithoutSecondFactor(t *testing.T) {
+	user := newLocalUser(t, "plain@example.com", "secret-password")
+	h := newAuthHarness(t, user)
+
+	response, err := h.service.CompleteLogin(context.Background(), user, models.LoginMethodMagicLink, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if response.SecondFactorRequired {
+		t.Fatal("второй фактор не включен, но запрошен")
+	}
+	if _, _, err := h.service.ValidateToken(context.Background(), response.Token); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+}
+
+func TestCompleteLoginRejectsBlockedUserBeforeSecondFactor(t *testing.T) {
+	user := newLocalUser(t, "banned@example.com", "secret-password")
+	user.MFAEnabled = true
+	user.Status = models.UserStatusBanned
+	h := newAuthHarness(t, user)
+
+	response, err := h.service.CompleteLogin(context.Background(), user, models.LoginMethodOTP, dto.RequestMeta{})
+	if err == nil {
+		t.Fatalf("заблокированный пользователь получил ответ %+v", response)
+	}
+	if !slices.Equal(h.loginHistory.failures, []string{models.LoginMethodOTP}) {
+		t.Errorf("неудачные входы = %v", h.loginHistory.failures)
+	}
+}
+
+func TestLoginWithPasswordRequiresSecondFactor(t *testing.T) {
+	user := newLocalUser(t, "mfa@example.com", "secret-password")
+	user.MFAEnabled = true
+	h := newAuthHarness(t, user)
+
+	response, err := h.service.Login(context.Background(), dto.LoginRequest{
+		Identifier: "mfa@example.com",
+		Password:   "secret-password",
+	}, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if !response.SecondFactorRequired {
+		t.Fatal("ожидался запрос второго фактора")
+	}
+	if _, err := h.service.ValidateMFAToken(context.Background(), response.Token); err != nil {
+		t.Fatalf("ValidateMFAToken: %v", err)
+	}
+}
+
+func TestLoginRecordsMetrics(t *testing.T) {
+	user := newLocalUser(t, "user@example.com", "secret-password")
+	h := newAuthHarness(t, user)
+	ctx := context.Background()
+
+	if _, err := h.service.Login(ctx, dto.LoginRequest{Identifier: "user@example.com", Password: "wrong"}, dto.RequestMeta{}); err == nil {
+		t.Fatal("вход с неверным паролем выполнен")
+	}
+	if _, err := h.service.Login(ctx, dto.LoginRequest{Identifier: "user@example.com", Password: "secret-password"}, dto.RequestMeta{}); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	expected := `
+# HELP auth_login_failures_total Неудачные попытки входа по способу аутентификации и причине.
+# TYPE auth_login_failures_total counter
+auth_login_failures_total{method="password",reason="invalid_credentials"} 1
+# HELP auth_login_success_total Успешные входы по способу аутентификации.
+# TYPE auth_login_success_total counter
+auth_login_success_total{method="password"} 1
+# HELP auth_tokens_issued_total Выпущенные JWT токены по виду: access, mfa или impersonation.
+# TYPE auth_tokens_issued_total counter
+auth_tokens_issued_total{kind="access"} 1
+`
+	err := testutil.GatherAndCompare(h.registry, strings.NewReader(expected),
+		"auth_login_failures_total", "auth_login_success_total", "auth_tokens_issued_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImpersonateIssuesTokenOnBehalfOfUser(t *testing.T) {
+	admin := newLocalUser(t, "admin@example.com", "secret-password")
+	admin.Role = "admin"
+	user := newLocalUser(t, "user@example.com", "secret-password")
+	h := newAuthHarness(t, admin, user)
+	ctx := context.Background()
+
+	token, err := h.service.Impersonate(ctx, admin.ID, user.ID, "обращение в поддержку", dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+
+	_, claims, err := h.service.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.UserID != user.ID || claims.Role != "user" {
+		t.Errorf("токен выпущен не от имени пользователя: %+v", claims)
+	}
+	if claims.Act == nil || claims.Act.UserID != admin.ID || claims.Act.Email != admin.Email {
+		t.Errorf("act = %+v", claims.Act)
+	}
+	if lifetime := time.Until(claims.ExpiresAt.Time); lifetime > h.cfg.ImpersonationTTL || lifetime < h.cfg.ImpersonationTTL-time.Minute {
+		t.Errorf("срок токена %v, ожидался IMPERSONATION_TTL", lifetime)
+	}
+
+	if actions := h.audit.recorded(); !slices.Equal(actions, []string{models.AuditImpersonationStart}) {
+		t.Fatalf("аудит = %v", actions)
+	}
+	if reason := h.audit.details[0]["reason"]; reason != "обращение в поддержку" {
+		t.Errorf("причина в аудите = %v", reason)
+	}
+}
+
+func TestImpersonateForbidsAdminsAndSelf(t *testing.T) {
+	admin := newLocalUser(t, "admin@example.com", "secret-password")
+	admin.Role = "admin"
+	other := newLocalUser(t, "other-admin@example.com", "secret-password")
+	other.Role = "admin"
+	h := newAuthHarness(t, admin, other)
+
+	for name, targetID := range map[string]uuid.UUID{"self": admin.ID, "admin": other.ID} {
+		t.Run(name, func(t *testing.T) {
+			_, err := h.service.Impersonate(context.Background(), admin.ID, targetID, "", dto.RequestMeta{})
+			if !errors.Is(err, ErrImpersonationForbidden) {
+				t.Fatalf("ожидалась ErrImpersonationForbidden, получено %v", err)
+			}
+		})
+	}
+	if actions := h.audit.recorded(); len(actions) != 0 {
+		t.Errorf("аудит = %v", actions)
+	}
+}
+
+func TestEndImpersonationRevokesToken(t *testing.T) {
+	admin := newLocalUser(t, "admin@example.com", "secret-password")
+	admin.Role = "admin"
+	user := newLocalUser(t, "user@example.com", "secret-password")
+	h := newAuthHarness(t, admin, user)
+	ctx := context.Background()
+
+	token, err := h.service.Impersonate(ctx, admin.ID, user.ID, "", dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+	_, claims, err := h.service.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	if err := h.service.EndImpersonation(ctx, claims, dto.RequestMeta{}); err != nil {
+		t.Fatalf("EndImpersonation: %v", err)
+	}
+	if _, _, err := h.service.ValidateToken(ctx, token); err == nil {
+		t.Fatal("токен имперсонации действителен после завершения")
+	}
+	want := []string{models.AuditImpersonationStart, models.AuditTokenRevoked, models.AuditImpersonationEnd}
+	if actions := h.audit.recorded(); !slices.Equal(actions, want) {
+		t.Errorf("аудит = %v", actions)
+	}
+
+	// Обычный токен нельзя завершить как имперсонацию
+	response, err := h.service.CompleteLogin(ctx, user, models.LoginMethodPassword, dto.RequestMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, claims, err = h.service.ValidateToken(ctx, response.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.service.EndImpersonation(ctx, claims, dto.RequestMeta{}); !errors.Is(err, ErrNotImpersonating) {
+		t.Fatalf("ожидалась ErrNotImpersonating, получено %v", err)
+	}
+}
+
+func TestReauthenticateRefreshesAuthTimeWithoutExtendingSession(t *testing.T) {
+	user := newLocalUser(t, "user@example.com", "secret-password")
+	h := newAuthHarness(t, user)
+	ctx := context.Background()
+
+	response, err := h.service.CompleteLogin(ctx, user, models.LoginMethodMagicLink, dto.RequestMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, claims, err := h.service.ValidateToken(ctx, response.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Вход был давно: токен еще действует, но для чувствительных операций устарел
+	claims.AuthTime = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	token, err := h.service.Reauthenticate(ctx, claims, "secret-password", dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Reauthenticate: %v", err)
+	}
+	_, elevated, err := h.service.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if elevated.AuthTime == nil || time.Since(elevated.AuthTime.Time) > time.Minute {
+		t.Errorf("auth_time не обновлен: %v", elevated.AuthTime)
+	}
+	if !elevated.ExpiresAt.Equal(claims.ExpiresAt.Time) {
+		t.Errorf("срок токена продлен: %v, был %v", elevated.ExpiresAt, claims.ExpiresAt)
+	}
+	if !slices.Equal(elevated.AMR, []string{"otp", "pwd"}) {
+		t.Errorf("amr = %v", elevated.AMR)
+	}
+	if actions := h.audit.recorded(); !slices.Contains(actions, models.AuditStepUp) {
+		t.Errorf("аудит = %v", actions)
+	}
+}
+
+func TestReauthenticateRejectsWrongPassword(t *testing.T) {
+	user := newLocalUser(t, "user@example.com", "secret-password")
+	h := newAuthHarness(t, user)
+
+	_, err := h.service.Reauthenticate(context.Background(), &JWTClaim{UserID: user.ID}, "wrong", dto.RequestMeta{})
+	if !errors.Is(err, ErrReauthenticationFailed) {
+		t.Fatalf("ожидалась ErrReauthenticationFailed, получено %v", err)
+	}
+	if !slices.Equal(h.loginHistory.failures, []string{models.LoginMethodStepUpPassword}) {
+		t.Errorf("неудачные входы = %v", h.loginHistory.failures)
+	}
+}
+
+func TestLoginAcceptsUsernameOrEmailInAnyCase(t *testing.T) {
+	user := newLocalUser(t, "reader@example.com", "secret-password")
+	user.Username = "Reader"
+
+	for _, identifier := range []string{"reader@example.com", " READER@Example.com ", "reader", "READER"} {
+		t.Run(identifier, func(t *testing.T) {
+			h := newAuthHarness(t, user)
+			response, err := h.service.Login(context.Background(), dto.LoginRequest{
+				Identifier: identifier,
+				Password:   "secret-password",
+			}, dto.RequestMeta{})
+			if err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+			if response.Token == "" {
+				t.Fatal("токен не выдан")
+			}
+		})
+	}
+}
+
+func TestLoginAcceptsLegacyEmailField(t *testing.T) {
+	h := newAuthHarness(t, newLocalUser(t, "reader@example.com", "secret-password"))
+
+	if _, err := h.service.Login(context.Background(), dto.LoginRequest{
+		Email:    "Reader@Example.com",
+		Password: "secret-password",
+	}, dto.RequestMeta{}); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+}
+
+func TestRegisterRejectsIdentifiersDifferingOnlyInCase(t *testing.T) {
+	existing := newLocalUser(t, "reader@example.com", "secret-password")
+	existing.Username = "reader"
+
+	tests := []struct {
+		name string
+		req  dto.RegisterRequest
+		want error
+	}{
+		{name: "email", req: dto.RegisterRequest{Email: "Reader@Example.COM", Username: "other", Password: "password"}},
+		{name: "username", req: dto.RegisterRequest{Email: "other@example.com", Username: "READER", Password: "password"}, want: ErrUsernameTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newAuthHarness(t, existing)
+			_, err := h.service.Register(context.Background(), tt.req, dto.RequestMeta{})
+			if err == nil {
+				t.Fatal("создана вторая учетная запись с тем же идентификатором")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("ожидалась %v, получено %v", tt.want, err)
+			}
+			if len(h.users.users) != 1 {
+				t.Errorf("пользователей = %d", len(h.users.users))
+			}
+		})
+	}
+}
+
+func TestValidateTokenAcceptsTokenIssuedRightAfterRevocation(t *testing.T) {
+	user := newLocalUser(t, "alice@example.com", "secret-password")
+	h := newAuthHarness(t, user)
+	ctx := context.Background()
+
+	// Сессии завершены в конце текущей секунды, новый токен выпущен в ту же секунду
+	revokedAt := time.Now().Truncate(time.Second).Add(999 * time.Millisecond)
+	h.users.users[user.ID].SessionsRevokedAt = &revokedAt
+
+	response, err := h.service.CompleteLogin(ctx, user, models.LoginMethodPassword, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if _, _, err := h.service.ValidateToken(ctx, response.Token); err != nil {
+		t.Fatalf("токен, выпущенный сразу после отзыва сессий, отклонен: %v", err)
+	}
+
+	// Токен из предыдущей секунды выпущен до отзыва
+	if _, _, err := h.service.ValidateToken(ctx, backdateToken(t, h, response.Token, time.Second)); err == nil {
+		t.Fatal("токен, выпущенный до отзыва сессий, принят")
+	}
+}
@@ -0,0 +1,296 @@
+// services/authenticator.go - цепочка бэкендов проверки учетных данных
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/metrics"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCredentials возвращается, если ни один бэкенд не подтвердил учетные данные
+var ErrInvalidCredentials = errors.New("неверное имя пользователя или пароль")
+
+// ErrExternalAccountConflict возвращается, если email внешней учетной записи занят пользователем
+// другого источника или email либо имя заняты при одновременном первом входе
+var ErrExternalAccountConflict = errors.New("пользователь с таким email уже существует и не связан с внешним провайдером")
+
+// Authenticator проверяет учетные данные и возвращает локального пользователя.
+// Сведения о запросе нужны бэкендам, которые создают пользователей и пишут журнал аудита.
+type Authenticator interface {
+	Authenticate(ctx context.Context, identifier, password string, meta dto.RequestMeta) (*models.User, error)
+}
+
+// authenticatorChain по очереди опрашивает бэкенды до первого успешного
+type authenticatorChain struct {
+	authenticators []Authenticator
+}
+
+// NewAuthenticatorChain создает цепочку аутентификаторов
+func NewAuthenticatorChain(authenticators ...Authenticator) Authenticator {
+	return &authenticatorChain{authenticators: authenticators}
+}
+
+// Authenticate возвращает пользователя от первого бэкенда, принявшего учетные данные.
+// Ошибки инфраструктуры (например, недоступный LDAP) не прерывают цепочку,
+// но возвращаются, если ни один бэкенд не подтвердил учетные данные.
+func (c *authenticatorChain) Authenticate(ctx context.Context, identifier, password string, meta dto.RequestMeta) (*models.User, error) {
+	var backendErr error
+	for _, authenticator := range c.authenticators {
+		user, err := authenticator.Authenticate(ctx, identifier, password, meta)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, ErrInvalidCredentials) && backendErr == nil {
+			backendErr = err
+		}
+	}
+
+	if backendErr != nil {
+		return nil, backendErr
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// localAuthenticator проверяет пароль по bcrypt-хешу в базе данных
+type localAuthenticator struct {
+	userRepo repositories.UserRepository
+}
+
+// NewLocalAuthenticator создает аутентификатор по локальным паролям
+func NewLocalAuthenticator(userRepo repositories.UserRepository) Authenticator {
+	return &localAuthenticator{userRepo: userRepo}
+}
+
+// Authenticate проверяет пароль локального пользователя, найденного по email или имени
+func (a *localAuthenticator) Authenticate(ctx context.Context, identifier, password string, meta dto.RequestMeta) (*models.User, error) {
+	user, err := a.userRepo.FindByIdentifier(ctx, identifier)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// Пароли пользователей из каталога проверяются только в каталоге
+	if user.AuthSource != "" && user.AuthSource != models.AuthSourceLocal {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := user.CheckPassword(password); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// mapGroupsToRole выбирает роль по первому правилу, группа которого есть у пользователя
+func mapGroupsToRole(groups []string, groupRoles []config.GroupRole, defaultRole string) string {
+	for _, mapping := range groupRoles {
+		for _, group := range groups {
+			if strings.EqualFold(strings.TrimSpace(group), mapping.Group) {
+				return mapping.Role
+			}
+		}
+	}
+	return defaultRole
+}
+
+// externalPasswordHash хранится вместо пароля у пользователей внешних провайдеров.
+// Это не bcrypt-хеш, поэтому локальная проверка пароля для них никогда не проходит.
+const externalPasswordHash = "!external"
+
+// externalProfile описывает пользователя, подтвержденного внешним провайдером (LDAP, SAML)
+type externalProfile struct {
+	Source    string
+	Email     string
+	Username  string
+	FirstName string
+	LastName  string
+	Role      string
+}
+
+// externalProvisioner создает и обновляет локальных пользователей внешних провайдеров
+// вместе с событиями outbox и записями журнала аудита
+type externalProvisioner struct {
+	userRepo     repositories.UserRepository
+	outboxRepo   repositories.OutboxRepository
+	txManager    repositories.TxManager
+	auditService AuditService
+	metrics      metrics.Metrics
+}
+
+// newExternalProvisioner создает общий для LDAP и SAML механизм синхронизации пользователей
+func newExternalProvisioner(
+	userRepo repositories.UserRepository,
+	outboxRepo repositories.OutboxRepository,
+	txManager repositories.TxManager,
+	auditService AuditService,
+	m metrics.Metrics,
+) *externalProvisioner {
+	return &externalProvisioner{
+		userRepo:     userRepo,
+		outboxRepo:   outboxRepo,
+		txManager:    txManager,
+		auditService: auditService,
+		metrics:      m,
+	}
+}
+
+// provision создает локального пользователя при первом входе через внешний провайдер
+// или обновляет его данные при последующих входах.
+// Одновременный первый вход с тем же email или именем нарушает уникальный индекс;
+// такая ошибка возвращается как ErrExternalAccountConflict, а не как сбой сервера.
+func (p *externalProvisioner) provision(ctx context.Context, profile externalProfile, meta dto.RequestMeta) (*models.User, error) {
+	user, err := p.userRepo.FindByEmail(ctx, profile.Email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		return p.create(ctx, profile, meta)
+	}
+
+	// Учетную запись другого источника с тем же email провайдер не перехватывает
+	if user.AuthSource != profile.Source {
+		return nil, ErrExternalAccountConflict
+	}
+	return p.update(ctx, user, profile, meta)
+}
+
+// create создает пользователя вместе с событием user.registered
+func (p *externalProvisioner) create(ctx context.Context, profile externalProfile, meta dto.RequestMeta) (*models.User, error) {
+	username, err := availableUsername(ctx, p.userRepo, profile.Username, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:   username,
+		Email:      profile.Email,
+		FirstName:  profile.FirstName,
+		LastName:   profile.LastName,
+		Role:       profile.Role,
+		AuthSource: profile.Source,
+	}
+	// Локальный пароль для таких пользователей не используется
+	user.SetPasswordHash(externalPasswordHash)
+
+	err = p.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := p.userRepo.WithTx(tx).Create(ctx, user); err != nil {
+			return provisioningError(err)
+		}
+
+		event, err := newOutboxEvent(models.EventUserRegistered, user.ID, toUserResponse(user))
+		if err != nil {
+			return err
+		}
+		return p.outboxRepo.WithTx(tx).Create(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.metrics.UserRegistered(profile.Source)
+
+	if err := p.auditService.Record(ctx, meta, models.AuditRegister, &user.ID, &user.ID, map[string]interface{}{
+		"auth_source": profile.Source,
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// update переносит данные провайдера в существующего пользователя. Если они изменились,
+// вместе с ними сохраняется событие user.updated, а смена роли фиксируется в журнале аудита.
+func (p *externalProvisioner) update(ctx context.Context, user *models.User, profile externalProfile, meta dto.RequestMeta) (*models.User, error) {
+	username, err := availableUsername(ctx, p.userRepo, profile.Username, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousRole := user.Role
+	var changed []string
+	if user.Username != username {
+		changed = append(changed, "username")
+	}
+	if user.FirstName != profile.FirstName {
+		changed = append(changed, "first_name")
+	}
+	if user.LastName != profile.LastName {
+		changed = append(changed, "last_name")
+	}
+	if user.Role != profile.Role {
+		changed = append(changed, "role")
+	}
+	if len(changed) == 0 {
+		return user, nil
+	}
+
+	user.Username = username
+	user.FirstName = profile.FirstName
+	user.LastName = profile.LastName
+	user.Role = profile.Role
+
+	err = p.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := p.userRepo.WithTx(tx).PatchUser(ctx, user, "username", "first_name", "last_name", "role"); err != nil {
+			return provisioningError(err)
+		}
+
+		event, err := newOutboxEvent(models.EventUserUpdated, user.ID, map[string]interface{}{
+			"user":           toUserResponse(user),
+			"changed_fields": changed,
+		})
+		if err != nil {
+			return err
+		}
+		return p.outboxRepo.WithTx(tx).Create(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Роль назначает провайдер по группам, поэтому инициатор не указывается
+	if user.Role != previousRole {
+		if err := p.auditService.Record(ctx, meta, models.AuditRoleChange, nil, &user.ID, map[string]interface{}{
+			"from":        previousRole,
+			"to":          user.Role,
+			"auth_source": profile.Source,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// provisioningError переводит нарушение уникальности email или имени в ErrExternalAccountConflict
+func provisioningError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrExternalAccountConflict
+	}
+	return err
+}
+
+// availableUsername возвращает имя из профиля провайдера, если оно допустимо и не занято
+// другим пользователем (в том числе ожидающим удаления); иначе имя остается пустым,
+// чтобы не блокировать вход
+func availableUsername(ctx context.Context, userRepo repositories.UserRepository, username string, userID uuid.UUID) (string, error) {
+	username = strings.TrimSpace(username)
+	if !dto.ValidUsername(username) {
+		return "", nil
+	}
+	taken, err := userRepo.UsernameTaken(ctx, username, userID)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", nil
+	}
+	return username, nil
+}
@@ -4,31 +4,41 @@ import (
 	"AuthApplications/dto"
 	"AuthApplications/models"
 	"AuthApplications/repositories"
+	"context"
 	"errors"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type BookService interface {
-	CreateBook(req dto.BookRequest) (*models.Book, error)
-	GetAllBook() ([]*dto.BookResponse, error)
-	GetByID(id uuid.UUID) (*dto.BookResponse, error)
-	FindByGenre(genre string) ([]*dto.BookResponse, error)
-    Search(query string) ([]*dto.BookResponse, error)
-    PatchBook(bookID uuid.UUID, req dto.PatchBookRequest) (*dto.BookResponse, error)
+	CreateBook(ctx context.Context, req dto.BookRequest) (*models.Book, error)
+	GetAllBook(ctx context.Context) ([]*dto.BookResponse, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*dto.BookResponse, error)
+	FindByGenre(ctx context.Context, genre string) ([]*dto.BookResponse, error)
+    Search(ctx context.Context, query string) ([]*dto.BookResponse, error)
+    PatchBook(ctx context.Context, bookID uuid.UUID, req dto.PatchBookRequest) (*dto.BookResponse, error)
 }
 
 type bookService struct {
-	bookRepo repositories.BookRepository
+	bookRepo   repositories.BookRepository
+	outboxRepo repositories.OutboxRepository
+	txManager  repositories.TxManager
 }
 
-func NewBookService(bookRepo repositories.BookRepository) BookService {
+func NewBookService(
+	bookRepo repositories.BookRepository,
+	outboxRepo repositories.OutboxRepository,
+	txManager repositories.TxManager,
+) BookService {
 	return &bookService{
-		bookRepo: bookRepo,
+		bookRepo:   bookRepo,
+		outboxRepo: outboxRepo,
+		txManager:  txManager,
 	}
 }
 
-func (s *bookService) CreateBook(req dto.BookRequest) (*models.Book, error) {
+func (s *bookService) CreateBook(ctx context.Context, req dto.BookRequest) (*models.Book, error) {
 	newBook := &models.Book{
 		Title:       req.Title,
 		AuthorID:    req.AuthorID,
@@ -42,7 +52,30 @@ func (s *bookService) CreateBook(req dto.BookRequest) (*models.Book, error) {
 		PageCount:   req.PageCount,
 	}
 
-	err := s.bookRepo.Create(newBook)
+	// Книга и событие book.created сохраняются в одной транзакции
+	err := s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.bookRepo.WithTx(tx).Create(ctx, newBook); err != nil {
+			return err
+		}
+
+		event, err := newOutboxEvent(models.EventBookCreated, newBook.ID, &dto.BookResponse{
+			ID:          newBook.ID,
+			Title:       newBook.Title,
+			AuthorID:    newBook.AuthorID,
+			Description: newBook.Description,
+			ISBN:        newBook.ISBN,
+			PublishYear: newBook.PublishYear,
+			CoverURL:    newBook.CoverURL,
+			FileURL:     newBook.FileURL,
+			Genre:       newBook.Genre,
+			Language:    newBook.Language,
+			PageCount:   newBook.PageCount,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outboxRepo.WithTx(tx).Create(ctx, event)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -50,8 +83,8 @@ func (s *bookService) CreateBook(req dto.BookRequest) (*models.Book, error) {
 	return newBook, nil
 }
 
-func (s *bookService) GetAllBook() ([]*dto.BookResponse, error) {
-	books, err := s.bookRepo.FindAll()
+func (s *bookService) GetAllBook(ctx context.Context) ([]*dto.BookResponse, error) {
+	books, err := s.bookRepo.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -76,8 +109,8 @@ func (s *bookService) GetAllBook() ([]*dto.BookResponse, error) {
 	return bookResponses, nil
 }
 
-func (s *bookService) GetByID(id uuid.UUID) (*dto.BookResponse, error) {
-	book, err := s.bookRepo.FindByID(id)
+func (s *bookService) GetByID(ctx context.Context, id uuid.UUID) (*dto.BookResponse, error) {
+	book, err := s.bookRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -99,8 +132,8 @@ func (s *bookService) GetByID(id uuid.UUID) (*dto.BookResponse, error) {
 	}, nil
 }
 
-func (s *bookService) FindByGenre(genre string) ([]*dto.BookResponse, error) {
-	books, err := s.bookRepo.FindByGenre(genre)
+func (s *bookService) FindByGenre(ctx context.Context, genre string) ([]*dto.BookResponse, error) {
+	books, err := s.bookRepo.FindByGenre(ctx, genre)
 	if err != nil {
 		return nil, err
 	}
@@ -129,8 +162,8 @@ func (s *bookService) FindByGenre(genre string) ([]*dto.BookResponse, error) {
 }
 
 
-func (s *bookService) Search(query string) ([]*dto.BookResponse, error) {
-    books, err := s.bookRepo.Search(query)
+func (s *bookService) Search(ctx context.Context, query string) ([]*dto.BookResponse, error) {
+    books, err := s.bookRepo.Search(ctx, query)
     if err != nil {
         return nil, err
     }
@@ -156,8 +189,8 @@ func (s *bookService) Search(query string) ([]*dto.BookResponse, error) {
     return bookResponses, nil
 }
 
-func (s *bookService) PatchBook(bookID uuid.UUID, req dto.PatchBookRequest) (*dto.BookResponse, error) {
-	book, err := s.bookRepo.FindByID(bookID)
+func (s *bookService) PatchBook(ctx context.Context, bookID uuid.UUID, req dto.PatchBookRequest) (*dto.BookResponse, error) {
+	book, err := s.bookRepo.FindByID(ctx, bookID)
 	if err != nil {
 		return nil, err
 	}
@@ -194,11 +227,7 @@ func (s *bookService) PatchBook(bookID uuid.UUID, req dto.PatchBookRequest) (*dt
 	if req.PageCount != nil {
 		book.PageCount = *req.PageCount
 	}
-	err = s.bookRepo.Patch(book)
-	if err != nil {
-		return nil, err
-	}
-	return &dto.BookResponse{
+	response := &dto.BookResponse{
 		ID:          book.ID,
 		Title:       book.Title,
 		AuthorID:    book.AuthorID,
@@ -210,5 +239,22 @@ func (s *bookService) PatchBook(bookID uuid.UUID, req dto.PatchBookRequest) (*dt
 		Genre:       book.Genre,
 		Language:    book.Language,
 		PageCount:   book.PageCount,
-	}, nil
+	}
+
+	// Изменения и событие book.updated сохраняются в одной транзакции
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.bookRepo.WithTx(tx).Patch(ctx, book); err != nil {
+			return err
+		}
+
+		event, err := newOutboxEvent(models.EventBookUpdated, book.ID, response)
+		if err != nil {
+			return err
+		}
+		return s.outboxRepo.WithTx(tx).Create(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
 }
\ No newline at end of file
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateBookWritesOutboxEvent(t *testing.T) {
+	books := &fakeBookRepository{}
+	outbox := &fakeOutboxRepository{}
+	service := NewBookService(books, outbox, fakeTxManager{})
+
+	book, err := service.CreateBook(context.Background(), dto.BookRequest{Title: "Мастер и Маргарита", AuthorID: uuid.New()})
+	if err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+
+	if len(outbox.events) != 1 {
+		t.Fatalf("событий в outbox: %d", len(outbox.events))
+	}
+	event := outbox.events[0]
+	if event.EventType != models.EventBookCreated || event.AggregateID != book.ID {
+		t.Errorf("событие = %+v", event)
+	}
+
+	var envelope struct {
+		ID   uuid.UUID        `json:"id"`
+		Type string           `json:"type"`
+		Data dto.BookResponse `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(event.Payload), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.ID != event.ID || envelope.Type != models.EventBookCreated || envelope.Data.Title != "Мастер и Маргарита" {
+		t.Errorf("тело события = %s", event.Payload)
+	}
+}
@@ -0,0 +1,70 @@
+// services/csrf_service.go - CSRF токены для запросов, аутентифицированных cookie
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"AuthApplications/config"
+)
+
+// ErrInvalidCSRFToken возвращается для отсутствующего, поддельного или истекшего CSRF токена
+var ErrInvalidCSRFToken = errors.New("недействительный CSRF токен")
+
+// CSRFService интерфейс сервиса CSRF токенов
+type CSRFService interface {
+	Issue() (token string, expiresAt time.Time, err error)
+	Verify(token string) error
+}
+
+// csrfService реализация CSRFService.
+// Токен вида "<истекает>.<случайное значение>.<HMAC>" подписан ключом, выведенным из секрета
+// приложения, поэтому cookie, подброшенный с соседнего поддомена, не пройдет проверку.
+type csrfService struct {
+	cfg *config.Config
+}
+
+// NewCSRFService создает новый сервис CSRF токенов
+func NewCSRFService(cfg *config.Config) CSRFService {
+	return &csrfService{cfg: cfg}
+}
+
+// Issue выпускает новый подписанный CSRF токен
+func (s *csrfService) Issue() (string, time.Time, error) {
+	nonce, err := randomToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(s.cfg.CSRFTokenTTL)
+	payload := fmt.Sprintf("%d.%s", expiresAt.Unix(), nonce)
+
+	return payload + "." + sign(s.cfg, signingPurposeCSRF, payload), expiresAt, nil
+}
+
+// Verify проверяет подпись и срок действия CSRF токена
+func (s *csrfService) Verify(token string) error {
+	idx := strings.LastIndex(token, ".")
+	if idx <= 0 {
+		return ErrInvalidCSRFToken
+	}
+	payload, signature := token[:idx], token[idx+1:]
+
+	if !verifySignature(s.cfg, signingPurposeCSRF, payload, signature) {
+		return ErrInvalidCSRFToken
+	}
+
+	expires, _, found := strings.Cut(payload, ".")
+	if !found {
+		return ErrInvalidCSRFToken
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return ErrInvalidCSRFToken
+	}
+
+	return nil
+}
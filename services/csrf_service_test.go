@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+)
+
+func TestCSRFServiceVerifiesIssuedToken(t *testing.T) {
+	service := NewCSRFService(&config.Config{JWTSecret: testJWTSecret, CSRFTokenTTL: time.Hour})
+
+	token, expiresAt, err := service.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(expiresAt) <= 0 || time.Until(expiresAt) > time.Hour {
+		t.Errorf("expiresAt = %v", expiresAt)
+	}
+	if err := service.Verify(token); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestCSRFServiceRejectsInvalidTokens(t *testing.T) {
+	cfg := &config.Config{JWTSecret: testJWTSecret, CSRFTokenTTL: time.Hour}
+	service := NewCSRFService(cfg)
+
+	token, _, err := service.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := token[:strings.LastIndex(token, ".")]
+
+	expiredPayload := "1.nonce"
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "tampered payload", token: "9" + token},
+		{name: "raw jwt secret", token: payload + "." + hashToken(cfg.JWTSecret, "csrf:"+payload)},
+		{name: "export key", token: payload + "." + sign(cfg, signingPurposeDataExport, payload)},
+		{name: "expired", token: expiredPayload + "." + sign(cfg, signingPurposeCSRF, expiredPayload)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.Verify(tt.token); !errors.Is(err, ErrInvalidCSRFToken) {
+				t.Fatalf("ожидалась ErrInvalidCSRFToken, получено %v", err)
+			}
+		})
+	}
+}
+
+func TestCSRFTokenSurvivesKeyRotation(t *testing.T) {
+	token, _, err := NewCSRFService(&config.Config{JWTSecret: testJWTSecret, CSRFTokenTTL: time.Hour}).Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keys rotate: новый секрет, старый остается в JWT_PREVIOUS_SECRETS
+	rotated := &config.Config{
+		JWTSecret:          "fedcba9876543210fedcba9876543210",
+		JWTPreviousSecrets: []string{testJWTSecret},
+		CSRFTokenTTL:       time.Hour,
+	}
+	if err := NewCSRFService(rotated).Verify(token); err != nil {
+		t.Fatalf("токен недействителен после ротации ключа: %v", err)
+	}
+
+	// Секрет скомпрометирован и не сохранен: старые токены отклоняются
+	rotated.JWTPreviousSecrets = nil
+	if err := NewCSRFService(rotated).Verify(token); !errors.Is(err, ErrInvalidCSRFToken) {
+		t.Fatalf("ожидалась ErrInvalidCSRFToken, получено %v", err)
+	}
+}
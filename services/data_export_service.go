@@ -0,0 +1,157 @@
+// services/data_export_service.go - выгрузка персональных данных пользователя по запросу
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Ошибки выгрузки данных
+var (
+	ErrDataExportNotFound  = errors.New("выгрузка не найдена")
+	ErrInvalidDownloadLink = errors.New("недействительная или истекшая ссылка на скачивание")
+)
+
+// DataExportService интерфейс сервиса выгрузки данных пользователя
+type DataExportService interface {
+	Request(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) (*dto.DataExportResponse, error)
+	Get(ctx context.Context, userID, exportID uuid.UUID) (*dto.DataExportResponse, error)
+	Download(ctx context.Context, exportID uuid.UUID, expires, signature string, meta dto.RequestMeta) ([]byte, error)
+}
+
+// dataExportService реализация DataExportService
+type dataExportService struct {
+	exportRepo   repositories.DataExportRepository
+	auditService AuditService
+	cfg          *config.Config
+}
+
+// NewDataExportService создает новый сервис выгрузки данных
+func NewDataExportService(
+	exportRepo repositories.DataExportRepository,
+	auditService AuditService,
+	cfg *config.Config,
+) DataExportService {
+	return &dataExportService{
+		exportRepo:   exportRepo,
+		auditService: auditService,
+		cfg:          cfg,
+	}
+}
+
+// Request ставит выгрузку в очередь фонового обработчика.
+// Если выгрузка пользователя уже собирается, возвращается она.
+func (s *dataExportService) Request(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) (*dto.DataExportResponse, error) {
+	export, err := s.exportRepo.FindInProgress(ctx, userID)
+	if err == nil {
+		return toDataExportResponse(export, s.cfg), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	export = &models.DataExport{
+		UserID: userID,
+		Status: models.DataExportPending,
+	}
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditDataExportRequest, nil, &userID, map[string]interface{}{
+		"export_id": export.ID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return toDataExportResponse(export, s.cfg), nil
+}
+
+// Get возвращает состояние выгрузки пользователя
+func (s *dataExportService) Get(ctx context.Context, userID, exportID uuid.UUID) (*dto.DataExportResponse, error) {
+	export, err := s.exportRepo.FindByID(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDataExportNotFound
+		}
+		return nil, err
+	}
+
+	// Чужая выгрузка не отличается от несуществующей
+	if export.UserID != userID {
+		return nil, ErrDataExportNotFound
+	}
+
+	return toDataExportResponse(export, s.cfg), nil
+}
+
+// Download проверяет подпись и срок ссылки и возвращает ZIP архив
+func (s *dataExportService) Download(ctx context.Context, exportID uuid.UUID, expires, signature string, meta dto.RequestMeta) ([]byte, error) {
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() >= expiresUnix {
+		return nil, ErrInvalidDownloadLink
+	}
+	if !verifySignature(s.cfg, signingPurposeDataExport, dataExportSignedValue(exportID, expiresUnix), signature) {
+		return nil, ErrInvalidDownloadLink
+	}
+
+	export, err := s.exportRepo.FindByID(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidDownloadLink
+		}
+		return nil, err
+	}
+	if export.Status != models.DataExportReady {
+		return nil, ErrInvalidDownloadLink
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditDataExportDownload, &export.UserID, &export.UserID, map[string]interface{}{
+		"export_id": export.ID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return export.Archive, nil
+}
+
+// dataExportLink формирует подписанную ссылку на скачивание архива, действующую до ExpiresAt
+func dataExportLink(cfg *config.Config, export *models.DataExport) string {
+	expires := export.ExpiresAt.Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", sign(cfg, signingPurposeDataExport, dataExportSignedValue(export.ID, expires)))
+	return cfg.PublicURL + "/api/users/profile/export/" + export.ID.String() + "/download?" + query.Encode()
+}
+
+// dataExportSignedValue формирует подписываемое значение ссылки: ID выгрузки и срок действия
+func dataExportSignedValue(exportID uuid.UUID, expires int64) string {
+	return exportID.String() + ":" + strconv.FormatInt(expires, 10)
+}
+
+// toDataExportResponse преобразует выгрузку в DTO; ссылка добавляется только для готового архива
+func toDataExportResponse(export *models.DataExport, cfg *config.Config) *dto.DataExportResponse {
+	response := &dto.DataExportResponse{
+		ID:          export.ID,
+		Status:      export.Status,
+		Error:       export.Error,
+		ExpiresAt:   export.ExpiresAt,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+	}
+	if export.Status == models.DataExportReady && export.ExpiresAt != nil {
+		response.DownloadURL = dataExportLink(cfg, export)
+	}
+	return response
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+)
+
+// newReadyExport создает готовую выгрузку со ссылкой, действующей еще час
+func newReadyExport() *models.DataExport {
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	return &models.DataExport{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Status:    models.DataExportReady,
+		Archive:   []byte("PK"),
+		ExpiresAt: &expiresAt,
+	}
+}
+
+// downloadFromLink скачивает архив по параметрам подписанной ссылки
+func downloadFromLink(t *testing.T, service DataExportService, link string) ([]byte, error) {
+	t.Helper()
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportID, err := uuid.Parse(path.Base(strings.TrimSuffix(parsed.Path, "/download")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	return service.Download(context.Background(), exportID, query.Get("expires"), query.Get("signature"), dto.RequestMeta{})
+}
+
+func TestDataExportLinkDownloads(t *testing.T) {
+	export := newReadyExport()
+	audit := &fakeAuditService{}
+	cfg := &config.Config{JWTSecret: testJWTSecret, PublicURL: "https://auth.example.com"}
+	service := NewDataExportService(newFakeDataExportRepository(export), audit, cfg)
+
+	archive, err := downloadFromLink(t, service, dataExportLink(cfg, export))
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if string(archive) != "PK" {
+		t.Errorf("архив = %q", archive)
+	}
+	if actions := audit.recorded(); len(actions) != 1 || actions[0] != models.AuditDataExportDownload {
+		t.Errorf("аудит = %v", actions)
+	}
+}
+
+func TestDataExportLinkSurvivesKeyRotation(t *testing.T) {
+	export := newReadyExport()
+	cfg := &config.Config{JWTSecret: testJWTSecret, PublicURL: "https://auth.example.com"}
+	link := dataExportLink(cfg, export)
+
+	// keys rotate: новый секрет, старый остается в JWT_PREVIOUS_SECRETS
+	rotated := &config.Config{
+		JWTSecret:          "fedcba9876543210fedcba9876543210",
+		JWTPreviousSecrets: []string{testJWTSecret},
+		PublicURL:          cfg.PublicURL,
+	}
+	service := NewDataExportService(newFakeDataExportRepository(export), &fakeAuditService{}, rotated)
+	if _, err := downloadFromLink(t, service, link); err != nil {
+		t.Fatalf("ссылка недействительна после ротации ключа: %v", err)
+	}
+
+	// После удаления старого секрета ссылка перестает действовать
+	rotated.JWTPreviousSecrets = nil
+	if _, err := downloadFromLink(t, service, link); !errors.Is(err, ErrInvalidDownloadLink) {
+		t.Fatalf("ожидалась ErrInvalidDownloadLink, получено %v", err)
+	}
+}
+
+func TestDataExportRejectsForeignSignatures(t *testing.T) {
+	export := newReadyExport()
+	cfg := &config.Config{JWTSecret: testJWTSecret}
+	service := NewDataExportService(newFakeDataExportRepository(export), &fakeAuditService{}, cfg)
+
+	expires := export.ExpiresAt.Unix()
+	value := dataExportSignedValue(export.ID, expires)
+	tests := []struct {
+		name      string
+		signature string
+	}{
+		{name: "csrf key", signature: sign(cfg, signingPurposeCSRF, value)},
+		{name: "raw jwt secret", signature: hashToken(cfg.JWTSecret, value)},
+		{name: "other export", signature: sign(cfg, signingPurposeDataExport, dataExportSignedValue(uuid.New(), expires))},
+		{name: "empty", signature: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Download(context.Background(), export.ID, strconv.FormatInt(expires, 10), tt.signature, dto.RequestMeta{})
+			if !errors.Is(err, ErrInvalidDownloadLink) {
+				t.Fatalf("ожидалась ErrInvalidDownloadLink, получено %v", err)
+			}
+		})
+	}
+}
+
+func TestDataExportRejectsExpiredLink(t *testing.T) {
+	export := newReadyExport()
+	expired := time.Now().Add(-time.Minute)
+	export.ExpiresAt = &expired
+	cfg := &config.Config{JWTSecret: testJWTSecret}
+	service := NewDataExportService(newFakeDataExportRepository(export), &fakeAuditService{}, cfg)
+
+	if _, err := downloadFromLink(t, service, dataExportLink(cfg, export)); !errors.Is(err, ErrInvalidDownloadLink) {
+		t.Fatalf("ожидалась ErrInvalidDownloadLink, получено %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+// services/data_export_worker.go - фоновая сборка архивов с данными пользователей
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/mailer"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+)
+
+// Параметры обработки очереди выгрузок
+const (
+	dataExportBatchSize = 5
+	dataExportLease     = 10 * time.Minute
+)
+
+// DataExportWorker интерфейс фонового обработчика выгрузок
+type DataExportWorker interface {
+	Run(ctx context.Context)
+}
+
+// dataExportWorker реализация DataExportWorker
+type dataExportWorker struct {
+	exportRepo     repositories.DataExportRepository
+	userRepo       repositories.UserRepository
+	bookRepo       repositories.BookRepository
+	loginEventRepo repositories.LoginEventRepository
+	auditService   AuditService
+	mailer         mailer.Mailer
+	logger         *slog.Logger
+	cfg            *config.Config
+}
+
+// NewDataExportWorker создает обработчик, который собирает ZIP архивы
+// по запросам на выгрузку и отправляет пользователю ссылку на скачивание
+func NewDataExportWorker(
+	exportRepo repositories.DataExportRepository,
+	userRepo repositories.UserRepository,
+	bookRepo repositories.BookRepository,
+	loginEventRepo repositories.LoginEventRepository,
+	auditService AuditService,
+	mailer mailer.Mailer,
+	logger *slog.Logger,
+	cfg *config.Config,
+) DataExportWorker {
+	return &dataExportWorker{
+		exportRepo:     exportRepo,
+		userRepo:       userRepo,
+		bookRepo:       bookRepo,
+		loginEventRepo: loginEventRepo,
+		auditService:   auditService,
+		mailer:         mailer,
+		logger:         logger.With("worker", "data_export"),
+		cfg:            cfg,
+	}
+}
+
+// Run обрабатывает очередь с интервалом DATA_EXPORT_POLL_INTERVAL до отмены контекста
+func (w *dataExportWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.DataExportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.processPending(ctx); err != nil {
+			w.logger.ErrorContext(ctx, "Ошибка обработки очереди выгрузок", "error", err)
+		}
+		if _, err := w.exportRepo.DeleteExpired(ctx, time.Now()); err != nil {
+			w.logger.ErrorContext(ctx, "Ошибка удаления истекших архивов", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processPending собирает архивы захваченных выгрузок
+func (w *dataExportWorker) processPending(ctx context.Context) error {
+	exports, err := w.exportRepo.ClaimPending(ctx, dataExportBatchSize, dataExportLease)
+	if err != nil {
+		return err
+	}
+
+	for i := range exports {
+		if ctx.Err() != nil {
+			return nil
+		}
+		w.process(ctx, &exports[i])
+	}
+	return nil
+}
+
+// process собирает архив одной выгрузки и уведомляет пользователя
+func (w *dataExportWorker) process(ctx context.Context, export *models.DataExport) {
+	user, err := w.userRepo.FindByID(ctx, export.UserID)
+	if err != nil {
+		w.fail(ctx, export, err)
+		return
+	}
+
+	archive, err := w.buildArchive(ctx, user)
+	if err != nil {
+		w.fail(ctx, export, err)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(w.cfg.DataExportLinkTTL)
+	export.Status = models.DataExportReady
+	export.Archive = archive
+	export.Error = ""
+	export.LockedUntil = nil
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	if err := w.exportRepo.Update(ctx, export); err != nil {
+		w.logger.ErrorContext(ctx, "Ошибка сохранения выгрузки", "export_id", export.ID, "error", err)
+		return
+	}
+
+	body := fmt.Sprintf(
+		"Архив с вашими данными готов. Скачать его можно по ссылке:\n\n%s\n\n"+
+			"Ссылка действует %d ч. Если вы не запрашивали выгрузку, смените пароль.",
+		dataExportLink(w.cfg, export), int(w.cfg.DataExportLinkTTL.Hours()),
+	)
+	if err := w.mailer.Send(ctx, user.Email, "Выгрузка данных готова", body); err != nil {
+		w.logger.ErrorContext(ctx, "Ошибка отправки ссылки на выгрузку", "export_id", export.ID, "error", err)
+	}
+}
+
+// fail отмечает выгрузку неудачной. Сборка, прерванная остановкой сервера, неудачной
+// не считается: выгрузку повторно захватит обработчик после истечения аренды.
+func (w *dataExportWorker) fail(ctx context.Context, export *models.DataExport, cause error) {
+	if ctx.Err() != nil {
+		w.logger.WarnContext(ctx, "Сборка выгрузки прервана", "export_id", export.ID, "error", cause)
+		return
+	}
+
+	w.logger.ErrorContext(ctx, "Ошибка сборки выгрузки", "export_id", export.ID, "error", cause)
+
+	now := time.Now()
+	export.Status = models.DataExportFailed
+	export.Error = "Ошибка сборки архива"
+	export.LockedUntil = nil
+	export.CompletedAt = &now
+	if err := w.exportRepo.Update(ctx, export); err != nil {
+		w.logger.ErrorContext(ctx, "Ошибка сохранения выгрузки", "export_id", export.ID, "error", err)
+	}
+}
+
+// buildArchive собирает ZIP архив с JSON файлами данных пользователя
+func (w *dataExportWorker) buildArchive(ctx context.Context, user *models.User) ([]byte, error) {
+	readingRecords, err := w.bookRepo.FindReadingRecords(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	reading := make([]dto.ReadingRecordExport, 0, len(readingRecords))
+	for _, record := range readingRecords {
+		reading = append(reading, dto.ReadingRecordExport{
+			ID:           record.ID,
+			BookID:       record.BookID,
+			IsFavorite:   record.IsFavorite,
+			ReadingState: record.ReadingState,
+			ReadProgress: record.ReadProgress,
+			LastReadPage: record.LastReadPage,
+			CreatedAt:    record.CreatedAt,
+			UpdatedAt:    record.UpdatedAt,
+		})
+	}
+
+	authoredBooks, err := w.bookRepo.FindByAuthorID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	books := make([]dto.BookResponse, 0, len(authoredBooks))
+	for _, book := range authoredBooks {
+		books = append(books, dto.BookResponse{
+			ID:          book.ID,
+			Title:       book.Title,
+			AuthorID:    book.AuthorID,
+			Description: book.Description,
+			ISBN:        book.ISBN,
+			PublishYear: book.PublishYear,
+			CoverURL:    book.CoverURL,
+			FileURL:     book.FileURL,
+			Genre:       book.Genre,
+			Language:    book.Language,
+			PageCount:   book.PageCount,
+		})
+	}
+
+	loginEvents, err := w.loginEventRepo.FindAllByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	logins := make([]dto.LoginEventResponse, 0, len(loginEvents))
+	for _, event := range loginEvents {
+		logins = append(logins, dto.LoginEventResponse{
+			ID:                event.ID,
+			Success:           event.Success,
+			Method:            event.Method,
+			IP:                event.IP,
+			UserAgent:         event.UserAgent,
+			DeviceFingerprint: event.DeviceFingerprint,
+			NewDevice:         event.NewDevice,
+			CreatedAt:         event.CreatedAt,
+		})
+	}
+
+	audit := []dto.AuditEventResponse{}
+	err = w.auditService.Export(ctx, dto.AuditFilter{UserID: &user.ID}, func(event dto.AuditEventResponse) error {
+		audit = append(audit, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"reading_records.json", reading},
+		{"books.json", books},
+		{"login_history.json", logins},
+		{"audit_events.json", audit},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
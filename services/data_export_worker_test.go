@@ -0,0 +1,71 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/logging"
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+)
+
+func TestDataExportWorkerBuildsArchiveAndSendsLink(t *testing.T) {
+	user := newLocalUser(t, "alice@example.com", "secret-password")
+	users := newFakeUserRepository(user)
+	books := &fakeBookRepository{books: []models.Book{{Title: "Своя книга", AuthorID: user.ID}}}
+	logins := &fakeLoginEventRepository{events: []models.LoginEvent{{UserID: user.ID, Success: true, Method: models.LoginMethodPassword}}}
+	audit := &fakeAuditService{actions: []string{models.AuditDataExportRequest}}
+	export := &models.DataExport{ID: uuid.New(), UserID: user.ID, Status: models.DataExportProcessing}
+	exports := newFakeDataExportRepository(export)
+	mail := &fakeMailer{}
+
+	cfg := &config.Config{JWTSecret: testJWTSecret, PublicURL: "https://auth.example.com", DataExportLinkTTL: 24 * time.Hour}
+	worker := NewDataExportWorker(exports, users, books, logins, audit, mail, logging.Nop(), cfg).(*dataExportWorker)
+	worker.process(context.Background(), export)
+
+	stored, _ := exports.FindByID(context.Background(), export.ID)
+	if stored.Status != models.DataExportReady || stored.ExpiresAt == nil || len(stored.Archive) == 0 {
+		t.Fatalf("выгрузка = %+v", stored)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(stored.Archive), int64(len(stored.Archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(data)
+	}
+
+	for _, name := range []string{"profile.json", "reading_records.json", "books.json", "login_history.json", "audit_events.json"} {
+		if !json.Valid([]byte(files[name])) {
+			t.Errorf("%s отсутствует или не является JSON: %q", name, files[name])
+		}
+	}
+	if !strings.Contains(files["profile.json"], "alice@example.com") || strings.Contains(files["profile.json"], user.Password) {
+		t.Errorf("profile.json = %s", files["profile.json"])
+	}
+	if !strings.Contains(files["books.json"], "Своя книга") || !strings.Contains(files["audit_events.json"], models.AuditDataExportRequest) {
+		t.Errorf("books.json = %s, audit_events.json = %s", files["books.json"], files["audit_events.json"])
+	}
+
+	// Пользователь получает ссылку, по которой архив можно скачать
+	messages := mail.messages()
+	if len(messages) != 1 || messages[0].to != user.Email || !strings.Contains(messages[0].body, dataExportLink(cfg, stored)) {
+		t.Fatalf("письма = %+v", messages)
+	}
+}
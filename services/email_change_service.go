@@ -0,0 +1,287 @@
+// services/email_change_service.go - смена email с подтверждением на обоих адресах
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/mailer"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Ошибки смены email
+var (
+	ErrEmailTaken                      = errors.New("email уже используется")
+	ErrInvalidEmailChangeToken         = errors.New("недействительная или истекшая ссылка")
+	ErrEmailChangeNotAllowed           = errors.New("email учетной записи управляется внешним провайдером")
+	ErrEmailChangeRequiresConfirmation = errors.New("email меняется только через POST /api/users/profile/email с подтверждением")
+)
+
+// EmailChangeService интерфейс сервиса смены email
+type EmailChangeService interface {
+	RequestChange(ctx context.Context, userID uuid.UUID, req dto.EmailChangeRequest, meta dto.RequestMeta) error
+	Confirm(ctx context.Context, token string, meta dto.RequestMeta) error
+	Cancel(ctx context.Context, token string, meta dto.RequestMeta) error
+}
+
+// emailChangeService реализация EmailChangeService
+type emailChangeService struct {
+	userRepo     repositories.UserRepository
+	changeRepo   repositories.EmailChangeRepository
+	outboxRepo   repositories.OutboxRepository
+	txManager    repositories.TxManager
+	auditService AuditService
+	mailer       mailer.Mailer
+	cfg          *config.Config
+}
+
+// NewEmailChangeService создает новый сервис смены email
+func NewEmailChangeService(
+	userRepo repositories.UserRepository,
+	changeRepo repositories.EmailChangeRepository,
+	outboxRepo repositories.OutboxRepository,
+	txManager repositories.TxManager,
+	auditService AuditService,
+	mailer mailer.Mailer,
+	cfg *config.Config,
+) EmailChangeService {
+	return &emailChangeService{
+		userRepo:     userRepo,
+		changeRepo:   changeRepo,
+		outboxRepo:   outboxRepo,
+		txManager:    txManager,
+		auditService: auditService,
+		mailer:       mailer,
+		cfg:          cfg,
+	}
+}
+
+// RequestChange создает запрос на смену email, отправляет ссылку подтверждения на новый адрес
+// и уведомление со ссылкой отмены на текущий. Предыдущие неподтвержденные запросы отменяются.
+func (s *emailChangeService) RequestChange(ctx context.Context, userID uuid.UUID, req dto.EmailChangeRequest, meta dto.RequestMeta) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Email пользователей каталога и IdP приходит от провайдера при каждом входе
+	if user.AuthSource != "" && user.AuthSource != models.AuthSourceLocal {
+		return ErrEmailChangeNotAllowed
+	}
+
+	newEmail := models.NormalizeEmail(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrEmailTaken
+	}
+	if _, err := s.userRepo.FindByEmail(ctx, newEmail); err == nil {
+		return ErrEmailTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	confirmToken, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	cancelToken, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	change := &models.EmailChange{
+		UserID:           user.ID,
+		OldEmail:         user.Email,
+		NewEmail:         newEmail,
+		ConfirmTokenHash: s.hash(confirmToken),
+		CancelTokenHash:  s.hash(cancelToken),
+		RevokeSessions:   req.RevokeSessions,
+		ExpiresAt:        now.Add(s.cfg.EmailChangeTTL),
+		CancelExpiresAt:  now.Add(s.cfg.EmailChangeCancelTTL),
+	}
+
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		changes := s.changeRepo.WithTx(tx)
+		if err := changes.CancelPending(ctx, user.ID); err != nil {
+			return err
+		}
+		return changes.Create(ctx, change)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditEmailChangeRequest, nil, &user.ID, map[string]interface{}{
+		"new_email": newEmail,
+	}); err != nil {
+		return err
+	}
+
+	confirmLink := s.cfg.PublicURL + "/api/users/email/confirm?token=" + url.QueryEscape(confirmToken)
+	if err := s.mailer.Send(ctx, newEmail, "Подтверждение нового email", fmt.Sprintf(
+		"Чтобы сделать этот адрес email вашей учетной записи, перейдите по ссылке:\n\n%s\n\n"+
+			"Ссылка действует %d мин. Если вы не запрашивали смену email, просто проигнорируйте это письмо.",
+		confirmLink, int(s.cfg.EmailChangeTTL.Minutes()),
+	)); err != nil {
+		return err
+	}
+
+	cancelLink := s.cfg.PublicURL + "/api/users/email/cancel?token=" + url.QueryEscape(cancelToken)
+	return s.mailer.Send(ctx, user.Email, "Запрошена смена email", fmt.Sprintf(
+		"Для вашей учетной записи запрошена смена email на %s.\n\n"+
+			"Если это были не вы, отмените смену по ссылке (действует %d ч., в том числе после подтверждения):\n\n%s",
+		newEmail, int(s.cfg.EmailChangeCancelTTL.Hours()), cancelLink,
+	))
+}
+
+// Confirm применяет смену email по ссылке из письма на новый адрес
+func (s *emailChangeService) Confirm(ctx context.Context, token string, meta dto.RequestMeta) error {
+	if token == "" {
+		return ErrInvalidEmailChangeToken
+	}
+
+	change, err := s.changeRepo.FindPendingByConfirmHash(ctx, s.hash(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidEmailChangeToken
+		}
+		return err
+	}
+
+	now := time.Now()
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		user, err := s.userRepo.WithTx(tx).FindByID(ctx, change.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Email изменился другим способом после создания запроса
+		if user.Email != change.OldEmail {
+			return ErrInvalidEmailChangeToken
+		}
+
+		user.Email = change.NewEmail
+		columns := []string{"email"}
+		if change.RevokeSessions {
+			user.SessionsRevokedAt = &now
+			columns = append(columns, "sessions_revoked_at")
+		}
+		change.ConfirmedAt = &now
+
+		return s.applyEmail(ctx, tx, user, change, columns...)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditEmailChangeConfirm, &change.UserID, &change.UserID, map[string]interface{}{
+		"old_email": change.OldEmail,
+		"new_email": change.NewEmail,
+	}); err != nil {
+		return err
+	}
+	if change.RevokeSessions {
+		return s.auditService.Record(ctx, meta, models.AuditSessionsRevoked, &change.UserID, &change.UserID, nil)
+	}
+	return nil
+}
+
+// Cancel отменяет смену email по ссылке из письма на старый адрес.
+// Уже подтвержденная смена откатывается, а все сессии пользователя завершаются,
+// так как подтверждение мог выполнить злоумышленник.
+// Почтовые сканеры, открывающие ссылки, могут отменить смену — это безопасный исход.
+func (s *emailChangeService) Cancel(ctx context.Context, token string, meta dto.RequestMeta) error {
+	if token == "" {
+		return ErrInvalidEmailChangeToken
+	}
+
+	change, err := s.changeRepo.FindByCancelHash(ctx, s.hash(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidEmailChangeToken
+		}
+		return err
+	}
+
+	now := time.Now()
+	change.CancelledAt = &now
+
+	if change.ConfirmedAt == nil {
+		if err := s.changeRepo.Update(ctx, change); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, meta, models.AuditEmailChangeCancel, nil, &change.UserID, map[string]interface{}{
+			"new_email": change.NewEmail,
+		})
+	}
+
+	err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		user, err := s.userRepo.WithTx(tx).FindByID(ctx, change.UserID)
+		if err != nil {
+			return err
+		}
+
+		if user.Email == change.NewEmail {
+			user.Email = change.OldEmail
+		}
+		user.SessionsRevokedAt = &now
+
+		return s.applyEmail(ctx, tx, user, change, "email", "sessions_revoked_at")
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.auditService.Record(ctx, meta, models.AuditEmailChangeRevert, nil, &change.UserID, map[string]interface{}{
+		"restored_email": change.OldEmail,
+		"removed_email":  change.NewEmail,
+	}); err != nil {
+		return err
+	}
+	return s.auditService.Record(ctx, meta, models.AuditSessionsRevoked, nil, &change.UserID, nil)
+}
+
+// applyEmail сохраняет столбцы columns пользователя, запрос и событие user.updated в транзакции tx
+func (s *emailChangeService) applyEmail(ctx context.Context, tx *gorm.DB, user *models.User, change *models.EmailChange, columns ...string) error {
+	if err := s.userRepo.WithTx(tx).PatchUser(ctx, user, columns...); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+	if err := s.changeRepo.WithTx(tx).Update(ctx, change); err != nil {
+		return err
+	}
+
+	event, err := newOutboxEvent(models.EventUserUpdated, user.ID, map[string]interface{}{
+		"user": &dto.UserResponse{
+			ID:        user.ID.String(),
+			Username:  user.Username,
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Role:      user.Role,
+			Status:    user.Status,
+		},
+		"changed_fields": []string{"email"},
+	})
+	if err != nil {
+		return err
+	}
+	return s.outboxRepo.WithTx(tx).Create(ctx, event)
+}
+
+// hash вычисляет HMAC токена ссылки
+func (s *emailChangeService) hash(value string) string {
+	return hashToken(s.cfg.JWTSecret, value)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/models"
+)
+
+// emailChangeHarness сервис смены email с фейковыми зависимостями
+type emailChangeHarness struct {
+	service EmailChangeService
+	users   *fakeUserRepository
+	changes *fakeEmailChangeRepository
+	outbox  *fakeOutboxRepository
+	audit   *fakeAuditService
+	mailer  *fakeMailer
+}
+
+func newEmailChangeHarness(t *testing.T, users ...*models.User) *emailChangeHarness {
+	t.Helper()
+
+	h := &emailChangeHarness{
+		users:   newFakeUserRepository(users...),
+		changes: &fakeEmailChangeRepository{},
+		outbox:  &fakeOutboxRepository{},
+		audit:   &fakeAuditService{},
+		mailer:  &fakeMailer{},
+	}
+	cfg := &config.Config{
+		JWTSecret:            testJWTSecret,
+		PublicURL:            "https://auth.example.com",
+		EmailChangeTTL:       time.Hour,
+		EmailChangeCancelTTL: 72 * time.Hour,
+	}
+	h.service = NewEmailChangeService(h.users, h.changes, h.outbox, fakeTxManager{}, h.audit, h.mailer, cfg)
+	return h
+}
+
+var emailChangeLinkToken = regexp.MustCompile(`https://auth\.example\.com/api/users/email/(confirm|cancel)\?token=(\S+)`)
+
+// linkToken возвращает токен ссылки kind ("confirm" или "cancel") из последнего письма на адрес to
+func (h *emailChangeHarness) linkToken(t *testing.T, to, kind string) string {
+	t.Helper()
+
+	messages := h.mailer.messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].to != to {
+			continue
+		}
+		match := emailChangeLinkToken.FindStringSubmatch(messages[i].body)
+		if match == nil || match[1] != kind {
+			t.Fatalf("в письме на %s нет ссылки %s: %q", to, kind, messages[i].body)
+		}
+		token, err := url.QueryUnescape(match[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	t.Fatalf("письмо на %s не отправлено", to)
+	return ""
+}
+
+func TestEmailChangeConfirmedFromNewAddress(t *testing.T) {
+	user := newLocalUser(t, "old@example.com", "secret-password")
+	h := newEmailChangeHarness(t, user)
+	ctx := context.Background()
+
+	if err := h.service.RequestChange(ctx, user.ID, dto.EmailChangeRequest{NewEmail: "New@Example.com"}, dto.RequestMeta{}); err != nil {
+		t.Fatalf("RequestChange: %v", err)
+	}
+
+	// До подтверждения email не меняется
+	if stored, _ := h.users.FindByID(ctx, user.ID); stored.Email != "old@example.com" {
+		t.Fatalf("email изменен до подтверждения: %s", stored.Email)
+	}
+
+	confirmToken := h.linkToken(t, "new@example.com", "confirm")
+	h.linkToken(t, "old@example.com", "cancel")
+
+	if err := h.service.Confirm(ctx, confirmToken, dto.RequestMeta{}); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	stored, _ := h.users.FindByID(ctx, user.ID)
+	if stored.Email != "new@example.com" {
+		t.Errorf("email = %s", stored.Email)
+	}
+	if stored.SessionsRevokedAt != nil {
+		t.Error("сессии завершены без запроса")
+	}
+	if len(h.outbox.events) != 1 || h.outbox.events[0].EventType != models.EventUserUpdated {
+		t.Errorf("события outbox: %+v", h.outbox.events)
+	}
+
+	// Ссылка одноразовая
+	if err := h.service.Confirm(ctx, confirmToken, dto.RequestMeta{}); !errors.Is(err, ErrInvalidEmailChangeToken) {
+		t.Fatalf("ожидалась ErrInvalidEmailChangeToken, получено %v", err)
+	}
+}
+
+func TestEmailChangeCancelRevertsConfirmedChange(t *testing.T) {
+	user := newLocalUser(t, "old@example.com", "secret-password")
+	h := newEmailChangeHarness(t, user)
+	ctx := context.Background()
+
+	if err := h.service.RequestChange(ctx, user.ID, dto.EmailChangeRequest{NewEmail: "attacker@example.com"}, dto.RequestMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.service.Confirm(ctx, h.linkToken(t, "attacker@example.com", "confirm"), dto.RequestMeta{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Владелец отменяет смену по ссылке со старого адреса уже после подтверждения
+	if err := h.service.Cancel(ctx, h.linkToken(t, "old@example.com", "cancel"), dto.RequestMeta{}); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	stored, _ := h.users.FindByID(ctx, user.ID)
+	if stored.Email != "old@example.com" {
+		t.Errorf("email не восстановлен: %s", stored.Email)
+	}
+	if stored.SessionsRevokedAt == nil {
+		t.Error("сессии не завершены после отката смены")
+	}
+	if actions := h.audit.recorded(); !slices.Contains(actions, models.AuditEmailChangeRevert) || !slices.Contains(actions, models.AuditSessionsRevoked) {
+		t.Errorf("аудит = %v", actions)
+	}
+}
+
+func TestEmailChangeNewRequestSupersedesPending(t *testing.T) {
+	user := newLocalUser(t, "old@example.com", "secret-password")
+	h := newEmailChangeHarness(t, user)
+	ctx := context.Background()
+
+	if err := h.service.RequestChange(ctx, user.ID, dto.EmailChangeRequest{NewEmail: "first@example.com"}, dto.RequestMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	firstToken := h.linkToken(t, "first@example.com", "confirm")
+	if err := h.service.RequestChange(ctx, user.ID, dto.EmailChangeRequest{NewEmail: "second@example.com"}, dto.RequestMeta{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.service.Confirm(ctx, firstToken, dto.RequestMeta{}); !errors.Is(err, ErrInvalidEmailChangeToken) {
+		t.Fatalf("ожидалась ErrInvalidEmailChangeToken, получено %v", err)
+	}
+}
+
+func TestEmailChangeRejectsTakenAndExternalAccounts(t *testing.T) {
+	user := newLocalUser(t, "old@example.com", "secret-password")
+	other := newLocalUser(t, "taken@example.com", "secret-password")
+	external := newLocalUser(t, "ldap@example.com", "secret-password")
+	external.AuthSource = models.AuthSourceLDAP
+	h := newEmailChangeHarness(t, user, other, external)
+	ctx := context.Background()
+
+	err := h.service.RequestChange(ctx, user.ID, dto.EmailChangeRequest{NewEmail: "TAKEN@example.com"}, dto.RequestMeta{})
+	if !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("ожидалась ErrEmailTaken, получено %v", err)
+	}
+	err = h.service.RequestChange(ctx, external.ID, dto.EmailChangeRequest{NewEmail: "free@example.com"}, dto.RequestMeta{})
+	if !errors.Is(err, ErrEmailChangeNotAllowed) {
+		t.Errorf("ожидалась ErrEmailChangeNotAllowed, получено %v", err)
+	}
+	if messages := h.mailer.messages(); len(messages) != 0 {
+		t.Errorf("отправлены письма: %v", messages)
+	}
+}
@@ -0,0 +1,769 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeUserRepository хранит пользователей в памяти.
+// Методы, не нужные тестам, не реализованы: вызов приведет к панике.
+type fakeUserRepository struct {
+	repositories.UserRepository
+
+	mu      sync.Mutex
+	users   map[uuid.UUID]*models.User
+	patched [][]string // столбцы каждого вызова PatchUser
+	saveErr error      // ошибка, которую вернут Create и PatchUser
+}
+
+func newFakeUserRepository(users ...*models.User) *fakeUserRepository {
+	repo := &fakeUserRepository{users: map[uuid.UUID]*models.User{}}
+	for _, user := range users {
+		if user.ID == uuid.Nil {
+			user.ID = uuid.New()
+		}
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) WithTx(tx *gorm.DB) repositories.UserRepository {
+	return r
+}
+
+func (r *fakeUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepository) find(match func(user *models.User) bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if !user.DeletedAt.Valid && match(user) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// findDeleted ищет среди мягко удаленных пользователей
+func (r *fakeUserRepository) findDeleted(match func(user *models.User) bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.DeletedAt.Valid && match(user) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return strings.EqualFold(user.Email, email) })
+}
+
+func (r *fakeUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return strings.EqualFold(user.Username, username) })
+}
+
+func (r *fakeUserRepository) FindByIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	return r.find(func(user *models.User) bool {
+		return strings.EqualFold(user.Email, identifier) || strings.EqualFold(user.Username, identifier)
+	})
+}
+
+func (r *fakeUserRepository) UsernameTaken(ctx context.Context, username string, exceptID uuid.UUID) (bool, error) {
+	user, err := r.FindByUsername(ctx, username)
+	if err != nil {
+		return false, nil
+	}
+	return user.ID != exceptID, nil
+}
+
+func (r *fakeUserRepository) ScheduleDeletion(ctx context.Context, id uuid.UUID, purgeAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	now := time.Now()
+	user.DeletionScheduledFor = &purgeAt
+	user.SessionsRevokedAt = &now
+	user.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	return nil
+}
+
+func (r *fakeUserRepository) FindDeletedByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.findDeleted(func(user *models.User) bool { return user.ID == id })
+}
+
+func (r *fakeUserRepository) FindPendingDeletionByIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	return r.findDeleted(func(user *models.User) bool {
+		return user.DeletionScheduledFor.After(time.Now()) &&
+			(strings.EqualFold(user.Email, identifier) || strings.EqualFold(user.Username, identifier))
+	})
+}
+
+func (r *fakeUserRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		user.DeletionScheduledFor = nil
+		user.DeletedAt = gorm.DeletedAt{}
+	}
+	return nil
+}
+
+func (r *fakeUserRepository) FindDueForPurge(ctx context.Context, now time.Time, limit int) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []models.User
+	for _, user := range r.users {
+		if user.DeletedAt.Valid && !user.DeletionScheduledFor.After(now) && len(due) < limit {
+			due = append(due, *user)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeUserRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status, reason string, until *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	user.Status, user.StatusReason, user.StatusUntil = status, reason, until
+	return nil
+}
+
+func (r *fakeUserRepository) LiftExpiredSuspensions(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uuid.UUID
+	for _, user := range r.users {
+		if user.Status != models.UserStatusActive && user.StatusUntil != nil && !user.StatusUntil.After(now) && !user.DeletedAt.Valid {
+			user.Status, user.StatusReason, user.StatusUntil = models.UserStatusActive, "", nil
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeUserRepository) RevokeAllSessions(ctx context.Context, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, user := range r.users {
+		if !user.DeletedAt.Valid {
+			revokedAt := at
+			user.SessionsRevokedAt = &revokedAt
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeUserRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeUserRepository) PatchUser(ctx context.Context, user *models.User, columns ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	copied := *user
+	r.users[user.ID] = &copied
+	r.patched = append(r.patched, columns)
+	return nil
+}
+
+// fakeTokenRepository хранит отозванные токены в памяти
+type fakeTokenRepository struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func newFakeTokenRepository() *fakeTokenRepository {
+	return &fakeTokenRepository{revoked: map[string]time.Time{}}
+}
+
+func (r *fakeTokenRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revoked[tokenID] = expiresAt
+	return nil
+}
+
+func (r *fakeTokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.revoked[tokenID]
+	return ok, nil
+}
+
+// fakeCredentialRepository хранит ключи доступа и сессии церемоний в памяти
+type fakeCredentialRepository struct {
+	mu          sync.Mutex
+	credentials []models.Credential
+	sessions    map[uuid.UUID]models.WebAuthnSession
+}
+
+func newFakeCredentialRepository() *fakeCredentialRepository {
+	return &fakeCredentialRepository{sessions: map[uuid.UUID]models.WebAuthnSession{}}
+}
+
+func (r *fakeCredentialRepository) Create(ctx context.Context, credential *models.Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential.ID = uuid.New()
+	credential.CreatedAt = time.Now()
+	r.credentials = append(r.credentials, *credential)
+	return nil
+}
+
+func (r *fakeCredentialRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.Credential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var credentials []models.Credential
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, credential)
+		}
+	}
+	return credentials, nil
+}
+
+func (r *fakeCredentialRepository) UpdateUsage(ctx context.Context, credential *models.Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.credentials {
+		if r.credentials[i].ID == credential.ID {
+			r.credentials[i].SignCount = credential.SignCount
+			r.credentials[i].Flags = credential.Flags
+			r.credentials[i].LastUsedAt = credential.LastUsedAt
+		}
+	}
+	return nil
+}
+
+func (r *fakeCredentialRepository) Delete(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.credentials {
+		if r.credentials[i].ID == id && r.credentials[i].UserID == userID {
+			r.credentials = append(r.credentials[:i], r.credentials[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeCredentialRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	credentials, _ := r.FindByUserID(ctx, userID)
+	return int64(len(credentials)), nil
+}
+
+func (r *fakeCredentialRepository) CreateSession(ctx context.Context, session *models.WebAuthnSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = uuid.New()
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *fakeCredentialRepository) TakeSession(ctx context.Context, id uuid.UUID, purpose string) (*models.WebAuthnSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok || session.Purpose != purpose || !session.ExpiresAt.After(time.Now()) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(r.sessions, id)
+	return &session, nil
+}
+
+// fakeAuditService запоминает записанные события
+type fakeAuditService struct {
+	AuditService
+
+	mu      sync.Mutex
+	actions []string
+	details []map[string]interface{}
+}
+
+func (s *fakeAuditService) Record(ctx context.Context, meta dto.RequestMeta, action string, actorID, targetID *uuid.UUID, details map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actions = append(s.actions, action)
+	s.details = append(s.details, details)
+	return nil
+}
+
+func (s *fakeAuditService) Export(ctx context.Context, filter dto.AuditFilter, fn func(dto.AuditEventResponse) error) error {
+	for _, action := range s.recorded() {
+		if err := fn(dto.AuditEventResponse{Action: action}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeAuditService) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.actions...)
+}
+
+// fakeDataExportRepository хранит выгрузки в памяти
+type fakeDataExportRepository struct {
+	repositories.DataExportRepository
+	exports map[uuid.UUID]*models.DataExport
+}
+
+func newFakeDataExportRepository(exports ...*models.DataExport) *fakeDataExportRepository {
+	r := &fakeDataExportRepository{exports: make(map[uuid.UUID]*models.DataExport)}
+	for _, export := range exports {
+		r.exports[export.ID] = export
+	}
+	return r
+}
+
+func (r *fakeDataExportRepository) Update(ctx context.Context, export *models.DataExport) error {
+	copied := *export
+	r.exports[export.ID] = &copied
+	return nil
+}
+
+func (r *fakeDataExportRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error) {
+	export, ok := r.exports[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *export
+	return &copied, nil
+}
+
+// fakeTxManager выполняет функцию без транзакции; фейковые репозитории ее не используют
+type fakeTxManager struct{}
+
+func (fakeTxManager) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+// fakeOutboxRepository хранит события outbox в памяти
+type fakeOutboxRepository struct {
+	repositories.OutboxRepository
+	events    []models.OutboxEvent
+	processed []uuid.UUID
+}
+
+func (r *fakeOutboxRepository) WithTx(tx *gorm.DB) repositories.OutboxRepository {
+	return r
+}
+
+func (r *fakeOutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeOutboxRepository) LockUnprocessed(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	for _, event := range r.events {
+		if !slices.Contains(r.processed, event.ID) && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeOutboxRepository) MarkProcessed(ctx context.Context, ids []uuid.UUID) error {
+	r.processed = append(r.processed, ids...)
+	return nil
+}
+
+// fakeBookRepository хранит книги в памяти
+type fakeBookRepository struct {
+	repositories.BookRepository
+	books []models.Book
+}
+
+func (r *fakeBookRepository) WithTx(tx *gorm.DB) repositories.BookRepository {
+	return r
+}
+
+func (r *fakeBookRepository) FindByAuthorID(ctx context.Context, authorID uuid.UUID) ([]models.Book, error) {
+	var books []models.Book
+	for _, book := range r.books {
+		if book.AuthorID == authorID {
+			books = append(books, book)
+		}
+	}
+	return books, nil
+}
+
+func (r *fakeBookRepository) FindReadingRecords(ctx context.Context, userID uuid.UUID) ([]models.AuthorBook, error) {
+	return nil, nil
+}
+
+func (r *fakeBookRepository) Create(ctx context.Context, book *models.Book) error {
+	book.ID = uuid.New()
+	r.books = append(r.books, *book)
+	return nil
+}
+
+// fakeEmailChangeRepository хранит запросы смены email в памяти
+type fakeEmailChangeRepository struct {
+	repositories.EmailChangeRepository
+	changes []*models.EmailChange
+}
+
+func (r *fakeEmailChangeRepository) WithTx(tx *gorm.DB) repositories.EmailChangeRepository {
+	return r
+}
+
+func (r *fakeEmailChangeRepository) Create(ctx context.Context, change *models.EmailChange) error {
+	change.ID = uuid.New()
+	copied := *change
+	r.changes = append(r.changes, &copied)
+	return nil
+}
+
+func (r *fakeEmailChangeRepository) CancelPending(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	for _, change := range r.changes {
+		if change.UserID == userID && change.ConfirmedAt == nil && change.CancelledAt == nil {
+			change.CancelledAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeEmailChangeRepository) FindPendingByConfirmHash(ctx context.Context, hash string) (*models.EmailChange, error) {
+	return r.find(func(change *models.EmailChange) bool {
+		return change.ConfirmTokenHash == hash && change.ConfirmedAt == nil && change.CancelledAt == nil && change.ExpiresAt.After(time.Now())
+	})
+}
+
+func (r *fakeEmailChangeRepository) FindByCancelHash(ctx context.Context, hash string) (*models.EmailChange, error) {
+	return r.find(func(change *models.EmailChange) bool {
+		return change.CancelTokenHash == hash && change.CancelledAt == nil && change.CancelExpiresAt.After(time.Now())
+	})
+}
+
+func (r *fakeEmailChangeRepository) find(match func(*models.EmailChange) bool) (*models.EmailChange, error) {
+	for _, change := range r.changes {
+		if match(change) {
+			copied := *change
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeEmailChangeRepository) Update(ctx context.Context, change *models.EmailChange) error {
+	for i, existing := range r.changes {
+		if existing.ID == change.ID {
+			copied := *change
+			r.changes[i] = &copied
+		}
+	}
+	return nil
+}
+
+// fakeWebhookRepository хранит webhook и созданные доставки в памяти
+type fakeWebhookRepository struct {
+	repositories.WebhookRepository
+	webhooks   []models.Webhook
+	deliveries []models.WebhookDelivery
+}
+
+func (r *fakeWebhookRepository) WithTx(tx *gorm.DB) repositories.WebhookRepository {
+	return r
+}
+
+func (r *fakeWebhookRepository) FindActive(ctx context.Context) ([]models.Webhook, error) {
+	var active []models.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.Active {
+			active = append(active, webhook)
+		}
+	}
+	return active, nil
+}
+
+func (r *fakeWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, deliveries...)
+	return nil
+}
+
+// fakeLoginHistory запоминает успешные и неудачные входы
+type fakeLoginHistory struct {
+	LoginHistoryService
+
+	mu        sync.Mutex
+	successes []string
+	failures  []string
+}
+
+func (h *fakeLoginHistory) RecordSuccess(ctx context.Context, user *models.User, method string, meta dto.RequestMeta) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.successes = append(h.successes, method)
+	return nil
+}
+
+func (h *fakeLoginHistory) RecordFailure(ctx context.Context, userID uuid.UUID, method string, meta dto.RequestMeta) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures = append(h.failures, method)
+	return nil
+}
+
+// fakeLoginCodeRepository хранит одноразовые коды входа в памяти
+type fakeLoginCodeRepository struct {
+	mu    sync.Mutex
+	codes []*models.LoginCode
+}
+
+func (r *fakeLoginCodeRepository) Create(ctx context.Context, code *models.LoginCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code.ID = uuid.New()
+	code.CreatedAt = time.Now()
+	copied := *code
+	r.codes = append(r.codes, &copied)
+	return nil
+}
+
+func (r *fakeLoginCodeRepository) active(code *models.LoginCode) bool {
+	return code.UsedAt == nil && code.ExpiresAt.After(time.Now())
+}
+
+func (r *fakeLoginCodeRepository) FindActiveByHash(ctx context.Context, kind, codeHash string) (*models.LoginCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, code := range r.codes {
+		if code.Kind == kind && code.CodeHash == codeHash && r.active(code) {
+			copied := *code
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeLoginCodeRepository) FindLatestActive(ctx context.Context, userID uuid.UUID, kind string) (*models.LoginCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.codes) - 1; i >= 0; i-- {
+		if code := r.codes[i]; code.UserID == userID && code.Kind == kind && r.active(code) {
+			copied := *code
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeLoginCodeRepository) RegisterFailedAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, code := range r.codes {
+		if code.ID == id && code.UsedAt == nil {
+			code.Attempts++
+			if code.Attempts >= maxAttempts {
+				now := time.Now()
+				code.UsedAt = &now
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeLoginCodeRepository) CountIssuedSince(ctx context.Context, userID uuid.UUID, kind string, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, code := range r.codes {
+		if code.UserID == userID && code.Kind == kind && !code.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeLoginCodeRepository) Consume(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, code := range r.codes {
+		if code.ID == id && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeLoginCodeRepository) InvalidateActive(ctx context.Context, userID uuid.UUID, kind string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, code := range r.codes {
+		if code.UserID == userID && code.Kind == kind && code.UsedAt == nil {
+			code.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+// sentMail письмо, отправленное через fakeMailer
+type sentMail struct {
+	to, subject, body string
+}
+
+// fakeMailer запоминает отправленные письма
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []sentMail
+}
+
+func (m *fakeMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, sentMail{to: to, subject: subject, body: body})
+	return nil
+}
+
+func (m *fakeMailer) messages() []sentMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]sentMail(nil), m.sent...)
+}
+
+// fakeLoginEventRepository хранит события входа в памяти
+type fakeLoginEventRepository struct {
+	repositories.LoginEventRepository
+	mu     sync.Mutex
+	events []models.LoginEvent
+}
+
+func (r *fakeLoginEventRepository) Create(ctx context.Context, event *models.LoginEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeLoginEventRepository) HasSuccessfulLogin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return r.has(func(event models.LoginEvent) bool { return event.UserID == userID && event.Success }), nil
+}
+
+func (r *fakeLoginEventRepository) HasSuccessfulLoginFromDevice(ctx context.Context, userID uuid.UUID, fingerprint string) (bool, error) {
+	return r.has(func(event models.LoginEvent) bool {
+		return event.UserID == userID && event.Success && event.DeviceFingerprint == fingerprint
+	}), nil
+}
+
+func (r *fakeLoginEventRepository) FindAllByUserID(ctx context.Context, userID uuid.UUID) ([]models.LoginEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []models.LoginEvent
+	for _, event := range r.events {
+		if event.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeLoginEventRepository) has(match func(models.LoginEvent) bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.ContainsFunc(r.events, match)
+}
+
+// fakeNotifier запоминает уведомления; пока release не закрыт, отправка блокируется
+type fakeNotifier struct {
+	mu       sync.Mutex
+	release  chan struct{}
+	notified []string
+	contexts []context.Context
+	ctxErrs  []error // состояние контекста в момент отправки
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, user *models.User, subject, message string) error {
+	if n.release != nil {
+		<-n.release
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notified = append(n.notified, user.Email)
+	n.contexts = append(n.contexts, ctx)
+	n.ctxErrs = append(n.ctxErrs, ctx.Err())
+	return nil
+}
+
+func (n *fakeNotifier) sent() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.notified...)
+}
+
+// fakeSAMLAssertionRepository хранит ID использованных SAML утверждений в памяти
+type fakeSAMLAssertionRepository struct {
+	mu       sync.Mutex
+	consumed map[string]time.Time
+}
+
+func newFakeSAMLAssertionRepository() *fakeSAMLAssertionRepository {
+	return &fakeSAMLAssertionRepository{consumed: map[string]time.Time{}}
+}
+
+func (r *fakeSAMLAssertionRepository) Consume(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.consumed[id]; ok {
+		return false, nil
+	}
+	r.consumed[id] = expiresAt
+	return true, nil
+}
@@ -0,0 +1,213 @@
+// services/health_service.go - реестр проверок состояния сервиса и его зависимостей
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/migrations"
+	"gorm.io/gorm"
+)
+
+// HealthChecker проверка состояния одной зависимости
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// HealthService реестр проверок состояния.
+// Обязательные проверки определяют готовность принимать трафик (/readyz),
+// необязательные видны только в подробном отчете администратора.
+type HealthService interface {
+	Register(checker HealthChecker)
+	RegisterOptional(checker HealthChecker)
+	Ready(ctx context.Context) dto.HealthReport
+	Report(ctx context.Context) dto.HealthReport
+}
+
+// registeredCheck проверка и ее влияние на готовность
+type registeredCheck struct {
+	checker  HealthChecker
+	required bool
+}
+
+// healthService реализация HealthService
+type healthService struct {
+	mu      sync.RWMutex
+	checks  []registeredCheck
+	timeout time.Duration
+}
+
+// NewHealthService создает реестр проверок; каждая проверка ограничена timeout
+func NewHealthService(timeout time.Duration) HealthService {
+	return &healthService{timeout: timeout}
+}
+
+// Register добавляет обязательную проверку
+func (s *healthService) Register(checker HealthChecker) {
+	s.register(checker, true)
+}
+
+// RegisterOptional добавляет проверку, которая не влияет на готовность
+func (s *healthService) RegisterOptional(checker HealthChecker) {
+	s.register(checker, false)
+}
+
+// register добавляет проверку в реестр
+func (s *healthService) register(checker HealthChecker, required bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = append(s.checks, registeredCheck{checker: checker, required: required})
+}
+
+// Ready выполняет обязательные проверки
+func (s *healthService) Ready(ctx context.Context) dto.HealthReport {
+	return s.run(ctx, true)
+}
+
+// Report выполняет все проверки
+func (s *healthService) Report(ctx context.Context) dto.HealthReport {
+	return s.run(ctx, false)
+}
+
+// run выполняет проверки параллельно. Сервис готов, если прошли все обязательные проверки.
+func (s *healthService) run(ctx context.Context, requiredOnly bool) dto.HealthReport {
+	s.mu.RLock()
+	var checks []registeredCheck
+	for _, check := range s.checks {
+		if check.required || !requiredOnly {
+			checks = append(checks, check)
+		}
+	}
+	s.mu.RUnlock()
+
+	results := make([]dto.HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check registeredCheck) {
+			defer wg.Done()
+			results[i] = s.runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := dto.HealthReport{
+		Status:    dto.HealthStatusOK,
+		CheckedAt: time.Now(),
+		Checks:    results,
+	}
+	for _, result := range results {
+		if result.Required && result.Status != dto.HealthStatusOK {
+			report.Status = dto.HealthStatusFail
+		}
+	}
+	return report
+}
+
+// runCheck выполняет одну проверку с ограничением по времени.
+// Результат не ждет проверку, которая не учитывает отмену контекста.
+func (s *healthService) runCheck(ctx context.Context, check registeredCheck) dto.HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := dto.HealthCheckResult{
+		Name:      check.checker.Name(),
+		Status:    dto.HealthStatusOK,
+		Required:  check.required,
+		LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = dto.HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// healthCheck проверка, заданная функцией
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// NewHealthCheck создает проверку из функции; подсистемы регистрируют так свои зависимости
+func NewHealthCheck(name string, check func(ctx context.Context) error) HealthChecker {
+	return &healthCheck{name: name, check: check}
+}
+
+// Name возвращает имя проверки
+func (c *healthCheck) Name() string {
+	return c.name
+}
+
+// Check выполняет проверку
+func (c *healthCheck) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+// NewDatabaseHealthCheck проверяет доступность базы данных
+func NewDatabaseHealthCheck(db *gorm.DB) HealthChecker {
+	return NewHealthCheck("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// NewMigrationsHealthCheck проверяет, что все миграции этой сборки применены и не изменены.
+// Миграции из более новой сборки (состояние missing) допустимы во время поэтапного обновления.
+func NewMigrationsHealthCheck(migrator migrations.Migrator) HealthChecker {
+	return NewHealthCheck("migrations", func(ctx context.Context) error {
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		var pending, modified []string
+		for _, status := range statuses {
+			name := fmt.Sprintf("%d_%s", status.Version, status.Name)
+			switch status.State {
+			case migrations.StatePending:
+				pending = append(pending, name)
+			case migrations.StateModified:
+				modified = append(modified, name)
+			}
+		}
+
+		var errs []error
+		if len(pending) > 0 {
+			errs = append(errs, fmt.Errorf("не применены: %s", strings.Join(pending, ", ")))
+		}
+		if len(modified) > 0 {
+			errs = append(errs, fmt.Errorf("%w: %s", migrations.ErrChecksumMismatch, strings.Join(modified, ", ")))
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// NewSigningKeysHealthCheck проверяет, что ключи подписи токенов загружены и пригодны
+func NewSigningKeysHealthCheck(authService AuthService) HealthChecker {
+	return NewHealthCheck("signing_keys", func(ctx context.Context) error {
+		return authService.CheckSigningKeys(ctx)
+	})
+}
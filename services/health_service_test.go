@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/logging"
+	"AuthApplications/metrics"
+	"AuthApplications/migrations"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeMigrator возвращает заданные состояния миграций
+type fakeMigrator struct {
+	migrations.Migrator
+	statuses []migrations.MigrationStatus
+}
+
+func (m *fakeMigrator) Status() ([]migrations.MigrationStatus, error) {
+	return m.statuses, nil
+}
+
+func okCheck(name string) HealthChecker {
+	return NewHealthCheck(name, func(ctx context.Context) error { return nil })
+}
+
+func failingCheck(name string) HealthChecker {
+	return NewHealthCheck(name, func(ctx context.Context) error { return errors.New(name + " недоступен") })
+}
+
+// resultByName возвращает результат проверки по имени
+func resultByName(t *testing.T, report dto.HealthReport, name string) dto.HealthCheckResult {
+	t.Helper()
+	for _, result := range report.Checks {
+		if result.Name == name {
+			return result
+		}
+	}
+	t.Fatalf("в отчете нет проверки %s: %+v", name, report.Checks)
+	return dto.HealthCheckResult{}
+}
+
+func TestHealthServiceOptionalCheckDoesNotAffectReadiness(t *testing.T) {
+	service := NewHealthService(time.Second)
+	service.Register(okCheck("database"))
+	service.RegisterOptional(failingCheck("smtp"))
+
+	ready := service.Ready(context.Background())
+	if ready.Status != dto.HealthStatusOK || len(ready.Checks) != 1 {
+		t.Fatalf("готовность: %+v", ready)
+	}
+
+	report := service.Report(context.Background())
+	if report.Status != dto.HealthStatusOK || len(report.Checks) != 2 {
+		t.Fatalf("подробный отчет: %+v", report)
+	}
+	smtp := resultByName(t, report, "smtp")
+	if smtp.Status != dto.HealthStatusFail || smtp.Required || smtp.Error != "smtp недоступен" {
+		t.Errorf("необязательная проверка: %+v", smtp)
+	}
+}
+
+func TestHealthServiceRequiredFailureMakesServiceNotReady(t *testing.T) {
+	service := NewHealthService(time.Second)
+	service.Register(okCheck("signing_keys"))
+	service.Register(failingCheck("database"))
+
+	report := service.Ready(context.Background())
+	if report.Status != dto.HealthStatusFail {
+		t.Fatalf("сервис готов при недоступной базе: %+v", report)
+	}
+	if resultByName(t, report, "signing_keys").Status != dto.HealthStatusOK {
+		t.Error("исправная проверка отмечена как непройденная")
+	}
+}
+
+func TestHealthServiceTimesOutHangingCheck(t *testing.T) {
+	service := NewHealthService(20 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	// Проверка не учитывает отмену контекста
+	service.Register(NewHealthCheck("ldap", func(ctx context.Context) error {
+		<-release
+		return nil
+	}))
+
+	started := time.Now()
+	report := service.Ready(context.Background())
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("отчет ждал зависшую проверку %v", elapsed)
+	}
+	ldap := resultByName(t, report, "ldap")
+	if report.Status != dto.HealthStatusFail || !strings.Contains(ldap.Error, context.DeadlineExceeded.Error()) {
+		t.Errorf("зависшая проверка: %+v", ldap)
+	}
+	if ldap.LatencyMs <= 0 {
+		t.Errorf("задержка не измерена: %v", ldap.LatencyMs)
+	}
+}
+
+func TestMigrationsHealthCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string
+		want  string
+	}{
+		{name: "applied", state: migrations.StateApplied},
+		{name: "newer build", state: migrations.StateMissing},
+		{name: "pending", state: migrations.StatePending, want: "не применены: 7_books_index"},
+		{name: "modified", state: migrations.StateModified, want: migrations.ErrChecksumMismatch.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrator := &fakeMigrator{statuses: []migrations.MigrationStatus{
+				{Version: 7, Name: "books_index", State: tt.state},
+			}}
+
+			err := NewMigrationsHealthCheck(migrator).Check(context.Background())
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Check: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ожидалась ошибка %q, получено %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDatabaseHealthCheckPingsDatabase(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	mock.ExpectPing()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	if err := NewDatabaseHealthCheck(db).Check(context.Background()); err == nil {
+		t.Error("недоступная база прошла проверку")
+	}
+	mock.ExpectPing()
+	if err := NewDatabaseHealthCheck(db).Check(context.Background()); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+}
+
+func TestSigningKeysHealthCheck(t *testing.T) {
+	h := newAuthHarness(t)
+	if err := NewSigningKeysHealthCheck(h.service).Check(context.Background()); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	empty := newAuthHarness(t)
+	empty.cfg.JWTSecret = ""
+	service := NewAuthService(empty.users, empty.tokens, empty.outbox, fakeTxManager{}, empty.audit, empty.loginHistory, metrics.NewNop(), logging.Nop(), empty.cfg)
+	if err := NewSigningKeysHealthCheck(service).Check(context.Background()); err == nil {
+		t.Error("пустой секрет подписи прошел проверку")
+	}
+}
@@ -0,0 +1,168 @@
+// services/ldap_authenticator.go - аутентификация через LDAP / Active Directory
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/metrics"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapAuthenticator проверяет пароль bind-операцией в каталоге и
+// создает или обновляет локального пользователя при входе
+type ldapAuthenticator struct {
+	cfg         *config.Config
+	provisioner *externalProvisioner
+}
+
+// NewLDAPAuthenticator создает аутентификатор для LDAP-каталога
+func NewLDAPAuthenticator(
+	cfg *config.Config,
+	userRepo repositories.UserRepository,
+	outboxRepo repositories.OutboxRepository,
+	txManager repositories.TxManager,
+	auditService AuditService,
+	m metrics.Metrics,
+) Authenticator {
+	return &ldapAuthenticator{
+		cfg:         cfg,
+		provisioner: newExternalProvisioner(userRepo, outboxRepo, txManager, auditService, m),
+	}
+}
+
+// Authenticate ищет запись пользователя в каталоге, проверяет пароль и
+// синхронизирует локальную учетную запись. Идентификатор (email или имя)
+// подставляется в LDAP_USER_FILTER, например "(|(mail=%s)(uid=%s))".
+func (a *ldapAuthenticator) Authenticate(ctx context.Context, identifier, password string, meta dto.RequestMeta) (*models.User, error) {
+	// Пустой пароль приводит к анонимному bind, который сервер считает успешным
+	if identifier == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return nil, fmt.Errorf("ldap: ошибка подключения: %w", err)
+	}
+	defer conn.Close()
+
+	if a.cfg.LDAPBindDN != "" {
+		if err := conn.Bind(a.cfg.LDAPBindDN, a.cfg.LDAPBindPassword); err != nil {
+			return nil, fmt.Errorf("ldap: ошибка сервисной авторизации: %w", err)
+		}
+	}
+
+	entry, err := a.findEntry(conn, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap: ошибка проверки пароля: %w", err)
+	}
+
+	return a.provisionUser(ctx, entry, meta)
+}
+
+// dial открывает соединение с каталогом с учетом настроек TLS
+func (a *ldapAuthenticator) dial() (*ldap.Conn, error) {
+	timeout := a.cfg.LDAPTimeout
+
+	serverURL, err := url.Parse(a.cfg.LDAPURL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         serverURL.Hostname(),
+		InsecureSkipVerify: a.cfg.LDAPInsecureSkipVerify,
+	}
+
+	conn, err := ldap.DialURL(a.cfg.LDAPURL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(timeout)
+
+	if a.cfg.LDAPStartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// findEntry ищет единственную запись пользователя по фильтру из конфигурации
+func (a *ldapAuthenticator) findEntry(conn *ldap.Conn, identifier string) (*ldap.Entry, error) {
+	request := ldap.NewSearchRequest(
+		a.cfg.LDAPBaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		int(a.cfg.LDAPTimeout.Seconds()),
+		false,
+		strings.ReplaceAll(a.cfg.LDAPUserFilter, "%s", ldap.EscapeFilter(identifier)),
+		[]string{
+			a.cfg.LDAPEmailAttribute,
+			a.cfg.LDAPUsernameAttribute,
+			a.cfg.LDAPGroupAttribute,
+			"givenName",
+			"sn",
+		},
+		nil,
+	)
+
+	result, err := conn.Search(request)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap: ошибка поиска пользователя: %w", err)
+	}
+
+	// Неоднозначный результат считаем отказом, а не выбираем первую запись
+	if len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	return result.Entries[0], nil
+}
+
+// provisionUser создает локального пользователя при первом входе или обновляет существующего
+func (a *ldapAuthenticator) provisionUser(ctx context.Context, entry *ldap.Entry, meta dto.RequestMeta) (*models.User, error) {
+	email := entry.GetEqualFoldAttributeValue(a.cfg.LDAPEmailAttribute)
+	if email == "" {
+		return nil, errors.New("ldap: у записи каталога отсутствует email")
+	}
+
+	return a.provisioner.provision(ctx, externalProfile{
+		Source:    models.AuthSourceLDAP,
+		Email:     email,
+		Username:  entry.GetEqualFoldAttributeValue(a.cfg.LDAPUsernameAttribute),
+		FirstName: entry.GetEqualFoldAttributeValue("givenName"),
+		LastName:  entry.GetEqualFoldAttributeValue("sn"),
+		Role:      a.mapRole(entry.GetEqualFoldAttributeValues(a.cfg.LDAPGroupAttribute)),
+	}, meta)
+}
+
+// mapRole выбирает роль по первому совпавшему правилу LDAP_GROUP_ROLES
+func (a *ldapAuthenticator) mapRole(groups []string) string {
+	return mapGroupsToRole(groups, a.cfg.LDAPGroupRoles, a.cfg.LDAPDefaultRole)
+}
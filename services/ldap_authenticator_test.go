@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/metrics"
+	"AuthApplications/models"
+
+	"gorm.io/gorm"
+)
+
+const ldapTestPassword = "directory-password"
+
+// ldapAlice запись каталога администратора с группой, которой сопоставлена роль
+func ldapAlice() ldapEntry {
+	return ldapEntry{
+		dn:       "uid=alice,ou=people,dc=example,dc=com",
+		password: ldapTestPassword,
+		attributes: map[string][]string{
+			"mail":      {"alice@example.com"},
+			"uid":       {"alice"},
+			"givenName": {"Alice"},
+			"sn":        {"Liddell"},
+			"memberOf":  {"cn=admins,ou=groups,dc=example,dc=com"},
+		},
+	}
+}
+
+// newLDAPTestAuthenticator создает аутентификатор, настроенный на заглушку каталога
+func newLDAPTestAuthenticator(stub *ldapStub, users *fakeUserRepository) Authenticator {
+	return NewLDAPAuthenticator(ldapTestConfig(stub), users, &fakeOutboxRepository{}, fakeTxManager{}, &fakeAuditService{}, metrics.NewNop())
+}
+
+// ldapTestConfig настройки LDAP для заглушки каталога
+func ldapTestConfig(stub *ldapStub) *config.Config {
+	return &config.Config{
+		LDAPEnabled:           true,
+		LDAPURL:               stub.url(),
+		LDAPBaseDN:            "dc=example,dc=com",
+		LDAPUserFilter:        "(|(mail=%s)(uid=%s))",
+		LDAPEmailAttribute:    "mail",
+		LDAPUsernameAttribute: "uid",
+		LDAPGroupAttribute:    "memberOf",
+		LDAPGroupRoles: []config.GroupRole{
+			{Group: "cn=admins,ou=groups,dc=example,dc=com", Role: "admin"},
+		},
+		LDAPDefaultRole: "user",
+		LDAPTimeout:     5 * time.Second,
+	}
+}
+
+func TestLDAPAuthenticatorProvisionsUserOnFirstLogin(t *testing.T) {
+	stub := newLDAPStub(t, ldapAlice())
+	users := newFakeUserRepository()
+
+	user, err := newLDAPTestAuthenticator(stub, users).Authenticate(context.Background(), "alice", ldapTestPassword, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	if user.Email != "alice@example.com" || user.Username != "alice" || user.FirstName != "Alice" || user.LastName != "Liddell" {
+		t.Errorf("профиль не перенесен из каталога: %+v", user)
+	}
+	if user.AuthSource != models.AuthSourceLDAP || user.Role != "admin" {
+		t.Errorf("auth_source = %q, role = %q", user.AuthSource, user.Role)
+	}
+
+	// Пароль каталога локально не сохраняется и не подходит для локального входа
+	if user.Password != externalPasswordHash {
+		t.Errorf("password = %q, ожидалась метка внешней учетной записи", user.Password)
+	}
+	if user.CheckPassword(ldapTestPassword) == nil {
+		t.Error("пароль каталога принят локальной проверкой")
+	}
+
+	if !slices.Contains(stub.bound(), "uid=alice,ou=people,dc=example,dc=com") {
+		t.Errorf("bind под записью пользователя не выполнен: %v", stub.bound())
+	}
+}
+
+func TestLDAPAuthenticatorRejectsWrongPassword(t *testing.T) {
+	stub := newLDAPStub(t, ldapAlice())
+	users := newFakeUserRepository()
+
+	_, err := newLDAPTestAuthenticator(stub, users).Authenticate(context.Background(), "alice", "wrong", dto.RequestMeta{})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("ожидалась ErrInvalidCredentials, получено %v", err)
+	}
+	if len(users.users) != 0 {
+		t.Error("пользователь создан после неудачного bind")
+	}
+}
+
+func TestLDAPAuthenticatorRejectsAmbiguousEntry(t *testing.T) {
+	other := ldapAlice()
+	other.dn = "uid=alice,ou=contractors,dc=example,dc=com"
+	stub := newLDAPStub(t, ldapAlice(), other)
+
+	_, err := newLDAPTestAuthenticator(stub, newFakeUserRepository()).Authenticate(context.Background(), "alice", ldapTestPassword, dto.RequestMeta{})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("ожидалась ErrInvalidCredentials, получено %v", err)
+	}
+}
+
+func TestLDAPAuthenticatorUsesServiceBind(t *testing.T) {
+	stub := newLDAPStub(t, ldapAlice())
+	stub.bindDN = "cn=reader,dc=example,dc=com"
+	stub.bindPass = "reader-password"
+
+	authenticator := newLDAPTestAuthenticator(stub, newFakeUserRepository()).(*ldapAuthenticator)
+	authenticator.cfg.LDAPBindDN = stub.bindDN
+	authenticator.cfg.LDAPBindPassword = stub.bindPass
+
+	if _, err := authenticator.Authenticate(context.Background(), "alice@example.com", ldapTestPassword, dto.RequestMeta{}); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	binds := stub.bound()
+	if len(binds) != 2 || binds[0] != stub.bindDN || binds[1] != "uid=alice,ou=people,dc=example,dc=com" {
+		t.Errorf("bind-запросы = %v", binds)
+	}
+}
+
+func TestLDAPAuthenticatorUpdatesExistingUserColumns(t *testing.T) {
+	stub := newLDAPStub(t, ldapAlice())
+	existing := &models.User{
+		Email:      "alice@example.com",
+		Username:   "alice",
+		FirstName:  "Old",
+		Role:       "user",
+		AuthSource: models.AuthSourceLDAP,
+	}
+	existing.SetPasswordHash(externalPasswordHash)
+	users := newFakeUserRepository(existing)
+
+	user, err := newLDAPTestAuthenticator(stub, users).Authenticate(context.Background(), "alice", ldapTestPassword, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.ID != existing.ID || user.FirstName != "Alice" || user.Role != "admin" {
+		t.Errorf("данные пользователя не обновлены: %+v", user)
+	}
+
+	// Повторный вход обновляет только данные из каталога
+	want := []string{"username", "first_name", "last_name", "role"}
+	if len(users.patched) != 1 || !slices.Equal(users.patched[0], want) {
+		t.Errorf("PatchUser вызван со столбцами %v", users.patched)
+	}
+}
+
+func TestLDAPAuthenticatorDoesNotTakeOverLocalAccount(t *testing.T) {
+	stub := newLDAPStub(t, ldapAlice())
+	local := newLocalUser(t, "alice@example.com", "local-password")
+	local.AuthSource = models.AuthSourceLocal
+	users := newFakeUserRepository(local)
+
+	_, err := newLDAPTestAuthenticator(stub, users).Authenticate(context.Background(), "alice", ldapTestPassword, dto.RequestMeta{})
+	if !errors.Is(err, ErrExternalAccountConflict) {
+		t.Fatalf("ожидалась ErrExternalAccountConflict, получено %v", err)
+	}
+	if len(users.patched) != 0 {
+		t.Error("локальная учетная запись изменена входом через каталог")
+	}
+}
+
+func TestLDAPAuthenticatorDropsInvalidUsername(t *testing.T) {
+	entry := ldapAlice()
+	entry.attributes["uid"] = []string{"alice smith!"}
+	entry.attributes["mail"] = []string{"alice@example.com"}
+	stub := newLDAPStub(t, entry)
+
+	user, err := newLDAPTestAuthenticator(stub, newFakeUserRepository()).Authenticate(context.Background(), "alice@example.com", ldapTestPassword, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Username != "" {
+		t.Errorf("недопустимое имя из каталога сохранено: %q", user.Username)
+	}
+}
+
+func TestLDAPAuthenticatorDropsUsernameTakenByAnotherUser(t *testing.T) {
+	stub := newLDAPStub(t, ldapAlice())
+	other := newLocalUser(t, "someone@example.com", "local-password")
+	other.Username = "Alice"
+	users := newFakeUserRepository(other)
+
+	user, err := newLDAPTestAuthenticator(stub, users).Authenticate(context.Background(), "alice@example.com", ldapTestPassword, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Username != "" {
+		t.Errorf("занятое имя назначено второму пользователю: %q", user.Username)
+	}
+}
+
+func TestLDAPAuthenticatorMapsUniqueViolationToConflict(t *testing.T) {
+	stub := newLDAPStub(t, ldapAlice())
+	users := newFakeUserRepository()
+	// Параллельный первый вход успел создать пользователя с тем же email
+	users.saveErr = gorm.ErrDuplicatedKey
+
+	_, err := newLDAPTestAuthenticator(stub, users).Authenticate(context.Background(), "alice", ldapTestPassword, dto.RequestMeta{})
+	if !errors.Is(err, ErrExternalAccountConflict) {
+		t.Fatalf("ожидалась ErrExternalAccountConflict, получено %v", err)
+	}
+}
+
+func TestLDAPAuthenticatorRecordsProvisioningEvents(t *testing.T) {
+	stub := newLDAPStub(t, ldapAlice())
+	users := newFakeUserRepository()
+	outbox := &fakeOutboxRepository{}
+	audit := &fakeAuditService{}
+	cfg := ldapTestConfig(stub)
+	authenticator := NewLDAPAuthenticator(cfg, users, outbox, fakeTxManager{}, audit, metrics.NewNop())
+	ctx := context.Background()
+
+	user, err := authenticator.Authenticate(ctx, "alice", ldapTestPassword, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if len(outbox.events) != 1 || outbox.events[0].EventType != models.EventUserRegistered || outbox.events[0].AggregateID != user.ID {
+		t.Fatalf("события outbox после первого входа = %+v", outbox.events)
+	}
+	if !slices.Equal(audit.recorded(), []string{models.AuditRegister}) {
+		t.Fatalf("журнал аудита после первого входа = %v", audit.recorded())
+	}
+
+	// Повторный вход без изменений в каталоге ничего не записывает
+	if _, err := authenticator.Authenticate(ctx, "alice", ldapTestPassword, dto.RequestMeta{}); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if len(outbox.events) != 1 || len(users.patched) != 0 || len(audit.recorded()) != 1 {
+		t.Fatalf("вход без изменений записал события %+v, столбцы %v, аудит %v", outbox.events, users.patched, audit.recorded())
+	}
+
+	// Пользователя убрали из группы администраторов
+	stub.mu.Lock()
+	stub.entries[0].attributes["memberOf"] = nil
+	stub.mu.Unlock()
+	if _, err := authenticator.Authenticate(ctx, "alice", ldapTestPassword, dto.RequestMeta{}); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if len(outbox.events) != 2 || outbox.events[1].EventType != models.EventUserUpdated {
+		t.Fatalf("события outbox после смены роли = %+v", outbox.events)
+	}
+	if !slices.Equal(audit.recorded(), []string{models.AuditRegister, models.AuditRoleChange}) {
+		t.Fatalf("журнал аудита после смены роли = %v", audit.recorded())
+	}
+	if details := audit.details[1]; details["from"] != "admin" || details["to"] != cfg.LDAPDefaultRole {
+		t.Errorf("детали смены роли = %v", details)
+	}
+}
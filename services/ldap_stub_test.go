@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapEntry запись каталога в заглушке LDAP
+type ldapEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// ldapStub минимальный LDAP-сервер в процессе теста: поддерживает simple bind,
+// поиск и unbind. Фильтр не вычисляется полностью: запись подходит, если фильтр
+// содержит условие (атрибут=значение) с одним из ее значений.
+type ldapStub struct {
+	t        *testing.T
+	listener net.Listener
+	bindDN   string
+	bindPass string
+
+	mu      sync.Mutex
+	entries []ldapEntry
+	binds   []string
+	filters []string
+}
+
+func newLDAPStub(t *testing.T, entries ...ldapEntry) *ldapStub {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &ldapStub{t: t, listener: listener, entries: entries}
+	t.Cleanup(func() { listener.Close() })
+
+	go stub.serve()
+	return stub
+}
+
+// url возвращает адрес заглушки для LDAP_URL
+func (s *ldapStub) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *ldapStub) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *ldapStub) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		if len(packet.Children) < 2 {
+			return
+		}
+		messageID, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := op.Children[1].Data.String()
+			password := op.Children[2].Data.String()
+			s.write(conn, messageID, ldapResult(ldap.ApplicationBindResponse, s.bind(dn, password)))
+
+		case ldap.ApplicationSearchRequest:
+			filter, err := ldap.DecompileFilter(op.Children[6])
+			if err != nil {
+				s.write(conn, messageID, ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError))
+				continue
+			}
+			for _, entry := range s.search(filter) {
+				s.write(conn, messageID, searchEntry(entry))
+			}
+			s.write(conn, messageID, ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+
+		case ldap.ApplicationUnbindRequest:
+			return
+
+		default:
+			return
+		}
+	}
+}
+
+// bind проверяет пароль сервисной учетной записи или записи каталога
+func (s *ldapStub) bind(dn, password string) uint16 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.binds = append(s.binds, dn)
+
+	if s.bindDN != "" && dn == s.bindDN && password == s.bindPass {
+		return ldap.LDAPResultSuccess
+	}
+	for _, entry := range s.entries {
+		if entry.dn == dn && entry.password == password && password != "" {
+			return ldap.LDAPResultSuccess
+		}
+	}
+	return ldap.LDAPResultInvalidCredentials
+}
+
+// search возвращает записи, значение атрибута которых упомянуто в фильтре
+func (s *ldapStub) search(filter string) []ldapEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filters = append(s.filters, filter)
+
+	var found []ldapEntry
+	for _, entry := range s.entries {
+		if entryMatches(entry, filter) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+func entryMatches(entry ldapEntry, filter string) bool {
+	for name, values := range entry.attributes {
+		for _, value := range values {
+			if strings.Contains(strings.ToLower(filter), strings.ToLower("("+name+"="+ldap.EscapeFilter(value)+")")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bound возвращает DN всех выполненных bind-запросов
+func (s *ldapStub) bound() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.binds...)
+}
+
+func (s *ldapStub) write(conn net.Conn, messageID int64, op *ber.Packet) {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "Message ID"))
+	envelope.AppendChild(op)
+	if _, err := conn.Write(envelope.Bytes()); err != nil && !errors.Is(err, net.ErrClosed) {
+		s.t.Logf("ldap stub: %v", err)
+	}
+}
+
+// ldapResult формирует LDAPResult с кодом code
+func ldapResult(tag ber.Tag, code uint16) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return op
+}
+
+// searchEntry формирует SearchResultEntry для записи каталога
+func searchEntry(entry ldapEntry) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "DN"))
+
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	op.AppendChild(attributes)
+	return op
+}
@@ -0,0 +1,174 @@
+// services/login_history_service.go - история входов и оповещения о новых устройствах
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/notifier"
+	"AuthApplications/repositories"
+
+	"github.com/google/uuid"
+)
+
+// newDeviceNotifyTimeout ограничивает отправку уведомления о новом устройстве
+const newDeviceNotifyTimeout = 30 * time.Second
+
+// LoginHistoryService интерфейс сервиса истории входов
+type LoginHistoryService interface {
+	RecordSuccess(ctx context.Context, user *models.User, method string, meta dto.RequestMeta) error
+	RecordFailure(ctx context.Context, userID uuid.UUID, method string, meta dto.RequestMeta) error
+	List(ctx context.Context, userID uuid.UUID, query dto.LoginHistoryQuery) (*dto.LoginHistoryResponse, error)
+}
+
+// loginHistoryService реализация LoginHistoryService
+type loginHistoryService struct {
+	loginEventRepo repositories.LoginEventRepository
+	notifier       notifier.Notifier
+	notifications  *sync.WaitGroup
+	logger         *slog.Logger
+}
+
+// NewLoginHistoryService создает новый сервис истории входов.
+// Уведомления отправляются в фоне и учитываются в notifications, чтобы при остановке
+// сервер мог дождаться их отправки.
+func NewLoginHistoryService(
+	loginEventRepo repositories.LoginEventRepository,
+	n notifier.Notifier,
+	notifications *sync.WaitGroup,
+	logger *slog.Logger,
+) LoginHistoryService {
+	return &loginHistoryService{
+		loginEventRepo: loginEventRepo,
+		notifier:       n,
+		notifications:  notifications,
+		logger:         logger,
+	}
+}
+
+// RecordSuccess сохраняет успешный вход и уведомляет пользователя,
+// если вход выполнен с устройства, которого раньше не было в истории
+func (s *loginHistoryService) RecordSuccess(ctx context.Context, user *models.User, method string, meta dto.RequestMeta) error {
+	fingerprint := deviceFingerprint(meta.UserAgent, meta.IP)
+
+	seenBefore, err := s.loginEventRepo.HasSuccessfulLogin(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	knownDevice, err := s.loginEventRepo.HasSuccessfulLoginFromDevice(ctx, user.ID, fingerprint)
+	if err != nil {
+		return err
+	}
+
+	// Первый вход в учетную запись не считается входом с нового устройства
+	event := &models.LoginEvent{
+		UserID:            user.ID,
+		Success:           true,
+		Method:            method,
+		IP:                meta.IP,
+		UserAgent:         meta.UserAgent,
+		DeviceFingerprint: fingerprint,
+		NewDevice:         seenBefore && !knownDevice,
+	}
+	if err := s.loginEventRepo.Create(ctx, event); err != nil {
+		return err
+	}
+
+	if event.NewDevice {
+		// Уведомление не должно задерживать или срывать вход: оно отправляется в фоне
+		// и не отменяется вместе с запросом, но ограничено newDeviceNotifyTimeout
+		notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), newDeviceNotifyTimeout)
+		s.notifications.Add(1)
+		go func(user models.User, event models.LoginEvent) {
+			defer s.notifications.Done()
+			defer cancel()
+			s.notifyNewDevice(notifyCtx, user, event)
+		}(*user, *event)
+	}
+	return nil
+}
+
+// RecordFailure сохраняет неудачную попытку входа
+func (s *loginHistoryService) RecordFailure(ctx context.Context, userID uuid.UUID, method string, meta dto.RequestMeta) error {
+	return s.loginEventRepo.Create(ctx, &models.LoginEvent{
+		UserID:            userID,
+		Success:           false,
+		Method:            method,
+		IP:                meta.IP,
+		UserAgent:         meta.UserAgent,
+		DeviceFingerprint: deviceFingerprint(meta.UserAgent, meta.IP),
+	})
+}
+
+// List возвращает страницу истории входов пользователя
+func (s *loginHistoryService) List(ctx context.Context, userID uuid.UUID, query dto.LoginHistoryQuery) (*dto.LoginHistoryResponse, error) {
+	events, total, err := s.loginEventRepo.FindByUserID(ctx, userID, query.Page, query.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.LoginEventResponse, 0, len(events))
+	for _, event := range events {
+		items = append(items, dto.LoginEventResponse{
+			ID:                event.ID,
+			Success:           event.Success,
+			Method:            event.Method,
+			IP:                event.IP,
+			UserAgent:         event.UserAgent,
+			DeviceFingerprint: event.DeviceFingerprint,
+			NewDevice:         event.NewDevice,
+			CreatedAt:         event.CreatedAt,
+		})
+	}
+
+	return &dto.LoginHistoryResponse{
+		Items:    items,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
+
+// notifyNewDevice отправляет пользователю уведомление о входе с нового устройства
+func (s *loginHistoryService) notifyNewDevice(ctx context.Context, user models.User, event models.LoginEvent) {
+	message := fmt.Sprintf(
+		"В вашу учетную запись выполнен вход с нового устройства.\n\n"+
+			"Время: %s\nIP адрес: %s\nУстройство: %s\n\n"+
+			"Если это были не вы, смените пароль и проверьте историю входов в профиле.",
+		event.CreatedAt.UTC().Format(time.RFC1123), event.IP, event.UserAgent,
+	)
+
+	if err := s.notifier.Notify(ctx, &user, "Вход с нового устройства", message); err != nil {
+		s.logger.ErrorContext(ctx, "Ошибка отправки уведомления о новом устройстве", "account_id", user.ID, "error", err)
+	}
+}
+
+// deviceFingerprint вычисляет отпечаток устройства по User-Agent и сети клиента.
+// User-Agent легко подделать, поэтому в отпечаток входит префикс IP адреса (/24 для IPv4,
+// /48 для IPv6): вход с того же браузера из другой сети тоже считается новым устройством,
+// а смена адреса внутри сети провайдера или офиса — нет.
+func deviceFingerprint(userAgent, ip string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(userAgent)) + "\n" + networkPrefix(ip)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// networkPrefix возвращает сеть, к которой относится IP адрес; для некорректного адреса — пустую строку
+func networkPrefix(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/logging"
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+)
+
+const firefoxUserAgent = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+
+func TestLoginHistoryNotifiesNewDeviceInTrackedBackground(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "alice@example.com"}
+	events := &fakeLoginEventRepository{}
+	n := &fakeNotifier{release: make(chan struct{})}
+	var notifications sync.WaitGroup
+	service := NewLoginHistoryService(events, n, &notifications, logging.Nop())
+
+	if err := service.RecordSuccess(context.Background(), user, models.LoginMethodPassword, dto.RequestMeta{IP: "192.0.2.10", UserAgent: firefoxUserAgent}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Вход с другого устройства после отмены запроса: уведомление не должно отмениться вместе с ним
+	requestCtx, cancelRequest := context.WithCancel(context.Background())
+	if err := service.RecordSuccess(requestCtx, user, models.LoginMethodPassword, dto.RequestMeta{IP: "198.51.100.7", UserAgent: firefoxUserAgent}); err != nil {
+		t.Fatal(err)
+	}
+	cancelRequest()
+
+	// Пока уведомление не отправлено, WaitGroup не должна освобождаться
+	done := make(chan struct{})
+	go func() {
+		notifications.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("WaitGroup освобождена до отправки уведомления")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(n.release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("уведомление не завершилось")
+	}
+
+	if notified := n.sent(); len(notified) != 1 || notified[0] != user.Email {
+		t.Fatalf("уведомления = %v", notified)
+	}
+	if err := n.ctxErrs[0]; err != nil {
+		t.Errorf("контекст уведомления отменен вместе с запросом: %v", err)
+	}
+	if deadline, ok := n.contexts[0].Deadline(); !ok || time.Until(deadline) > newDeviceNotifyTimeout {
+		t.Errorf("у контекста уведомления нет ограничения по времени: %v, %v", deadline, ok)
+	}
+}
+
+func TestLoginHistoryDoesNotNotifyKnownDevice(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "alice@example.com"}
+	n := &fakeNotifier{}
+	var notifications sync.WaitGroup
+	service := NewLoginHistoryService(&fakeLoginEventRepository{}, n, &notifications, logging.Nop())
+
+	// Смена адреса внутри той же сети не считается новым устройством
+	for _, ip := range []string{"192.0.2.10", "192.0.2.200"} {
+		if err := service.RecordSuccess(context.Background(), user, models.LoginMethodPassword, dto.RequestMeta{IP: ip, UserAgent: firefoxUserAgent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notifications.Wait()
+
+	if notified := n.sent(); len(notified) != 0 {
+		t.Errorf("лишние уведомления: %v", notified)
+	}
+}
+
+func TestDeviceFingerprintIncludesNetworkPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		sameHash bool
+	}{
+		{name: "ipv4 same /24", a: "192.0.2.10", b: "192.0.2.250", sameHash: true},
+		{name: "ipv4 other /24", a: "192.0.2.10", b: "192.0.3.10", sameHash: false},
+		{name: "ipv6 same /48", a: "2001:db8:1::1", b: "2001:db8:1:ffff::2", sameHash: true},
+		{name: "ipv6 other /48", a: "2001:db8:1::1", b: "2001:db8:2::1", sameHash: false},
+		{name: "ipv4-mapped ipv6", a: "::ffff:192.0.2.10", b: "192.0.2.99", sameHash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			same := deviceFingerprint(firefoxUserAgent, tt.a) == deviceFingerprint(firefoxUserAgent, tt.b)
+			if same != tt.sameHash {
+				t.Errorf("совпадение отпечатков %s и %s = %v, ожидалось %v", tt.a, tt.b, same, tt.sameHash)
+			}
+		})
+	}
+
+	if deviceFingerprint(firefoxUserAgent, "192.0.2.10") == deviceFingerprint("curl/8.0", "192.0.2.10") {
+		t.Error("отпечаток не зависит от User-Agent")
+	}
+}
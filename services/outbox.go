@@ -0,0 +1,42 @@
+// services/outbox.go - формирование доменных событий для transactional outbox
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+)
+
+// outboxEnvelope формат тела события, доставляемого на webhook
+type outboxEnvelope struct {
+	ID         uuid.UUID   `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// newOutboxEvent формирует событие для записи в outbox в одной транзакции с изменением данных
+func newOutboxEvent(eventType string, aggregateID uuid.UUID, data interface{}) (*models.OutboxEvent, error) {
+	event := &models.OutboxEvent{
+		ID:          uuid.New(),
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		CreatedAt:   time.Now(),
+	}
+
+	payload, err := json.Marshal(outboxEnvelope{
+		ID:         event.ID,
+		Type:       eventType,
+		OccurredAt: event.CreatedAt,
+		Data:       data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	event.Payload = string(payload)
+
+	return event, nil
+}
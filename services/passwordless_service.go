@@ -0,0 +1,258 @@
+// services/passwordless_service.go - вход по ссылке из письма и одноразовому коду
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/mailer"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidLoginCode возвращается для неверного, истекшего, использованного или чужого кода
+var ErrInvalidLoginCode = errors.New("недействительный или истекший код входа")
+
+// PasswordlessService интерфейс сервиса входа без пароля
+type PasswordlessService interface {
+	RequestMagicLink(ctx context.Context, email, deviceID string) error
+	RedeemMagicLink(ctx context.Context, token, deviceID string, meta dto.RequestMeta) (*dto.AuthResponse, error)
+	RequestOTP(ctx context.Context, email, deviceID string) error
+	RedeemOTP(ctx context.Context, email, code, deviceID string, meta dto.RequestMeta) (*dto.AuthResponse, error)
+}
+
+// passwordlessService реализация PasswordlessService
+type passwordlessService struct {
+	userRepo    repositories.UserRepository
+	codeRepo    repositories.LoginCodeRepository
+	authService AuthService
+	mailer      mailer.Mailer
+	cfg         *config.Config
+}
+
+// NewPasswordlessService создает новый сервис входа без пароля
+func NewPasswordlessService(
+	userRepo repositories.UserRepository,
+	codeRepo repositories.LoginCodeRepository,
+	authService AuthService,
+	mailer mailer.Mailer,
+	cfg *config.Config,
+) PasswordlessService {
+	return &passwordlessService{
+		userRepo:    userRepo,
+		codeRepo:    codeRepo,
+		authService: authService,
+		mailer:      mailer,
+		cfg:         cfg,
+	}
+}
+
+// RequestMagicLink отправляет одноразовую ссылку для входа.
+// Для неизвестного email ошибка не возвращается, чтобы не раскрывать наличие учетной записи.
+// Как и для кодов, число писем ограничено OTPRequestLimit за OTPRequestWindow.
+func (s *passwordlessService) RequestMagicLink(ctx context.Context, email, deviceID string) error {
+	user, err := s.findLocalUser(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	if limited, err := s.requestLimitReached(ctx, user, models.LoginCodeMagicLink); err != nil || limited {
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	if err := s.issueCode(ctx, user, models.LoginCodeMagicLink, token, deviceID, s.cfg.MagicLinkTTL); err != nil {
+		return err
+	}
+
+	link := s.cfg.PublicURL + "/api/auth/magic-link/verify?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf(
+		"Для входа перейдите по ссылке:\n\n%s\n\nСсылка действует %d мин. и откроется только в том браузере, где был запрошен вход.\n"+
+			"Если вы не запрашивали вход, просто проигнорируйте это письмо.",
+		link, int(s.cfg.MagicLinkTTL.Minutes()),
+	)
+	return s.mailer.Send(ctx, user.Email, "Ссылка для входа", body)
+}
+
+// RedeemMagicLink проверяет ссылку и выдает JWT токен
+func (s *passwordlessService) RedeemMagicLink(ctx context.Context, token, deviceID string, meta dto.RequestMeta) (*dto.AuthResponse, error) {
+	if token == "" || deviceID == "" {
+		return nil, ErrInvalidLoginCode
+	}
+
+	code, err := s.codeRepo.FindActiveByHash(ctx, models.LoginCodeMagicLink, s.hash(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidLoginCode
+		}
+		return nil, err
+	}
+
+	// Ссылка, открытая на другом устройстве (или почтовым сканером), не расходуется
+	if !hmac.Equal([]byte(code.DeviceHash), []byte(s.hash(deviceID))) {
+		if err := s.codeRepo.RegisterFailedAttempt(ctx, code.ID, s.cfg.OTPMaxAttempts); err != nil {
+			return nil, err
+		}
+		if user, err := s.userRepo.FindByID(ctx, code.UserID); err == nil {
+			if err := s.authService.RecordLoginFailure(ctx, user, models.LoginMethodMagicLink, ErrInvalidLoginCode, meta); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidLoginCode
+	}
+
+	return s.redeem(ctx, code, models.LoginMethodMagicLink, meta)
+}
+
+// RequestOTP отправляет шестизначный одноразовый код на email.
+// Новый код отзывает предыдущий вместе с его счетчиком попыток, поэтому выпуск кодов
+// ограничен OTPRequestLimit за OTPRequestWindow. Сверх лимита код не отправляется,
+// а ответ не отличается от обычного, чтобы не раскрывать наличие учетной записи.
+func (s *passwordlessService) RequestOTP(ctx context.Context, email, deviceID string) error {
+	user, err := s.findLocalUser(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	if limited, err := s.requestLimitReached(ctx, user, models.LoginCodeOTP); err != nil || limited {
+		return err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+	otp := fmt.Sprintf("%06d", n.Int64())
+
+	if err := s.issueCode(ctx, user, models.LoginCodeOTP, otp, deviceID, s.cfg.OTPTTL); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Ваш код для входа: %s\n\nКод действует %d мин. Никому его не сообщайте.\n"+
+			"Если вы не запрашивали вход, просто проигнорируйте это письмо.",
+		otp, int(s.cfg.OTPTTL.Minutes()),
+	)
+	return s.mailer.Send(ctx, user.Email, "Код для входа", body)
+}
+
+// requestLimitReached сообщает, что пользователю уже отправлено OTPRequestLimit писем
+// этого вида за OTPRequestWindow. Сверх лимита письмо молча не отправляется.
+func (s *passwordlessService) requestLimitReached(ctx context.Context, user *models.User, purpose string) (bool, error) {
+	issued, err := s.codeRepo.CountIssuedSince(ctx, user.ID, purpose, time.Now().Add(-s.cfg.OTPRequestWindow))
+	if err != nil {
+		return false, err
+	}
+	return issued >= int64(s.cfg.OTPRequestLimit), nil
+}
+
+// RedeemOTP проверяет одноразовый код и выдает JWT токен
+func (s *passwordlessService) RedeemOTP(ctx context.Context, email, otp, deviceID string, meta dto.RequestMeta) (*dto.AuthResponse, error) {
+	if otp == "" || deviceID == "" {
+		return nil, ErrInvalidLoginCode
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidLoginCode
+		}
+		return nil, err
+	}
+
+	code, err := s.codeRepo.FindLatestActive(ctx, user.ID, models.LoginCodeOTP)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidLoginCode
+		}
+		return nil, err
+	}
+
+	deviceMatches := hmac.Equal([]byte(code.DeviceHash), []byte(s.hash(deviceID)))
+	codeMatches := hmac.Equal([]byte(code.CodeHash), []byte(s.hash(otp)))
+	if !deviceMatches || !codeMatches {
+		if err := s.codeRepo.RegisterFailedAttempt(ctx, code.ID, s.cfg.OTPMaxAttempts); err != nil {
+			return nil, err
+		}
+		if err := s.authService.RecordLoginFailure(ctx, user, models.LoginMethodOTP, ErrInvalidLoginCode, meta); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidLoginCode
+	}
+
+	return s.redeem(ctx, code, models.LoginMethodOTP, meta)
+}
+
+// findLocalUser возвращает локального пользователя или nil, если вход без пароля для него недоступен
+func (s *passwordlessService) findLocalUser(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Пользователи каталога и IdP входят только через свой провайдер
+	if user.AuthSource != "" && user.AuthSource != models.AuthSourceLocal {
+		return nil, nil
+	}
+
+	return user, nil
+}
+
+// issueCode отзывает предыдущие коды того же вида и сохраняет новый
+func (s *passwordlessService) issueCode(ctx context.Context, user *models.User, kind, secret, deviceID string, ttl time.Duration) error {
+	if deviceID == "" {
+		return errors.New("не задан идентификатор устройства")
+	}
+
+	if err := s.codeRepo.InvalidateActive(ctx, user.ID, kind); err != nil {
+		return err
+	}
+
+	return s.codeRepo.Create(ctx, &models.LoginCode{
+		UserID:     user.ID,
+		Kind:       kind,
+		CodeHash:   s.hash(secret),
+		DeviceHash: s.hash(deviceID),
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+}
+
+// redeem атомарно расходует код и выдает токен его владельцу
+func (s *passwordlessService) redeem(ctx context.Context, code *models.LoginCode, method string, meta dto.RequestMeta) (*dto.AuthResponse, error) {
+	consumed, err := s.codeRepo.Consume(ctx, code.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidLoginCode
+	}
+
+	user, err := s.userRepo.FindByID(ctx, code.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authService.CompleteLogin(ctx, user, method, meta)
+}
+
+// hash вычисляет HMAC-SHA256 значения; коды и идентификаторы устройств в открытом виде не хранятся
+func (s *passwordlessService) hash(value string) string {
+	return hashToken(s.cfg.JWTSecret, value)
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+)
+
+const testDeviceID = "device-1"
+
+// otpPattern находит шестизначный код в тексте письма
+var otpPattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// passwordlessHarness сервис входа без пароля поверх фейковых репозиториев и почты
+type passwordlessHarness struct {
+	*authHarness
+	codes   *fakeLoginCodeRepository
+	mailer  *fakeMailer
+	service PasswordlessService
+}
+
+func newPasswordlessHarness(t *testing.T, users ...*models.User) *passwordlessHarness {
+	t.Helper()
+
+	auth := newAuthHarness(t, users...)
+	auth.cfg.OTPTTL = 10 * time.Minute
+	auth.cfg.MagicLinkTTL = 15 * time.Minute
+	auth.cfg.OTPMaxAttempts = 3
+	auth.cfg.OTPRequestLimit = 2
+	auth.cfg.OTPRequestWindow = 15 * time.Minute
+
+	h := &passwordlessHarness{authHarness: auth, codes: &fakeLoginCodeRepository{}, mailer: &fakeMailer{}}
+	h.service = NewPasswordlessService(auth.users, h.codes, auth.service, h.mailer, auth.cfg)
+	return h
+}
+
+// requestOTP запрашивает код и возвращает его из последнего письма
+func (h *passwordlessHarness) requestOTP(t *testing.T, email string) string {
+	t.Helper()
+
+	if err := h.service.RequestOTP(context.Background(), email, testDeviceID); err != nil {
+		t.Fatalf("RequestOTP: %v", err)
+	}
+	messages := h.mailer.messages()
+	if len(messages) == 0 {
+		t.Fatal("письмо с кодом не отправлено")
+	}
+	otp := otpPattern.FindString(messages[len(messages)-1].body)
+	if otp == "" {
+		t.Fatalf("код не найден в письме: %q", messages[len(messages)-1].body)
+	}
+	return otp
+}
+
+// wrongOTP возвращает код, отличный от верного
+func wrongOTP(otp string) string {
+	if otp == "000000" {
+		return "000001"
+	}
+	return "000000"
+}
+
+func TestRedeemOTPIssuesToken(t *testing.T) {
+	user := newLocalUser(t, "otp@example.com", "secret-password")
+	h := newPasswordlessHarness(t, user)
+
+	otp := h.requestOTP(t, "otp@example.com")
+	response, err := h.service.RedeemOTP(context.Background(), "otp@example.com", otp, testDeviceID, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("RedeemOTP: %v", err)
+	}
+	if _, _, err := h.authHarness.service.ValidateToken(context.Background(), response.Token); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	// Код одноразовый
+	if _, err := h.service.RedeemOTP(context.Background(), "otp@example.com", otp, testDeviceID, dto.RequestMeta{}); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("повторное использование кода: %v", err)
+	}
+}
+
+func TestRedeemOTPConsumesCodeAfterMaxAttempts(t *testing.T) {
+	user := newLocalUser(t, "otp@example.com", "secret-password")
+	h := newPasswordlessHarness(t, user)
+	ctx := context.Background()
+
+	otp := h.requestOTP(t, "otp@example.com")
+	for i := 0; i < h.cfg.OTPMaxAttempts; i++ {
+		if _, err := h.service.RedeemOTP(ctx, "otp@example.com", wrongOTP(otp), testDeviceID, dto.RequestMeta{}); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Fatalf("попытка %d: %v", i+1, err)
+		}
+	}
+
+	if _, err := h.service.RedeemOTP(ctx, "otp@example.com", otp, testDeviceID, dto.RequestMeta{}); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("верный код принят после исчерпания попыток: %v", err)
+	}
+	if len(h.loginHistory.failures) != h.cfg.OTPMaxAttempts {
+		t.Errorf("неудачных входов %d, ожидалось %d", len(h.loginHistory.failures), h.cfg.OTPMaxAttempts)
+	}
+}
+
+func TestRedeemOTPRejectsOtherDevice(t *testing.T) {
+	user := newLocalUser(t, "otp@example.com", "secret-password")
+	h := newPasswordlessHarness(t, user)
+
+	otp := h.requestOTP(t, "otp@example.com")
+	if _, err := h.service.RedeemOTP(context.Background(), "otp@example.com", otp, "device-2", dto.RequestMeta{}); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("код принят на другом устройстве: %v", err)
+	}
+	if h.codes.codes[0].Attempts != 1 {
+		t.Errorf("попыток = %d, ожидалась 1", h.codes.codes[0].Attempts)
+	}
+}
+
+func TestRequestOTPThrottlesPerEmail(t *testing.T) {
+	user := newLocalUser(t, "otp@example.com", "secret-password")
+	h := newPasswordlessHarness(t, user)
+	ctx := context.Background()
+
+	var otp string
+	for i := 0; i < h.cfg.OTPRequestLimit; i++ {
+		otp = h.requestOTP(t, "otp@example.com")
+	}
+
+	// Сверх лимита новый код не выпускается и ответ не меняется
+	if err := h.service.RequestOTP(ctx, "otp@example.com", testDeviceID); err != nil {
+		t.Fatalf("RequestOTP сверх лимита: %v", err)
+	}
+	if sent := len(h.mailer.messages()); sent != h.cfg.OTPRequestLimit {
+		t.Fatalf("отправлено %d писем, ожидалось %d", sent, h.cfg.OTPRequestLimit)
+	}
+	if issued := len(h.codes.codes); issued != h.cfg.OTPRequestLimit {
+		t.Fatalf("выпущено %d кодов, ожидалось %d", issued, h.cfg.OTPRequestLimit)
+	}
+
+	// Счетчик попыток последнего кода не сброшен новым запросом
+	if _, err := h.service.RedeemOTP(ctx, "otp@example.com", wrongOTP(otp), testDeviceID, dto.RequestMeta{}); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatal(err)
+	}
+	if err := h.service.RequestOTP(ctx, "otp@example.com", testDeviceID); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := h.codes.FindLatestActive(context.Background(), user.ID, models.LoginCodeOTP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Attempts != 1 {
+		t.Errorf("попыток у действующего кода %d, ожидалась 1", latest.Attempts)
+	}
+}
+
+func TestRequestOTPUnknownEmail(t *testing.T) {
+	h := newPasswordlessHarness(t)
+
+	if err := h.service.RequestOTP(context.Background(), "nobody@example.com", testDeviceID); err != nil {
+		t.Fatalf("RequestOTP: %v", err)
+	}
+	if len(h.mailer.messages()) != 0 {
+		t.Fatal("письмо отправлено на неизвестный email")
+	}
+}
+
+func TestRequestMagicLinkThrottlesPerEmail(t *testing.T) {
+	user := newLocalUser(t, "link@example.com", "secret-password")
+	h := newPasswordlessHarness(t, user)
+	ctx := context.Background()
+
+	for i := 0; i <= h.cfg.OTPRequestLimit; i++ {
+		if err := h.service.RequestMagicLink(ctx, "link@example.com", testDeviceID); err != nil {
+			t.Fatalf("RequestMagicLink #%d: %v", i+1, err)
+		}
+	}
+	if sent := len(h.mailer.messages()); sent != h.cfg.OTPRequestLimit {
+		t.Fatalf("отправлено %d писем, ожидалось %d", sent, h.cfg.OTPRequestLimit)
+	}
+	if issued := len(h.codes.codes); issued != h.cfg.OTPRequestLimit {
+		t.Fatalf("выпущено %d ссылок, ожидалось %d", issued, h.cfg.OTPRequestLimit)
+	}
+
+	// Лимит ссылок не расходует лимит кодов
+	h.requestOTP(t, "link@example.com")
+}
+
+func TestRedeemMagicLinkRequiresSecondFactor(t *testing.T) {
+	user := newLocalUser(t, "link@example.com", "secret-password")
+	user.MFAEnabled = true
+	h := newPasswordlessHarness(t, user)
+	ctx := context.Background()
+
+	if err := h.service.RequestMagicLink(ctx, "link@example.com", testDeviceID); err != nil {
+		t.Fatalf("RequestMagicLink: %v", err)
+	}
+	token := regexp.MustCompile(`token=([0-9a-f]+)`).FindStringSubmatch(h.mailer.messages()[0].body)
+	if token == nil {
+		t.Fatalf("ссылка не найдена в письме: %q", h.mailer.messages()[0].body)
+	}
+
+	response, err := h.service.RedeemMagicLink(ctx, token[1], testDeviceID, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("RedeemMagicLink: %v", err)
+	}
+	if !response.SecondFactorRequired {
+		t.Fatal("вход по ссылке обошел второй фактор")
+	}
+}
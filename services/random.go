@@ -0,0 +1,25 @@
+// services/random.go - генерация криптографически стойких случайных значений
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// randomToken возвращает hex-строку из n случайных байт
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken вычисляет HMAC-SHA256 секретного значения, чтобы коды и ссылки не хранились в открытом виде
+func hashToken(key, value string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
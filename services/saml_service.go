@@ -0,0 +1,270 @@
+// services/saml_service.go - вход через SAML 2.0 Identity Provider
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/metrics"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// ErrInvalidSAMLResponse возвращается, если ответ IdP не прошел проверку подписи или условий
+var ErrInvalidSAMLResponse = errors.New("недействительный SAML ответ")
+
+// SAMLService интерфейс сервиса входа через SAML
+type SAMLService interface {
+	Metadata() ([]byte, error)
+	AuthnRequest(relayState string) (redirectURL string, requestID string, err error)
+	ConsumeResponse(ctx context.Context, samlResponse string, possibleRequestIDs []string, meta dto.RequestMeta) (*dto.AuthResponse, error)
+}
+
+// samlService реализация SAMLService
+type samlService struct {
+	sp            *saml.ServiceProvider
+	cfg           *config.Config
+	provisioner   *externalProvisioner
+	assertionRepo repositories.SAMLAssertionRepository
+	authService   AuthService
+}
+
+// NewSAMLService создает сервис входа через SAML: загружает ключ SP и метаданные IdP
+func NewSAMLService(
+	cfg *config.Config,
+	userRepo repositories.UserRepository,
+	outboxRepo repositories.OutboxRepository,
+	txManager repositories.TxManager,
+	auditService AuditService,
+	assertionRepo repositories.SAMLAssertionRepository,
+	authService AuthService,
+	m metrics.Metrics,
+) (SAMLService, error) {
+	rootURL, err := url.Parse(cfg.SAMLRootURL)
+	if err != nil || rootURL.Scheme == "" || rootURL.Host == "" {
+		return nil, fmt.Errorf("saml: некорректный SAML_ROOT_URL %q", cfg.SAMLRootURL)
+	}
+
+	keyPair, err := tls.LoadX509KeyPair(cfg.SAMLCertFile, cfg.SAMLKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("saml: ошибка загрузки ключа SP: %w", err)
+	}
+	certificate, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("saml: ошибка разбора сертификата SP: %w", err)
+	}
+	key, ok := keyPair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("saml: ключ SP должен быть RSA")
+	}
+
+	idpMetadata, err := loadIDPMetadata(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("saml: ошибка загрузки метаданных IdP: %w", err)
+	}
+
+	metadataURL := rootURL.ResolveReference(&url.URL{Path: "/api/auth/saml/metadata"})
+	acsURL := rootURL.ResolveReference(&url.URL{Path: "/api/auth/saml/acs"})
+
+	entityID := cfg.SAMLEntityID
+	if entityID == "" {
+		entityID = metadataURL.String()
+	}
+
+	sp := &saml.ServiceProvider{
+		EntityID:          entityID,
+		Key:               key,
+		Certificate:       certificate,
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idpMetadata,
+		AllowIDPInitiated: cfg.SAMLAllowIDPInitiated,
+		SignatureMethod:   dsig.RSASHA256SignatureMethod,
+	}
+
+	return &samlService{
+		sp:            sp,
+		cfg:           cfg,
+		provisioner:   newExternalProvisioner(userRepo, outboxRepo, txManager, auditService, m),
+		assertionRepo: assertionRepo,
+		authService:   authService,
+	}, nil
+}
+
+// loadIDPMetadata читает метаданные IdP из файла или загружает их по URL
+func loadIDPMetadata(cfg *config.Config) (*saml.EntityDescriptor, error) {
+	if cfg.SAMLIDPMetadataFile != "" {
+		data, err := os.ReadFile(cfg.SAMLIDPMetadataFile)
+		if err != nil {
+			return nil, err
+		}
+		return samlsp.ParseMetadata(data)
+	}
+
+	if cfg.SAMLIDPMetadataURL == "" {
+		return nil, errors.New("не задан SAML_IDP_METADATA_FILE или SAML_IDP_METADATA_URL")
+	}
+	metadataURL, err := url.Parse(cfg.SAMLIDPMetadataURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return samlsp.FetchMetadata(ctx, http.DefaultClient, *metadataURL)
+}
+
+// Metadata возвращает XML метаданные Service Provider для регистрации в IdP
+func (s *samlService) Metadata() ([]byte, error) {
+	metadata, err := xml.MarshalIndent(s.sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), metadata...), nil
+}
+
+// AuthnRequest формирует подписанный AuthnRequest для HTTP-Redirect binding
+// и возвращает адрес IdP вместе с ID запроса для последующей проверки InResponseTo
+func (s *samlService) AuthnRequest(relayState string) (string, string, error) {
+	request, err := s.sp.MakeAuthenticationRequest(
+		s.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding),
+		saml.HTTPRedirectBinding,
+		saml.HTTPPostBinding,
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Библиотека добавляет RelayState в запрос без экранирования
+	redirectURL, err := request.Redirect(url.QueryEscape(relayState), s.sp)
+	if err != nil {
+		return "", "", err
+	}
+
+	return redirectURL.String(), request.ID, nil
+}
+
+// ConsumeResponse проверяет ответ IdP (подпись, аудиторию, сроки, InResponseTo),
+// отклоняет повторно предъявленное утверждение, сопоставляет атрибуты с пользователем
+// и выпускает обычный JWT токен
+func (s *samlService) ConsumeResponse(ctx context.Context, samlResponse string, possibleRequestIDs []string, meta dto.RequestMeta) (*dto.AuthResponse, error) {
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return nil, ErrInvalidSAMLResponse
+	}
+
+	assertion, err := s.sp.ParseXMLResponse(raw, possibleRequestIDs)
+	if err != nil {
+		return nil, ErrInvalidSAMLResponse
+	}
+
+	// Утверждение действительно до окончания срока, и без учета использованных ID
+	// перехваченный ответ (особенно при входе по инициативе IdP) можно предъявить повторно
+	if assertion.ID == "" {
+		return nil, ErrInvalidSAMLResponse
+	}
+	consumed, err := s.assertionRepo.Consume(ctx, assertion.ID, assertionExpiry(assertion, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, fmt.Errorf("%w: утверждение уже использовано", ErrInvalidSAMLResponse)
+	}
+
+	user, err := s.provisionUser(ctx, assertion, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authService.CompleteLogin(ctx, user, models.LoginMethodSAML, meta)
+}
+
+// assertionExpiry возвращает момент, после которого утверждение будет отклонено проверкой
+// сроков: наибольший NotOnOrAfter условий и подтверждений субъекта плюс допустимое
+// расхождение часов. Без сроков в утверждении используется наибольшая задержка выдачи.
+func assertionExpiry(assertion *saml.Assertion, now time.Time) time.Time {
+	expiry := now.Add(saml.MaxIssueDelay)
+	if assertion.Conditions != nil && assertion.Conditions.NotOnOrAfter.After(expiry) {
+		expiry = assertion.Conditions.NotOnOrAfter
+	}
+	if assertion.Subject != nil {
+		for _, confirmation := range assertion.Subject.SubjectConfirmations {
+			data := confirmation.SubjectConfirmationData
+			if data != nil && data.NotOnOrAfter.After(expiry) {
+				expiry = data.NotOnOrAfter
+			}
+		}
+	}
+	return expiry.Add(saml.MaxClockSkew)
+}
+
+// provisionUser сопоставляет атрибуты утверждения с локальным пользователем
+func (s *samlService) provisionUser(ctx context.Context, assertion *saml.Assertion, meta dto.RequestMeta) (*models.User, error) {
+	email := assertionAttribute(assertion, s.cfg.SAMLEmailAttribute)
+	if email == "" && assertion.Subject != nil && assertion.Subject.NameID != nil &&
+		strings.Contains(assertion.Subject.NameID.Value, "@") {
+		email = assertion.Subject.NameID.Value
+	}
+	if email == "" {
+		return nil, errors.New("saml: в утверждении отсутствует email")
+	}
+
+	return s.provisioner.provision(ctx, externalProfile{
+		Source:    models.AuthSourceSAML,
+		Email:     email,
+		Username:  assertionAttribute(assertion, s.cfg.SAMLUsernameAttribute),
+		FirstName: assertionAttribute(assertion, s.cfg.SAMLFirstNameAttribute),
+		LastName:  assertionAttribute(assertion, s.cfg.SAMLLastNameAttribute),
+		Role: mapGroupsToRole(
+			assertionAttributeValues(assertion, s.cfg.SAMLGroupAttribute),
+			s.cfg.SAMLGroupRoles,
+			s.cfg.SAMLDefaultRole,
+		),
+	}, meta)
+}
+
+// assertionAttribute возвращает первое значение атрибута по Name или FriendlyName
+func assertionAttribute(assertion *saml.Assertion, name string) string {
+	values := assertionAttributeValues(assertion, name)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// assertionAttributeValues возвращает все значения атрибута по Name или FriendlyName
+func assertionAttributeValues(assertion *saml.Assertion, name string) []string {
+	if name == "" {
+		return nil
+	}
+
+	var values []string
+	for _, statement := range assertion.AttributeStatements {
+		for _, attribute := range statement.Attributes {
+			if attribute.Name != name && attribute.FriendlyName != name {
+				continue
+			}
+			for _, value := range attribute.Values {
+				values = append(values, value.Value)
+			}
+		}
+	}
+	return values
+}
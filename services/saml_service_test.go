@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/metrics"
+	"AuthApplications/models"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+)
+
+// samlHarness сервис SAML, настроенный на тестовый IdP в памяти
+type samlHarness struct {
+	*authHarness
+	assertions *fakeSAMLAssertionRepository
+	service    SAMLService
+	idp        *saml.IdentityProvider
+	sp         *saml.EntityDescriptor
+}
+
+func newSAMLHarness(t *testing.T) *samlHarness {
+	t.Helper()
+	dir := t.TempDir()
+
+	idpKey, idpCert := newTestCertificate(t, "idp.example.com")
+	idp := &saml.IdentityProvider{
+		Key:         idpKey,
+		Certificate: idpCert,
+		MetadataURL: url.URL{Scheme: "https", Host: "idp.example.com", Path: "/metadata"},
+		SSOURL:      url.URL{Scheme: "https", Host: "idp.example.com", Path: "/sso"},
+	}
+	idpMetadata, err := xml.Marshal(idp.Metadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spKey, spCert := newTestCertificate(t, "auth.example.com")
+	writeFile(t, filepath.Join(dir, "idp.xml"), idpMetadata)
+	writeFile(t, filepath.Join(dir, "sp.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: spCert.Raw}))
+	writeFile(t, filepath.Join(dir, "sp.key"), pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(spKey)}))
+
+	auth := newAuthHarness(t)
+	auth.cfg.SAMLRootURL = "https://auth.example.com"
+	auth.cfg.SAMLCertFile = filepath.Join(dir, "sp.crt")
+	auth.cfg.SAMLKeyFile = filepath.Join(dir, "sp.key")
+	auth.cfg.SAMLIDPMetadataFile = filepath.Join(dir, "idp.xml")
+	auth.cfg.SAMLEmailAttribute = "email"
+	auth.cfg.SAMLDefaultRole = "user"
+
+	assertions := newFakeSAMLAssertionRepository()
+	service, err := NewSAMLService(auth.cfg, auth.users, auth.outbox, fakeTxManager{}, auth.audit, assertions, auth.service, metrics.NewNop())
+	if err != nil {
+		t.Fatalf("NewSAMLService: %v", err)
+	}
+
+	spMetadata, err := service.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sp saml.EntityDescriptor
+	if err := xml.Unmarshal(spMetadata, &sp); err != nil {
+		t.Fatal(err)
+	}
+
+	return &samlHarness{authHarness: auth, assertions: assertions, service: service, idp: idp, sp: &sp}
+}
+
+// response подписывает ответ IdP на запрос requestID для пользователя с email
+func (h *samlHarness) response(t *testing.T, requestID, email string) string {
+	t.Helper()
+
+	now := time.Now()
+	request := &saml.IdpAuthnRequest{
+		IDP:                     h.idp,
+		HTTPRequest:             httptest.NewRequest("POST", "/sso", nil),
+		Request:                 saml.AuthnRequest{ID: requestID, IssueInstant: now},
+		ServiceProviderMetadata: h.sp,
+		SPSSODescriptor:         &h.sp.SPSSODescriptors[0],
+		ACSEndpoint:             &h.sp.SPSSODescriptors[0].AssertionConsumerServices[0],
+		Now:                     now,
+	}
+	session := &saml.Session{
+		ID:        "session",
+		NameID:    email,
+		UserEmail: email,
+		CustomAttributes: []saml.Attribute{{
+			Name:   "email",
+			Values: []saml.AttributeValue{{Type: "xs:string", Value: email}},
+		}},
+	}
+	if err := (saml.DefaultAssertionMaker{}).MakeAssertion(request, session); err != nil {
+		t.Fatalf("MakeAssertion: %v", err)
+	}
+	if err := request.MakeResponse(); err != nil {
+		t.Fatalf("MakeResponse: %v", err)
+	}
+
+	doc := etree.NewDocument()
+	doc.SetRoot(request.ResponseEl)
+	raw, err := doc.WriteToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestSAMLConsumeResponseRejectsReplayedAssertion(t *testing.T) {
+	h := newSAMLHarness(t)
+	ctx := context.Background()
+	response := h.response(t, "id-request", "saml@example.com")
+
+	first, err := h.service.ConsumeResponse(ctx, response, []string{"id-request"}, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("ConsumeResponse: %v", err)
+	}
+	if first.SecondFactorRequired || first.Token == "" {
+		t.Fatalf("токен не выдан: %+v", first)
+	}
+
+	// Перехваченный ответ, отправленный повторно, не дает второй сессии
+	if _, err := h.service.ConsumeResponse(ctx, response, []string{"id-request"}, dto.RequestMeta{}); !errors.Is(err, ErrInvalidSAMLResponse) {
+		t.Fatalf("ожидалась ErrInvalidSAMLResponse, получено %v", err)
+	}
+	if successes := h.loginHistory.successes; len(successes) != 1 || successes[0] != models.LoginMethodSAML {
+		t.Errorf("успешные входы = %v", successes)
+	}
+
+	if len(h.assertions.consumed) != 1 {
+		t.Fatalf("использованные утверждения: %v", h.assertions.consumed)
+	}
+	for _, expiresAt := range h.assertions.consumed {
+		// ID хранится не меньше, чем утверждение проходит проверку сроков
+		if minimum := time.Now().Add(saml.MaxIssueDelay + saml.MaxClockSkew - time.Minute); expiresAt.Before(minimum) {
+			t.Errorf("expires_at = %v, раньше окончания срока утверждения", expiresAt)
+		}
+	}
+}
+
+func TestSAMLConsumeResponseAcceptsNewAssertions(t *testing.T) {
+	h := newSAMLHarness(t)
+	ctx := context.Background()
+
+	// Каждый вход получает от IdP новое утверждение с собственным ID
+	for _, requestID := range []string{"id-first", "id-second"} {
+		if _, err := h.service.ConsumeResponse(ctx, h.response(t, requestID, "saml@example.com"), []string{requestID}, dto.RequestMeta{}); err != nil {
+			t.Fatalf("%s: %v", requestID, err)
+		}
+	}
+}
+
+func TestSAMLConsumeResponseRecordsProvisionedUser(t *testing.T) {
+	h := newSAMLHarness(t)
+	ctx := context.Background()
+
+	for _, requestID := range []string{"id-first", "id-second"} {
+		if _, err := h.service.ConsumeResponse(ctx, h.response(t, requestID, "saml@example.com"), []string{requestID}, dto.RequestMeta{}); err != nil {
+			t.Fatalf("%s: %v", requestID, err)
+		}
+	}
+
+	// Пользователь создан при первом входе; повторный вход с теми же данными ничего не меняет
+	if len(h.outbox.events) != 1 || h.outbox.events[0].EventType != models.EventUserRegistered {
+		t.Errorf("события outbox = %+v", h.outbox.events)
+	}
+	if registered := slices.Index(h.audit.recorded(), models.AuditRegister); registered < 0 || h.audit.details[registered]["auth_source"] != models.AuthSourceSAML {
+		t.Errorf("журнал аудита = %v", h.audit.recorded())
+	}
+}
+
+func TestAssertionExpiryUsesLatestNotOnOrAfter(t *testing.T) {
+	now := time.Now()
+	conditions := now.Add(10 * time.Minute)
+	confirmation := now.Add(20 * time.Minute)
+	assertion := &saml.Assertion{
+		Conditions: &saml.Conditions{NotOnOrAfter: conditions},
+		Subject: &saml.Subject{SubjectConfirmations: []saml.SubjectConfirmation{{
+			SubjectConfirmationData: &saml.SubjectConfirmationData{NotOnOrAfter: confirmation},
+		}}},
+	}
+
+	if got := assertionExpiry(assertion, now); !got.Equal(confirmation.Add(saml.MaxClockSkew)) {
+		t.Errorf("срок = %v, ожидался %v", got, confirmation.Add(saml.MaxClockSkew))
+	}
+	if got := assertionExpiry(&saml.Assertion{}, now); !got.Equal(now.Add(saml.MaxIssueDelay + saml.MaxClockSkew)) {
+		t.Errorf("срок без условий = %v", got)
+	}
+}
+
+// newTestCertificate создает RSA ключ и самоподписанный сертификат
+func newTestCertificate(t *testing.T, commonName string) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, certificate
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
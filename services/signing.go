@@ -0,0 +1,37 @@
+// services/signing.go - подписи ссылок и токенов ключами, выведенными из секрета приложения
+package services
+
+import (
+	"crypto/hmac"
+
+	"AuthApplications/config"
+)
+
+// Назначения подписей: у каждого свой ключ, поэтому подпись одного назначения
+// не подходит для другого и не совпадает с подписью JWT
+const (
+	signingPurposeDataExport = "data-export"
+	signingPurposeCSRF       = "csrf"
+)
+
+// purposeKey выводит из секрета ключ подписи для назначения purpose
+func purposeKey(secret, purpose string) string {
+	return hashToken(secret, "signing-key:"+purpose)
+}
+
+// sign подписывает value ключом назначения purpose, выведенным из текущего JWT_SECRET
+func sign(cfg *config.Config, purpose, value string) string {
+	return hashToken(purposeKey(cfg.JWTSecret, purpose), value)
+}
+
+// verifySignature проверяет подпись value ключом назначения purpose. Подходит ключ,
+// выведенный из текущего секрета или из JWT_PREVIOUS_SECRETS, поэтому после `keys rotate`
+// выданные ссылки и токены действуют до своего срока, пока старый секрет не удален из конфигурации.
+func verifySignature(cfg *config.Config, purpose, value, signature string) bool {
+	valid := hmac.Equal([]byte(signature), []byte(sign(cfg, purpose, value)))
+	for _, secret := range cfg.JWTPreviousSecrets {
+		expected := hashToken(purposeKey(secret, purpose), value)
+		valid = hmac.Equal([]byte(signature), []byte(expected)) || valid
+	}
+	return valid
+}
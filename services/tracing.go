@@ -0,0 +1,209 @@
+// services/tracing.go - спаны вокруг вызовов AuthService, UserService и BookService
+package services
+
+import (
+	"context"
+	"time"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/tracing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Атрибуты спанов сервисов. Email, пароли и токены в спаны не попадают.
+const (
+	attrUserID   = attribute.Key("user.id")
+	attrTargetID = attribute.Key("user.target_id")
+	attrBookID   = attribute.Key("book.id")
+	attrMethod   = attribute.Key("auth.method")
+)
+
+// tracedAuthService оборачивает AuthService спанами
+type tracedAuthService struct {
+	next AuthService
+}
+
+// NewTracedAuthService создает AuthService, записывающий спан на каждый вызов
+func NewTracedAuthService(next AuthService) AuthService {
+	return &tracedAuthService{next: next}
+}
+
+func (s *tracedAuthService) Register(ctx context.Context, req dto.RegisterRequest, meta dto.RequestMeta) (user *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	defer func() { tracing.End(span, err) }()
+	return s.next.Register(ctx, req, meta)
+}
+
+func (s *tracedAuthService) Login(ctx context.Context, req dto.LoginRequest, meta dto.RequestMeta) (response *dto.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer func() { tracing.End(span, err) }()
+	return s.next.Login(ctx, req, meta)
+}
+
+func (s *tracedAuthService) Logout(ctx context.Context, tokenString string, meta dto.RequestMeta) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Logout")
+	defer func() { tracing.End(span, err) }()
+	return s.next.Logout(ctx, tokenString, meta)
+}
+
+func (s *tracedAuthService) ValidateToken(ctx context.Context, tokenString string) (token *jwt.Token, claims *JWTClaim, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ValidateToken")
+	defer func() { tracing.End(span, err) }()
+	return s.next.ValidateToken(ctx, tokenString)
+}
+
+func (s *tracedAuthService) ValidateMFAToken(ctx context.Context, tokenString string) (claims *JWTClaim, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ValidateMFAToken")
+	defer func() { tracing.End(span, err) }()
+	return s.next.ValidateMFAToken(ctx, tokenString)
+}
+
+func (s *tracedAuthService) CompleteLogin(ctx context.Context, user *models.User, method string, meta dto.RequestMeta) (response *dto.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.CompleteLogin", attrUserID.String(user.ID.String()), attrMethod.String(method))
+	defer func() { tracing.End(span, err) }()
+	return s.next.CompleteLogin(ctx, user, method, meta)
+}
+
+func (s *tracedAuthService) RecordLoginFailure(ctx context.Context, user *models.User, method string, cause error, meta dto.RequestMeta) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RecordLoginFailure", attrUserID.String(user.ID.String()), attrMethod.String(method))
+	defer func() { tracing.End(span, err) }()
+	return s.next.RecordLoginFailure(ctx, user, method, cause, meta)
+}
+
+func (s *tracedAuthService) Reauthenticate(ctx context.Context, claims *JWTClaim, password string, meta dto.RequestMeta) (token string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Reauthenticate", attrUserID.String(claims.UserID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.Reauthenticate(ctx, claims, password, meta)
+}
+
+func (s *tracedAuthService) ElevateToken(ctx context.Context, claims *JWTClaim, amr []string, meta dto.RequestMeta) (token string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ElevateToken", attrUserID.String(claims.UserID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.ElevateToken(ctx, claims, amr, meta)
+}
+
+func (s *tracedAuthService) Impersonate(ctx context.Context, actorID, targetID uuid.UUID, reason string, meta dto.RequestMeta) (token string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Impersonate", attrUserID.String(actorID.String()), attrTargetID.String(targetID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.Impersonate(ctx, actorID, targetID, reason, meta)
+}
+
+func (s *tracedAuthService) EndImpersonation(ctx context.Context, claims *JWTClaim, meta dto.RequestMeta) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.EndImpersonation", attrUserID.String(claims.UserID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.EndImpersonation(ctx, claims, meta)
+}
+
+func (s *tracedAuthService) CheckSigningKeys(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.CheckSigningKeys")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CheckSigningKeys(ctx)
+}
+
+// tracedUserService оборачивает UserService спанами
+type tracedUserService struct {
+	next UserService
+}
+
+// NewTracedUserService создает UserService, записывающий спан на каждый вызов
+func NewTracedUserService(next UserService) UserService {
+	return &tracedUserService{next: next}
+}
+
+func (s *tracedUserService) GetUserProfile(ctx context.Context, userID uuid.UUID) (user *dto.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserProfile", attrUserID.String(userID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetUserProfile(ctx, userID)
+}
+
+func (s *tracedUserService) GetAllUser(ctx context.Context) (users []*dto.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUser")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetAllUser(ctx)
+}
+
+func (s *tracedUserService) GetByID(ctx context.Context, userID uuid.UUID) (user *dto.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetByID", attrUserID.String(userID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetByID(ctx, userID)
+}
+
+func (s *tracedUserService) PatchUser(ctx context.Context, userID uuid.UUID, req dto.PatchUserRequsest, meta dto.RequestMeta) (user *dto.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.PatchUser", attrUserID.String(userID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.PatchUser(ctx, userID, req, meta)
+}
+
+func (s *tracedUserService) DeleteUser(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) (purgeAt *time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser", attrUserID.String(userID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.DeleteUser(ctx, userID, meta)
+}
+
+func (s *tracedUserService) RestoreUser(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RestoreUser", attrUserID.String(userID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.RestoreUser(ctx, userID, meta)
+}
+
+func (s *tracedUserService) SuspendUser(ctx context.Context, actorID, userID uuid.UUID, req dto.SuspendRequest, meta dto.RequestMeta) (user *dto.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.SuspendUser", attrUserID.String(actorID.String()), attrTargetID.String(userID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.SuspendUser(ctx, actorID, userID, req, meta)
+}
+
+func (s *tracedUserService) UnsuspendUser(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) (user *dto.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UnsuspendUser", attrTargetID.String(userID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.UnsuspendUser(ctx, userID, meta)
+}
+
+// tracedBookService оборачивает BookService спанами
+type tracedBookService struct {
+	next BookService
+}
+
+// NewTracedBookService создает BookService, записывающий спан на каждый вызов
+func NewTracedBookService(next BookService) BookService {
+	return &tracedBookService{next: next}
+}
+
+func (s *tracedBookService) CreateBook(ctx context.Context, req dto.BookRequest) (book *models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.CreateBook")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CreateBook(ctx, req)
+}
+
+func (s *tracedBookService) GetAllBook(ctx context.Context) (books []*dto.BookResponse, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetAllBook")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetAllBook(ctx)
+}
+
+func (s *tracedBookService) GetByID(ctx context.Context, id uuid.UUID) (book *dto.BookResponse, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetByID", attrBookID.String(id.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *tracedBookService) FindByGenre(ctx context.Context, genre string) (books []*dto.BookResponse, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.FindByGenre", attribute.String("book.genre", genre))
+	defer func() { tracing.End(span, err) }()
+	return s.next.FindByGenre(ctx, genre)
+}
+
+func (s *tracedBookService) Search(ctx context.Context, query string) (books []*dto.BookResponse, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.Search")
+	defer func() { tracing.End(span, err) }()
+	return s.next.Search(ctx, query)
+}
+
+func (s *tracedBookService) PatchBook(ctx context.Context, bookID uuid.UUID, req dto.PatchBookRequest) (book *dto.BookResponse, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.PatchBook", attrBookID.String(bookID.String()))
+	defer func() { tracing.End(span, err) }()
+	return s.next.PatchBook(ctx, bookID, req)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"AuthApplications/dto"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans устанавливает глобальный провайдер, записывающий завершенные спаны
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+// spanByName возвращает завершенный спан по имени
+func spanByName(t *testing.T, recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	t.Fatalf("нет спана %s", name)
+	return nil
+}
+
+func TestTracedAuthServiceLoginSpans(t *testing.T) {
+	recorder := recordSpans(t)
+	h := newAuthHarness(t, newLocalUser(t, "reader@example.com", "secret-password"))
+	service := NewTracedAuthService(h.service)
+
+	if _, err := service.Login(context.Background(), dto.LoginRequest{
+		Identifier: "reader@example.com",
+		Password:   "secret-password",
+	}, dto.RequestMeta{}); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	login := spanByName(t, recorder, "AuthService.Login")
+	authenticate := spanByName(t, recorder, "Authenticator.Authenticate")
+	// Время bcrypt видно отдельным дочерним спаном
+	if authenticate.Parent().SpanID() != login.SpanContext().SpanID() {
+		t.Error("спан проверки пароля не привязан к спану входа")
+	}
+	if login.Status().Code == codes.Error {
+		t.Errorf("успешный вход отмечен ошибкой: %v", login.Status())
+	}
+
+	// Email, пароль и токены в атрибуты спанов не попадают
+	for _, span := range recorder.Ended() {
+		for _, attr := range span.Attributes() {
+			if value := attr.Value.Emit(); strings.Contains(value, "reader@example.com") || strings.Contains(value, "secret-password") {
+				t.Errorf("спан %s содержит учетные данные: %s=%s", span.Name(), attr.Key, value)
+			}
+		}
+	}
+}
+
+func TestTracedAuthServiceMarksFailure(t *testing.T) {
+	recorder := recordSpans(t)
+	h := newAuthHarness(t, newLocalUser(t, "reader@example.com", "secret-password"))
+	service := NewTracedAuthService(h.service)
+
+	if _, err := service.Login(context.Background(), dto.LoginRequest{
+		Identifier: "reader@example.com",
+		Password:   "wrong-password",
+	}, dto.RequestMeta{}); err == nil {
+		t.Fatal("вход с неверным паролем")
+	}
+
+	if login := spanByName(t, recorder, "AuthService.Login"); login.Status().Code != codes.Error {
+		t.Error("неудачный вход не отмечен в спане сервиса")
+	}
+	// Неверный пароль — ожидаемый исход проверки, а не сбой бэкенда
+	if authenticate := spanByName(t, recorder, "Authenticator.Authenticate"); authenticate.Status().Code == codes.Error {
+		t.Error("неверный пароль отмечен как сбой аутентификатора")
+	}
+}
+
+func TestTracedBookServiceSpan(t *testing.T) {
+	recorder := recordSpans(t)
+	service := NewTracedBookService(NewBookService(&fakeBookRepository{}, &fakeOutboxRepository{}, fakeTxManager{}))
+
+	if _, err := service.CreateBook(context.Background(), dto.BookRequest{Title: "Мастер и Маргарита", AuthorID: uuid.New()}); err != nil {
+		t.Fatalf("CreateBook: %v", err)
+	}
+	spanByName(t, recorder, "BookService.CreateBook")
+}
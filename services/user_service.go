@@ -2,36 +2,73 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"time"
+
+	"AuthApplications/config"
 	"AuthApplications/dto"
+	"AuthApplications/models"
 	"AuthApplications/repositories"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // UserService интерфейс сервиса пользователей
 type UserService interface {
-	GetUserProfile(userID uuid.UUID) (*dto.UserResponse, error)
-	GetAllUser() ([]*dto.UserResponse, error)
-	GetByID(userID uuid.UUID) (*dto.UserResponse, error)
-    PatchUser(userID uuid.UUID, req dto.PatchUserRequsest) (*dto.UserResponse, error) 
-    DeleteUser(userID uuid.UUID) error
+	GetUserProfile(ctx context.Context, userID uuid.UUID) (*dto.UserResponse, error)
+	GetAllUser(ctx context.Context) ([]*dto.UserResponse, error)
+	GetByID(ctx context.Context, userID uuid.UUID) (*dto.UserResponse, error)
+    PatchUser(ctx context.Context, userID uuid.UUID, req dto.PatchUserRequsest, meta dto.RequestMeta) (*dto.UserResponse, error)
+    DeleteUser(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) (*time.Time, error)
+	RestoreUser(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) error
+	SuspendUser(ctx context.Context, actorID, userID uuid.UUID, req dto.SuspendRequest, meta dto.RequestMeta) (*dto.UserResponse, error)
+	UnsuspendUser(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) (*dto.UserResponse, error)
 }
 
+// ErrUsernameTaken возвращается, если имя пользователя (без учета регистра) уже занято
+var ErrUsernameTaken = errors.New("пользователь с таким именем уже существует")
+
+// Ошибки блокировки пользователей
+var (
+	ErrCannotSuspendSelf  = errors.New("нельзя заблокировать собственную учетную запись")
+	ErrInvalidStatusUntil = errors.New("срок блокировки должен быть в будущем")
+)
+
+// ErrUserNotDeleted возвращается при восстановлении пользователя, который не удален
+// или уже удален окончательно
+var ErrUserNotDeleted = errors.New("пользователь не ожидает удаления")
+
 // userService реализация UserService
 type userService struct {
-	userRepo repositories.UserRepository
+	userRepo     repositories.UserRepository
+	outboxRepo   repositories.OutboxRepository
+	txManager    repositories.TxManager
+	auditService AuditService
+	cfg          *config.Config
 }
 
 // NewUserService создает новый сервис пользователей
-func NewUserService(userRepo repositories.UserRepository) UserService {
+func NewUserService(
+	userRepo repositories.UserRepository,
+	outboxRepo repositories.OutboxRepository,
+	txManager repositories.TxManager,
+	auditService AuditService,
+	cfg *config.Config,
+) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		outboxRepo:   outboxRepo,
+		txManager:    txManager,
+		auditService: auditService,
+		cfg:          cfg,
 	}
 }
 
 // GetAllUser получает всех пользователей
-func (s *userService) GetAllUser() ([]*dto.UserResponse, error) {
-    users, err := s.userRepo.FindAll()
+func (s *userService) GetAllUser(ctx context.Context) ([]*dto.UserResponse, error) {
+    users, err := s.userRepo.FindAll(ctx)
     if err != nil {
         return nil, err
     }
@@ -46,6 +83,7 @@ func (s *userService) GetAllUser() ([]*dto.UserResponse, error) {
             FirstName: user.FirstName,
             LastName:  user.LastName,
             Role:      user.Role,
+            Status:    user.Status,
         })
     }
 
@@ -53,8 +91,8 @@ func (s *userService) GetAllUser() ([]*dto.UserResponse, error) {
 }
 
 // GetUserProfile получает профиль пользователя
-func (s *userService) GetUserProfile(userID uuid.UUID) (*dto.UserResponse, error) {
-	user, err := s.userRepo.FindByID(userID)
+func (s *userService) GetUserProfile(ctx context.Context, userID uuid.UUID) (*dto.UserResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -66,12 +104,13 @@ func (s *userService) GetUserProfile(userID uuid.UUID) (*dto.UserResponse, error
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
 		Role:      user.Role,
+		Status:    user.Status,
 	}, nil
 }
 
 // GetByID находит пользователя по ID
-func (s *userService) GetByID(userID uuid.UUID) (*dto.UserResponse, error) {
-    user, err := s.userRepo.FindByID(userID) // Репозиторий должен реализовывать метод FindByID
+func (s *userService) GetByID(ctx context.Context, userID uuid.UUID) (*dto.UserResponse, error) {
+    user, err := s.userRepo.FindByID(ctx, userID) // Репозиторий должен реализовывать метод FindByID
     if err != nil {
         return nil, err
     }
@@ -83,58 +122,275 @@ func (s *userService) GetByID(userID uuid.UUID) (*dto.UserResponse, error) {
         FirstName: user.FirstName,
         LastName:  user.LastName,
         Role:      user.Role,
+        Status:    user.Status,
     }, nil
 }
 
 // UpdateUser обновляет данные пользователя
-func (s *userService) PatchUser(userID uuid.UUID, req dto.PatchUserRequsest) (*dto.UserResponse, error) {
+func (s *userService) PatchUser(ctx context.Context, userID uuid.UUID, req dto.PatchUserRequsest, meta dto.RequestMeta) (*dto.UserResponse, error) {
+    // Email меняется только через подтверждение на обоих адресах
+    if req.Email != nil {
+        return nil, ErrEmailChangeRequiresConfirmation
+    }
+
     // Найдем пользователя по ID
-    user, err := s.userRepo.FindByID(userID)
+    user, err := s.userRepo.FindByID(ctx, userID)
     if err != nil {
         return nil, err
     }
+    previousRole := user.Role
 
     // Обновляем только предоставленные поля
+    var changed []string
     if req.Username != nil {
         user.Username = *req.Username
-    }
-    if req.Email != nil {
-        user.Email = *req.Email
+        changed = append(changed, "username")
     }
     if req.FirstName != nil {
         user.FirstName = *req.FirstName
+        changed = append(changed, "first_name")
     }
     if req.LastName != nil {
         user.LastName = *req.LastName
+        changed = append(changed, "last_name")
     }
     if req.Role != nil {
         user.Role = *req.Role
+        changed = append(changed, "role")
     }
     
 
-    // Сохраним обновления
-    if err := s.userRepo.PatchUser(user); err != nil {
-        return nil, err
-    }
-
-    return &dto.UserResponse{
+    response := &dto.UserResponse{
         ID:        user.ID.String(),
         Username:  user.Username,
         Email:     user.Email,
         FirstName: user.FirstName,
         LastName:  user.LastName,
         Role:      user.Role,
-    }, nil
+        Status:    user.Status,
+    }
+
+    // Пустой запрос ничего не меняет: ни записи в базе, ни события user.updated
+    if len(changed) == 0 {
+        return response, nil
+    }
+
+    // Сохраним обновления вместе с событием user.updated
+    err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+        if err := s.userRepo.WithTx(tx).PatchUser(ctx, user, changed...); err != nil {
+            // Имя уже занято другим пользователем (уникальный индекс lower(username))
+            if errors.Is(err, gorm.ErrDuplicatedKey) {
+                return ErrUsernameTaken
+            }
+            return err
+        }
+
+        event, err := newOutboxEvent(models.EventUserUpdated, user.ID, map[string]interface{}{
+            "user":           response,
+            "changed_fields": changed,
+        })
+        if err != nil {
+            return err
+        }
+        return s.outboxRepo.WithTx(tx).Create(ctx, event)
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    // Запись в журнал аудита: изменение роли фиксируется отдельным событием
+    if err := s.auditService.Record(ctx, meta, models.AuditUserUpdate, nil, &user.ID, map[string]interface{}{
+        "fields": changed,
+    }); err != nil {
+        return nil, err
+    }
+    if user.Role != previousRole {
+        if err := s.auditService.Record(ctx, meta, models.AuditRoleChange, nil, &user.ID, map[string]interface{}{
+            "from": previousRole,
+            "to":   user.Role,
+        }); err != nil {
+            return nil, err
+        }
+    }
+
+    return response, nil
 }
 
-// DeleteUser удаляет пользователя по ID
-func (s *userService) DeleteUser(userID uuid.UUID) error {
+// DeleteUser мягко удаляет пользователя и назначает окончательное удаление
+// через ACCOUNT_DELETION_GRACE_PERIOD дней. До этого срока пользователь может
+// отменить удаление входом по паролю, а администратор — восстановить учетную запись.
+func (s *userService) DeleteUser(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) (*time.Time, error) {
     // Проверим, существует ли пользователь
-    _, err := s.userRepo.FindByID(userID)
+    user, err := s.userRepo.FindByID(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    purgeAt := time.Now().Add(s.cfg.AccountDeletionGracePeriod)
+
+    // Удаляем пользователя вместе с записью события user.deleted
+    err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+        if err := s.userRepo.WithTx(tx).ScheduleDeletion(ctx, userID, purgeAt); err != nil {
+            return err
+        }
+
+        event, err := newOutboxEvent(models.EventUserDeleted, user.ID, map[string]interface{}{
+            "id":                     user.ID,
+            "email":                  user.Email,
+            "deletion_scheduled_for": purgeAt,
+        })
+        if err != nil {
+            return err
+        }
+        return s.outboxRepo.WithTx(tx).Create(ctx, event)
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.auditService.Record(ctx, meta, models.AuditUserDelete, nil, &user.ID, map[string]interface{}{
+        "email":                  user.Email,
+        "deletion_scheduled_for": purgeAt,
+    }); err != nil {
+        return nil, err
+    }
+
+    return &purgeAt, nil
+}
+
+// RestoreUser отменяет удаление пользователя до окончательного удаления
+func (s *userService) RestoreUser(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) error {
+    user, err := s.userRepo.FindDeletedByID(ctx, userID)
     if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return ErrUserNotDeleted
+        }
         return err
     }
 
-    // Удаляем пользователя
-    return s.userRepo.DeleteByID(userID)
+    if err := restoreUser(ctx, s.txManager, s.userRepo, s.outboxRepo, user); err != nil {
+        return err
+    }
+
+    return s.auditService.Record(ctx, meta, models.AuditUserRestore, nil, &user.ID, map[string]interface{}{
+        "by": "admin",
+    })
+}
+
+// SuspendUser приостанавливает или блокирует пользователя. Блокировка действует
+// и для уже выданных токенов; временная снимается автоматически по истечении срока.
+func (s *userService) SuspendUser(ctx context.Context, actorID, userID uuid.UUID, req dto.SuspendRequest, meta dto.RequestMeta) (*dto.UserResponse, error) {
+    if actorID == userID {
+        return nil, ErrCannotSuspendSelf
+    }
+    if req.Until != nil && !req.Until.After(time.Now()) {
+        return nil, ErrInvalidStatusUntil
+    }
+
+    status := req.Status
+    if status == "" {
+        status = models.UserStatusSuspended
+    }
+
+    user, err := s.changeStatus(ctx, userID, status, req.Reason, req.Until)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.auditService.Record(ctx, meta, models.AuditUserSuspend, nil, &user.ID, map[string]interface{}{
+        "status": status,
+        "reason": req.Reason,
+        "until":  req.Until,
+    }); err != nil {
+        return nil, err
+    }
+
+    return toUserResponse(user), nil
+}
+
+// UnsuspendUser снимает блокировку пользователя
+func (s *userService) UnsuspendUser(ctx context.Context, userID uuid.UUID, meta dto.RequestMeta) (*dto.UserResponse, error) {
+    user, err := s.changeStatus(ctx, userID, models.UserStatusActive, "", nil)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.auditService.Record(ctx, meta, models.AuditUserUnsuspend, nil, &user.ID, nil); err != nil {
+        return nil, err
+    }
+
+    return toUserResponse(user), nil
+}
+
+// changeStatus сохраняет статус пользователя вместе с событием user.updated
+func (s *userService) changeStatus(ctx context.Context, userID uuid.UUID, status, reason string, until *time.Time) (*models.User, error) {
+    user, err := s.userRepo.FindByID(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    user.Status = status
+    user.StatusReason = reason
+    user.StatusUntil = until
+
+    err = s.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+        if err := s.userRepo.WithTx(tx).UpdateStatus(ctx, user.ID, status, reason, until); err != nil {
+            return err
+        }
+
+        event, err := newOutboxEvent(models.EventUserUpdated, user.ID, map[string]interface{}{
+            "user":           toUserResponse(user),
+            "changed_fields": []string{"status"},
+        })
+        if err != nil {
+            return err
+        }
+        return s.outboxRepo.WithTx(tx).Create(ctx, event)
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return user, nil
+}
+
+// toUserResponse преобразует пользователя в DTO
+func toUserResponse(user *models.User) *dto.UserResponse {
+    return &dto.UserResponse{
+        ID:        user.ID.String(),
+        Username:  user.Username,
+        Email:     user.Email,
+        FirstName: user.FirstName,
+        LastName:  user.LastName,
+        Role:      user.Role,
+        Status:    user.Status,
+    }
+}
+
+// restoreUser снимает пометку удаления вместе с записью события user.restored
+func restoreUser(
+    ctx context.Context,
+    txManager repositories.TxManager,
+    userRepo repositories.UserRepository,
+    outboxRepo repositories.OutboxRepository,
+    user *models.User,
+) error {
+    return txManager.Transaction(ctx, func(tx *gorm.DB) error {
+        if err := userRepo.WithTx(tx).Restore(ctx, user.ID); err != nil {
+            return err
+        }
+
+        event, err := newOutboxEvent(models.EventUserRestored, user.ID, &dto.UserResponse{
+            ID:        user.ID.String(),
+            Username:  user.Username,
+            Email:     user.Email,
+            FirstName: user.FirstName,
+            LastName:  user.LastName,
+            Role:      user.Role,
+            Status:    user.Status,
+        })
+        if err != nil {
+            return err
+        }
+        return outboxRepo.WithTx(tx).Create(ctx, event)
+    })
 }
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/logging"
+	"AuthApplications/models"
+
+	"gorm.io/gorm"
+)
+
+// userServiceHarness сервис пользователей с фейковыми зависимостями
+type userServiceHarness struct {
+	service UserService
+	users   *fakeUserRepository
+	outbox  *fakeOutboxRepository
+	audit   *fakeAuditService
+	cfg     *config.Config
+}
+
+func newUserServiceHarness(t *testing.T, users ...*models.User) *userServiceHarness {
+	t.Helper()
+
+	h := &userServiceHarness{
+		users:  newFakeUserRepository(users...),
+		outbox: &fakeOutboxRepository{},
+		audit:  &fakeAuditService{},
+		cfg:    &config.Config{AccountDeletionGracePeriod: 30 * 24 * time.Hour},
+	}
+	h.service = NewUserService(h.users, h.outbox, fakeTxManager{}, h.audit, h.cfg)
+	return h
+}
+
+// eventTypes возвращает типы событий, записанных в outbox
+func (h *userServiceHarness) eventTypes() []string {
+	var types []string
+	for _, event := range h.outbox.events {
+		types = append(types, event.EventType)
+	}
+	return types
+}
+
+func TestDeleteUserSchedulesPurgeAndRestoreCancelsIt(t *testing.T) {
+	user := newLocalUser(t, "alice@example.com", "secret-password")
+	h := newUserServiceHarness(t, user)
+	ctx := context.Background()
+
+	purgeAt, err := h.service.DeleteUser(ctx, user.ID, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if delay := time.Until(*purgeAt); delay < h.cfg.AccountDeletionGracePeriod-time.Minute || delay > h.cfg.AccountDeletionGracePeriod {
+		t.Errorf("окончательное удаление через %v", delay)
+	}
+
+	// Мягко удаленный пользователь не виден, но его данные сохраняются до окончания отсрочки
+	if _, err := h.users.FindByID(ctx, user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("удаленный пользователь найден: %v", err)
+	}
+	deleted, err := h.users.FindDeletedByID(ctx, user.ID)
+	if err != nil || deleted.SessionsRevokedAt == nil {
+		t.Fatalf("удаленный пользователь = %+v, %v", deleted, err)
+	}
+
+	if err := h.service.RestoreUser(ctx, user.ID, dto.RequestMeta{}); err != nil {
+		t.Fatalf("RestoreUser: %v", err)
+	}
+	restored, err := h.users.FindByID(ctx, user.ID)
+	if err != nil || restored.DeletionScheduledFor != nil {
+		t.Fatalf("пользователь не восстановлен: %+v, %v", restored, err)
+	}
+
+	if types := h.eventTypes(); !slices.Equal(types, []string{models.EventUserDeleted, models.EventUserRestored}) {
+		t.Errorf("события outbox = %v", types)
+	}
+	if actions := h.audit.recorded(); !slices.Equal(actions, []string{models.AuditUserDelete, models.AuditUserRestore}) {
+		t.Errorf("аудит = %v", actions)
+	}
+
+	// Восстановить можно только удаленного пользователя
+	if err := h.service.RestoreUser(ctx, user.ID, dto.RequestMeta{}); !errors.Is(err, ErrUserNotDeleted) {
+		t.Fatalf("ожидалась ErrUserNotDeleted, получено %v", err)
+	}
+}
+
+func TestLoginDuringGracePeriodRestoresAccount(t *testing.T) {
+	user := newLocalUser(t, "alice@example.com", "secret-password")
+	h := newAuthHarness(t, user)
+	ctx := context.Background()
+	if err := h.users.ScheduleDeletion(ctx, user.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Неверный пароль удаление не отменяет
+	if _, err := h.service.Login(ctx, dto.LoginRequest{Identifier: "alice@example.com", Password: "wrong"}, dto.RequestMeta{}); err == nil {
+		t.Fatal("вход с неверным паролем выполнен")
+	}
+	if _, err := h.users.FindByID(ctx, user.ID); err == nil {
+		t.Fatal("удаление отменено входом с неверным паролем")
+	}
+
+	response, err := h.service.Login(ctx, dto.LoginRequest{Identifier: "alice@example.com", Password: "secret-password"}, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, _, err := h.service.ValidateToken(ctx, response.Token); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if len(h.outbox.events) != 1 || h.outbox.events[0].EventType != models.EventUserRestored {
+		t.Errorf("события outbox = %+v", h.outbox.events)
+	}
+}
+
+func TestSuspendUserBlocksLoginAndIssuedTokens(t *testing.T) {
+	admin := newLocalUser(t, "admin@example.com", "secret-password")
+	admin.Role = "admin"
+	user := newLocalUser(t, "alice@example.com", "secret-password")
+	auth := newAuthHarness(t, admin, user)
+	// Сервис пользователей работает с тем же хранилищем, что и сервис аутентификации
+	h := newUserServiceHarness(t)
+	h.service = NewUserService(auth.users, h.outbox, fakeTxManager{}, h.audit, h.cfg)
+	ctx := context.Background()
+
+	response, err := auth.service.Login(ctx, dto.LoginRequest{Identifier: "alice@example.com", Password: "secret-password"}, dto.RequestMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.service.SuspendUser(ctx, admin.ID, user.ID, dto.SuspendRequest{Status: models.UserStatusBanned, Reason: "спам"}, dto.RequestMeta{}); err != nil {
+		t.Fatalf("SuspendUser: %v", err)
+	}
+
+	// Блокировка действует и для уже выданного токена, и для нового входа
+	var blocked *AccountBlockedError
+	if _, _, err := auth.service.ValidateToken(ctx, response.Token); !errors.As(err, &blocked) || blocked.Status != models.UserStatusBanned || blocked.Reason != "спам" {
+		t.Fatalf("ожидалась AccountBlockedError, получено %v", err)
+	}
+	if _, err := auth.service.Login(ctx, dto.LoginRequest{Identifier: "alice@example.com", Password: "secret-password"}, dto.RequestMeta{}); !errors.Is(err, ErrAccountBlocked) {
+		t.Fatalf("ожидалась ErrAccountBlocked, получено %v", err)
+	}
+
+	if _, err := h.service.UnsuspendUser(ctx, user.ID, dto.RequestMeta{}); err != nil {
+		t.Fatalf("UnsuspendUser: %v", err)
+	}
+	if _, _, err := auth.service.ValidateToken(ctx, response.Token); err != nil {
+		t.Fatalf("токен не принят после снятия блокировки: %v", err)
+	}
+	if types := h.eventTypes(); !slices.Equal(types, []string{models.EventUserUpdated, models.EventUserUpdated}) {
+		t.Errorf("события outbox = %v", types)
+	}
+}
+
+func TestSuspendUserValidatesRequest(t *testing.T) {
+	admin := newLocalUser(t, "admin@example.com", "secret-password")
+	user := newLocalUser(t, "alice@example.com", "secret-password")
+	h := newUserServiceHarness(t, admin, user)
+	past := time.Now().Add(-time.Hour)
+
+	if _, err := h.service.SuspendUser(context.Background(), admin.ID, admin.ID, dto.SuspendRequest{Reason: "x"}, dto.RequestMeta{}); !errors.Is(err, ErrCannotSuspendSelf) {
+		t.Errorf("ожидалась ErrCannotSuspendSelf, получено %v", err)
+	}
+	if _, err := h.service.SuspendUser(context.Background(), admin.ID, user.ID, dto.SuspendRequest{Reason: "x", Until: &past}, dto.RequestMeta{}); !errors.Is(err, ErrInvalidStatusUntil) {
+		t.Errorf("ожидалась ErrInvalidStatusUntil, получено %v", err)
+	}
+}
+
+func TestTemporarySuspensionExpires(t *testing.T) {
+	user := newLocalUser(t, "alice@example.com", "secret-password")
+	until := time.Now().Add(-time.Second)
+	user.Status = models.UserStatusSuspended
+	user.StatusUntil = &until
+	h := newAuthHarness(t, user)
+	ctx := context.Background()
+
+	// Истекшая блокировка не действует еще до фонового снятия
+	if _, err := h.service.Login(ctx, dto.LoginRequest{Identifier: "alice@example.com", Password: "secret-password"}, dto.RequestMeta{}); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	purger := NewAccountPurger(h.users, nil, fakeTxManager{}, h.audit, logging.Nop(), &config.Config{}).(*accountPurger)
+	if err := purger.liftSuspensions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := h.users.FindByID(ctx, user.ID)
+	if stored.Status != models.UserStatusActive || stored.StatusUntil != nil {
+		t.Errorf("блокировка не снята: %+v", stored)
+	}
+	if actions := h.audit.recorded(); !slices.Contains(actions, models.AuditUserUnsuspend) {
+		t.Errorf("аудит = %v", actions)
+	}
+}
+
+func TestPatchUserWithoutChangesWritesNothing(t *testing.T) {
+	user := newLocalUser(t, "alice@example.com", "secret-password")
+	h := newUserServiceHarness(t, user)
+
+	response, err := h.service.PatchUser(context.Background(), user.ID, dto.PatchUserRequsest{}, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("PatchUser: %v", err)
+	}
+	if response.ID != user.ID.String() {
+		t.Errorf("ответ для пользователя %s", response.ID)
+	}
+	if len(h.outbox.events) != 0 || len(h.users.patched) != 0 || len(h.audit.actions) != 0 {
+		t.Errorf("пустой запрос записал события %v, столбцы %v, аудит %v", h.eventTypes(), h.users.patched, h.audit.actions)
+	}
+}
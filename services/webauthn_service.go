@@ -0,0 +1,541 @@
+// services/webauthn_service.go - регистрация и вход по ключам доступа (WebAuthn / passkeys)
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Ошибки WebAuthn
+var (
+	ErrWebAuthnSession      = errors.New("сессия WebAuthn не найдена или истекла")
+	ErrWebAuthnVerification = errors.New("ключ доступа не прошел проверку")
+	ErrCredentialCloned     = errors.New("счетчик подписей ключа уменьшился, возможно ключ скопирован")
+	ErrCredentialNotFound   = errors.New("ключ доступа не найден")
+	ErrNoCredentials        = errors.New("сначала зарегистрируйте ключ доступа")
+)
+
+// webAuthnSessionLifetime время на завершение церемонии
+const webAuthnSessionLifetime = 5 * time.Minute
+
+// WebAuthnService интерфейс сервиса ключей доступа
+type WebAuthnService interface {
+	BeginRegistration(ctx context.Context, userID uuid.UUID) (*dto.WebAuthnBeginResponse, error)
+	FinishRegistration(ctx context.Context, userID uuid.UUID, sessionID, name string, body io.Reader) (*dto.CredentialResponse, error)
+	BeginLogin(ctx context.Context) (*dto.WebAuthnBeginResponse, error)
+	FinishLogin(ctx context.Context, sessionID string, body io.Reader, meta dto.RequestMeta) (*dto.AuthResponse, error)
+	BeginSecondFactor(ctx context.Context, mfaToken string) (*dto.WebAuthnBeginResponse, error)
+	FinishSecondFactor(ctx context.Context, mfaToken, sessionID string, body io.Reader, meta dto.RequestMeta) (*dto.AuthResponse, error)
+	BeginReauthentication(ctx context.Context, userID uuid.UUID) (*dto.WebAuthnBeginResponse, error)
+	FinishReauthentication(ctx context.Context, claims *JWTClaim, sessionID string, body io.Reader, meta dto.RequestMeta) (string, error)
+	ListCredentials(ctx context.Context, userID uuid.UUID) ([]*dto.CredentialResponse, error)
+	DeleteCredential(ctx context.Context, userID, credentialID uuid.UUID) error
+	SetSecondFactor(ctx context.Context, userID uuid.UUID, enabled bool) error
+}
+
+// webAuthnService реализация WebAuthnService
+type webAuthnService struct {
+	webAuthn       *webauthn.WebAuthn
+	userRepo       repositories.UserRepository
+	credentialRepo repositories.CredentialRepository
+	authService    AuthService
+}
+
+// NewWebAuthnService создает новый сервис ключей доступа
+func NewWebAuthnService(
+	cfg *config.Config,
+	userRepo repositories.UserRepository,
+	credentialRepo repositories.CredentialRepository,
+	authService AuthService,
+) (WebAuthnService, error) {
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: cfg.WebAuthnRPDisplayName,
+		RPOrigins:     cfg.WebAuthnRPOrigins,
+		// Аттестация не запрашивается: модель аутентификатора нам не важна
+		AttestationPreference: protocol.PreferNoAttestation,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: %w", err)
+	}
+
+	return &webAuthnService{
+		webAuthn:       wa,
+		userRepo:       userRepo,
+		credentialRepo: credentialRepo,
+		authService:    authService,
+	}, nil
+}
+
+// BeginRegistration начинает регистрацию нового ключа для пользователя
+func (s *webAuthnService) BeginRegistration(ctx context.Context, userID uuid.UUID) (*dto.WebAuthnBeginResponse, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Исключаем уже зарегистрированные ключи, чтобы не создать дубликат
+	var exclusions []protocol.CredentialDescriptor
+	for _, credential := range user.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveSession(ctx, &userID, models.WebAuthnPurposeRegistration, session, creation)
+}
+
+// FinishRegistration проверяет ответ аутентификатора и сохраняет ключ
+func (s *webAuthnService) FinishRegistration(ctx context.Context, userID uuid.UUID, sessionID, name string, body io.Reader) (*dto.CredentialResponse, error) {
+	session, err := s.takeSession(ctx, sessionID, models.WebAuthnPurposeRegistration, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return nil, ErrWebAuthnVerification
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		return nil, ErrWebAuthnVerification
+	}
+
+	var transports []string
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+	if name == "" {
+		name = "Ключ доступа"
+	}
+
+	model := &models.Credential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Flags:           uint8(credential.Flags.ProtocolValue()),
+		Transports:      strings.Join(transports, ","),
+	}
+	if err := s.credentialRepo.Create(ctx, model); err != nil {
+		return nil, err
+	}
+
+	return toCredentialResponse(model), nil
+}
+
+// BeginLogin начинает вход без пароля по discoverable ключу (passkey)
+func (s *webAuthnService) BeginLogin(ctx context.Context) (*dto.WebAuthnBeginResponse, error) {
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveSession(ctx, nil, models.WebAuthnPurposeLogin, session, assertion)
+}
+
+// FinishLogin проверяет подпись ключа и выдает JWT токен
+func (s *webAuthnService) FinishLogin(ctx context.Context, sessionID string, body io.Reader, meta dto.RequestMeta) (*dto.AuthResponse, error) {
+	session, err := s.takeSession(ctx, sessionID, models.WebAuthnPurposeLogin, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, ErrWebAuthnVerification
+	}
+
+	// Пользователь определяется по user handle, который вернул аутентификатор
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		return s.loadUser(ctx, userID)
+	}
+
+	found, credential, err := s.webAuthn.ValidatePasskeyLogin(handler, *session, parsed)
+	if err != nil {
+		return nil, ErrWebAuthnVerification
+	}
+
+	user := found.(*webAuthnUser)
+	if err := s.recordUsage(ctx, user, credential); err != nil {
+		if errors.Is(err, ErrCredentialCloned) {
+			if recordErr := s.authService.RecordLoginFailure(ctx, user.user, models.LoginMethodPasskey, err, meta); recordErr != nil {
+				return nil, recordErr
+			}
+		}
+		return nil, err
+	}
+
+	return s.authService.CompleteLogin(ctx, user.user, models.LoginMethodPasskey, meta)
+}
+
+// BeginSecondFactor начинает подтверждение входа ключом после проверки пароля
+func (s *webAuthnService) BeginSecondFactor(ctx context.Context, mfaToken string) (*dto.WebAuthnBeginResponse, error) {
+	claims, err := s.authService.ValidateMFAToken(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.credentials) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	assertion, session, err := s.webAuthn.BeginLogin(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveSession(ctx, &claims.UserID, models.WebAuthnPurposeMFA, session, assertion)
+}
+
+// FinishSecondFactor проверяет подпись ключа и выдает полноценный JWT токен
+func (s *webAuthnService) FinishSecondFactor(ctx context.Context, mfaToken, sessionID string, body io.Reader, meta dto.RequestMeta) (*dto.AuthResponse, error) {
+	claims, err := s.authService.ValidateMFAToken(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.takeSession(ctx, sessionID, models.WebAuthnPurposeMFA, &claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, ErrWebAuthnVerification
+	}
+
+	credential, err := s.webAuthn.ValidateLogin(user, *session, parsed)
+	if err != nil {
+		if recordErr := s.authService.RecordLoginFailure(ctx, user.user, models.LoginMethodMFA, ErrWebAuthnVerification, meta); recordErr != nil {
+			return nil, recordErr
+		}
+		return nil, ErrWebAuthnVerification
+	}
+
+	if err := s.recordUsage(ctx, user, credential); err != nil {
+		if errors.Is(err, ErrCredentialCloned) {
+			if recordErr := s.authService.RecordLoginFailure(ctx, user.user, models.LoginMethodMFA, err, meta); recordErr != nil {
+				return nil, recordErr
+			}
+		}
+		return nil, err
+	}
+
+	return s.authService.CompleteLogin(ctx, user.user, models.LoginMethodMFA, meta)
+}
+
+// BeginReauthentication начинает повторную проверку ключом доступа для чувствительных операций
+func (s *webAuthnService) BeginReauthentication(ctx context.Context, userID uuid.UUID) (*dto.WebAuthnBeginResponse, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.credentials) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	assertion, session, err := s.webAuthn.BeginLogin(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveSession(ctx, &userID, models.WebAuthnPurposeReauth, session, assertion)
+}
+
+// FinishReauthentication проверяет подпись ключа и выпускает токен с обновленным auth_time
+func (s *webAuthnService) FinishReauthentication(ctx context.Context, claims *JWTClaim, sessionID string, body io.Reader, meta dto.RequestMeta) (string, error) {
+	session, err := s.takeSession(ctx, sessionID, models.WebAuthnPurposeReauth, &claims.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.loadUser(ctx, claims.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return "", ErrWebAuthnVerification
+	}
+
+	credential, err := s.webAuthn.ValidateLogin(user, *session, parsed)
+	if err != nil {
+		if recordErr := s.authService.RecordLoginFailure(ctx, user.user, models.LoginMethodStepUpPasskey, ErrWebAuthnVerification, meta); recordErr != nil {
+			return "", recordErr
+		}
+		return "", ErrWebAuthnVerification
+	}
+
+	if err := s.recordUsage(ctx, user, credential); err != nil {
+		return "", err
+	}
+
+	return s.authService.ElevateToken(ctx, claims, []string{"hwk"}, meta)
+}
+
+// ListCredentials возвращает ключи доступа пользователя
+func (s *webAuthnService) ListCredentials(ctx context.Context, userID uuid.UUID) ([]*dto.CredentialResponse, error) {
+	credentials, err := s.credentialRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []*dto.CredentialResponse{}
+	for i := range credentials {
+		responses = append(responses, toCredentialResponse(&credentials[i]))
+	}
+	return responses, nil
+}
+
+// DeleteCredential удаляет ключ; после удаления последнего ключа второй фактор отключается
+func (s *webAuthnService) DeleteCredential(ctx context.Context, userID, credentialID uuid.UUID) error {
+	deleted, err := s.credentialRepo.Delete(ctx, userID, credentialID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrCredentialNotFound
+	}
+
+	count, err := s.credentialRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return s.SetSecondFactor(ctx, userID, false)
+	}
+	return nil
+}
+
+// SetSecondFactor включает или выключает обязательное подтверждение входа ключом
+func (s *webAuthnService) SetSecondFactor(ctx context.Context, userID uuid.UUID, enabled bool) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		count, err := s.credentialRepo.CountByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNoCredentials
+		}
+	}
+
+	user.MFAEnabled = enabled
+	return s.userRepo.PatchUser(ctx, user, "mfa_enabled")
+}
+
+// saveSession сохраняет данные церемонии и возвращает клиенту ее идентификатор и параметры
+func (s *webAuthnService) saveSession(ctx context.Context, userID *uuid.UUID, purpose string, session *webauthn.SessionData, options interface{}) (*dto.WebAuthnBeginResponse, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &models.WebAuthnSession{
+		UserID:    userID,
+		Purpose:   purpose,
+		Data:      string(data),
+		ExpiresAt: time.Now().Add(webAuthnSessionLifetime),
+	}
+	if err := s.credentialRepo.CreateSession(ctx, model); err != nil {
+		return nil, err
+	}
+
+	return &dto.WebAuthnBeginResponse{
+		SessionID: model.ID.String(),
+		Options:   options,
+	}, nil
+}
+
+// takeSession извлекает одноразовую сессию церемонии и проверяет ее владельца
+func (s *webAuthnService) takeSession(ctx context.Context, sessionID, purpose string, userID *uuid.UUID) (*webauthn.SessionData, error) {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return nil, ErrWebAuthnSession
+	}
+
+	model, err := s.credentialRepo.TakeSession(ctx, id, purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebAuthnSession
+		}
+		return nil, err
+	}
+
+	if userID != nil && (model.UserID == nil || *model.UserID != *userID) {
+		return nil, ErrWebAuthnSession
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(model.Data), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// recordUsage проверяет счетчик подписей и сохраняет его новое значение
+func (s *webAuthnService) recordUsage(ctx context.Context, user *webAuthnUser, credential *webauthn.Credential) error {
+	if credential.Authenticator.CloneWarning {
+		return ErrCredentialCloned
+	}
+
+	model := user.findCredential(credential.ID)
+	if model == nil {
+		return ErrCredentialNotFound
+	}
+
+	now := time.Now()
+	model.SignCount = credential.Authenticator.SignCount
+	model.Flags = uint8(credential.Flags.ProtocolValue())
+	model.LastUsedAt = &now
+	return s.credentialRepo.UpdateUsage(ctx, model)
+}
+
+// loadUser загружает пользователя вместе с его ключами
+func (s *webAuthnService) loadUser(ctx context.Context, userID uuid.UUID) (*webAuthnUser, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.credentialRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webAuthnUser{user: user, stored: credentials, credentials: toWebAuthnCredentials(credentials)}, nil
+}
+
+// webAuthnUser адаптирует models.User к интерфейсу webauthn.User
+type webAuthnUser struct {
+	user        *models.User
+	stored      []models.Credential
+	credentials []webauthn.Credential
+}
+
+// WebAuthnID возвращает user handle — байты UUID пользователя
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+// WebAuthnName возвращает имя учетной записи для аутентификатора
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+// WebAuthnDisplayName возвращает отображаемое имя пользователя
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if name := strings.TrimSpace(u.user.FirstName + " " + u.user.LastName); name != "" {
+		return name
+	}
+	return u.user.Email
+}
+
+// WebAuthnCredentials возвращает ключи пользователя
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// findCredential находит сохраненный ключ по идентификатору аутентификатора
+func (u *webAuthnUser) findCredential(credentialID []byte) *models.Credential {
+	for i := range u.stored {
+		if string(u.stored[i].CredentialID) == string(credentialID) {
+			return &u.stored[i]
+		}
+	}
+	return nil
+}
+
+// toWebAuthnCredentials преобразует сохраненные ключи в формат библиотеки
+func toWebAuthnCredentials(credentials []models.Credential) []webauthn.Credential {
+	var result []webauthn.Credential
+	for _, credential := range credentials {
+		var transports []protocol.AuthenticatorTransport
+		for _, transport := range strings.Split(credential.Transports, ",") {
+			if transport != "" {
+				transports = append(transports, protocol.AuthenticatorTransport(transport))
+			}
+		}
+
+		result = append(result, webauthn.Credential{
+			ID:              credential.CredentialID,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			Transport:       transports,
+			Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(credential.Flags)),
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    credential.AAGUID,
+				SignCount: credential.SignCount,
+			},
+		})
+	}
+	return result
+}
+
+// toCredentialResponse преобразует ключ в DTO
+func toCredentialResponse(credential *models.Credential) *dto.CredentialResponse {
+	transports := []string{}
+	for _, transport := range strings.Split(credential.Transports, ",") {
+		if transport != "" {
+			transports = append(transports, transport)
+		}
+	}
+
+	return &dto.CredentialResponse{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		Transports: transports,
+		CreatedAt:  credential.CreatedAt,
+		LastUsedAt: credential.LastUsedAt,
+	}
+}
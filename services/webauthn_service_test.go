@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"slices"
+	"testing"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+)
+
+const (
+	testRPID   = "auth.example.com"
+	testOrigin = "https://auth.example.com"
+)
+
+// webAuthnHarness сервис ключей доступа поверх фейкового сервиса аутентификации
+type webAuthnHarness struct {
+	*authHarness
+	credentials *fakeCredentialRepository
+	service     WebAuthnService
+}
+
+func newWebAuthnHarness(t *testing.T, users ...*models.User) *webAuthnHarness {
+	t.Helper()
+
+	auth := newAuthHarness(t, users...)
+	auth.cfg.WebAuthnRPID = testRPID
+	auth.cfg.WebAuthnRPDisplayName = "Auth"
+	auth.cfg.WebAuthnRPOrigins = []string{testOrigin}
+
+	credentials := newFakeCredentialRepository()
+	service, err := NewWebAuthnService(auth.cfg, auth.users, credentials, auth.service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &webAuthnHarness{authHarness: auth, credentials: credentials, service: service}
+}
+
+// register регистрирует программный ключ пользователя и включает второй фактор
+func (h *webAuthnHarness) register(t *testing.T, user *models.User) *softwareAuthenticator {
+	t.Helper()
+	ctx := context.Background()
+
+	authenticator := newSoftwareAuthenticator(t, testRPID, testOrigin)
+	begin, err := h.service.BeginRegistration(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	if _, err := h.service.FinishRegistration(ctx, user.ID, begin.SessionID, "Тестовый ключ", authenticator.create(begin.Options)); err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+	if err := h.service.SetSecondFactor(ctx, user.ID, true); err != nil {
+		t.Fatalf("SetSecondFactor: %v", err)
+	}
+	return authenticator
+}
+
+// loginWithPassword выполняет вход по паролю и возвращает токен подтверждения входа
+func (h *webAuthnHarness) loginWithPassword(t *testing.T, email, password string) string {
+	t.Helper()
+
+	response, err := h.authHarness.service.Login(context.Background(), dto.LoginRequest{
+		Identifier: email,
+		Password:   password,
+	}, dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if !response.SecondFactorRequired {
+		t.Fatal("после включения второго фактора вход по паролю выдал полноценный токен")
+	}
+	return response.Token
+}
+
+func TestWebAuthnSecondFactorCompletesPasswordLogin(t *testing.T) {
+	user := newLocalUser(t, "mfa@example.com", "secret-password")
+	h := newWebAuthnHarness(t, user)
+	authenticator := h.register(t, user)
+	ctx := context.Background()
+
+	mfaToken := h.loginWithPassword(t, "mfa@example.com", "secret-password")
+
+	begin, err := h.service.BeginSecondFactor(ctx, mfaToken)
+	if err != nil {
+		t.Fatalf("BeginSecondFactor: %v", err)
+	}
+	response, err := h.service.FinishSecondFactor(ctx, mfaToken, begin.SessionID, authenticator.get(begin.Options), dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("FinishSecondFactor: %v", err)
+	}
+	if response.SecondFactorRequired {
+		t.Fatal("после подтверждения ключом снова запрошен второй фактор")
+	}
+
+	_, claims, err := h.authHarness.service.ValidateToken(ctx, response.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if !slices.Equal(claims.AMR, []string{"pwd", "hwk", "mfa"}) {
+		t.Errorf("amr = %v", claims.AMR)
+	}
+
+	// Включение второго фактора записывает только mfa_enabled
+	if len(h.users.patched) != 1 || !slices.Equal(h.users.patched[0], []string{"mfa_enabled"}) {
+		t.Errorf("PatchUser вызван со столбцами %v", h.users.patched)
+	}
+
+	stored, _ := h.credentials.FindByUserID(context.Background(), user.ID)
+	if len(stored) != 1 || stored[0].SignCount != 1 || stored[0].LastUsedAt == nil {
+		t.Errorf("использование ключа не сохранено: %+v", stored)
+	}
+}
+
+func TestWebAuthnSecondFactorRejectsForeignKey(t *testing.T) {
+	user := newLocalUser(t, "mfa@example.com", "secret-password")
+	h := newWebAuthnHarness(t, user)
+	authenticator := h.register(t, user)
+	ctx := context.Background()
+
+	mfaToken := h.loginWithPassword(t, "mfa@example.com", "secret-password")
+	begin, err := h.service.BeginSecondFactor(ctx, mfaToken)
+	if err != nil {
+		t.Fatalf("BeginSecondFactor: %v", err)
+	}
+
+	foreignKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = h.service.FinishSecondFactor(ctx, mfaToken, begin.SessionID, authenticator.getSignedBy(begin.Options, foreignKey), dto.RequestMeta{})
+	if !errors.Is(err, ErrWebAuthnVerification) {
+		t.Fatalf("ожидалась ErrWebAuthnVerification, получено %v", err)
+	}
+	if !slices.Equal(h.loginHistory.failures, []string{models.LoginMethodMFA}) {
+		t.Errorf("неудачные входы = %v", h.loginHistory.failures)
+	}
+}
+
+func TestWebAuthnSecondFactorRequiresMFAToken(t *testing.T) {
+	user := newLocalUser(t, "mfa@example.com", "secret-password")
+	h := newWebAuthnHarness(t, user)
+	h.register(t, user)
+
+	// Полноценный токен доступа не заменяет токен подтверждения входа
+	response, err := h.authHarness.service.CompleteLogin(context.Background(), user, models.LoginMethodPasskey, dto.RequestMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.service.BeginSecondFactor(context.Background(), response.Token); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Fatalf("ожидалась ErrInvalidMFAToken, получено %v", err)
+	}
+}
+
+func TestWebAuthnPasskeyLoginSkipsSecondFactor(t *testing.T) {
+	user := newLocalUser(t, "mfa@example.com", "secret-password")
+	h := newWebAuthnHarness(t, user)
+	authenticator := h.register(t, user)
+	ctx := context.Background()
+
+	begin, err := h.service.BeginLogin(ctx)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	response, err := h.service.FinishLogin(ctx, begin.SessionID, authenticator.get(begin.Options), dto.RequestMeta{})
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+	if response.SecondFactorRequired {
+		t.Fatal("вход ключом доступа не должен требовать второго фактора")
+	}
+
+	_, claims, err := h.authHarness.service.ValidateToken(ctx, response.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.UserID != user.ID || !slices.Equal(claims.AMR, []string{"hwk", "user"}) {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	// Сессия церемонии одноразовая
+	if _, err := h.service.FinishLogin(ctx, begin.SessionID, authenticator.get(begin.Options), dto.RequestMeta{}); !errors.Is(err, ErrWebAuthnSession) {
+		t.Fatalf("повторное использование сессии: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+// services/webhook_dispatcher.go - фоновая доставка событий из outbox на webhook
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Параметры повторных попыток доставки
+const (
+	webhookRetryBaseDelay = 30 * time.Second
+	webhookRetryMaxDelay  = 6 * time.Hour
+)
+
+// WebhookDispatcher интерфейс фонового обработчика outbox
+type WebhookDispatcher interface {
+	Run(ctx context.Context)
+}
+
+// webhookDispatcher реализация WebhookDispatcher
+type webhookDispatcher struct {
+	outboxRepo  repositories.OutboxRepository
+	webhookRepo repositories.WebhookRepository
+	txManager   repositories.TxManager
+	client      *http.Client
+	logger      *slog.Logger
+	cfg         *config.Config
+}
+
+// NewWebhookDispatcher создает обработчик, который раскладывает события outbox
+// по подписанным webhook и доставляет их с повторными попытками
+func NewWebhookDispatcher(
+	outboxRepo repositories.OutboxRepository,
+	webhookRepo repositories.WebhookRepository,
+	txManager repositories.TxManager,
+	logger *slog.Logger,
+	cfg *config.Config,
+) WebhookDispatcher {
+	return &webhookDispatcher{
+		outboxRepo:  outboxRepo,
+		webhookRepo: webhookRepo,
+		txManager:   txManager,
+		client: &http.Client{
+			Timeout: cfg.WebhookTimeout,
+			// Перенаправления не выполняются: ответ 3xx считается неудачной доставкой
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger.With("worker", "webhooks"),
+		cfg:    cfg,
+	}
+}
+
+// Run обрабатывает outbox с интервалом WEBHOOK_POLL_INTERVAL до отмены контекста
+func (d *webhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.WebhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.fanOut(ctx); err != nil {
+			d.logger.ErrorContext(ctx, "Ошибка обработки outbox", "error", err)
+		}
+		if err := d.deliverDue(ctx); err != nil {
+			d.logger.ErrorContext(ctx, "Ошибка доставки webhook", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fanOut создает доставки новых событий для каждого подписанного webhook
+// и отмечает события обработанными в одной транзакции
+func (d *webhookDispatcher) fanOut(ctx context.Context) error {
+	return d.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		outbox := d.outboxRepo.WithTx(tx)
+		webhooks := d.webhookRepo.WithTx(tx)
+
+		events, err := outbox.LockUnprocessed(ctx, d.cfg.WebhookBatchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		active, err := webhooks.FindActive(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var deliveries []models.WebhookDelivery
+		ids := make([]uuid.UUID, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+			for _, webhook := range active {
+				if !webhook.Subscribes(event.EventType) {
+					continue
+				}
+				deliveries = append(deliveries, models.WebhookDelivery{
+					WebhookID:     webhook.ID,
+					EventID:       event.ID,
+					Status:        models.DeliveryPending,
+					NextAttemptAt: now,
+				})
+			}
+		}
+
+		if err := webhooks.CreateDeliveries(ctx, deliveries); err != nil {
+			return err
+		}
+		return outbox.MarkProcessed(ctx, ids)
+	})
+}
+
+// deliverDue отправляет доставки, время попытки которых наступило
+func (d *webhookDispatcher) deliverDue(ctx context.Context) error {
+	lease := d.cfg.WebhookTimeout + time.Minute
+	deliveries, err := d.webhookRepo.ClaimDueDeliveries(ctx, d.cfg.WebhookBatchSize, lease)
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			// Захваченные доставки будут повторены после истечения lease
+			return nil
+		}
+
+		delivery := &deliveries[i]
+		d.attempt(ctx, delivery)
+		if ctx.Err() != nil {
+			// Прерванная остановкой попытка не засчитывается
+			return nil
+		}
+		if err := d.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attempt выполняет одну попытку доставки и обновляет ее состояние
+func (d *webhookDispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+
+	statusCode, err := d.send(ctx, delivery)
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		now := time.Now()
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.cfg.WebhookMaxAttempts {
+		delivery.Status = models.DeliveryDead
+		return
+	}
+	delivery.NextAttemptAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+}
+
+// send отправляет событие с подписью HMAC-SHA256.
+// Подписывается строка "<timestamp>.<тело>" секретом webhook;
+// получатель должен сверить подпись и отклонять запросы со старой меткой времени.
+func (d *webhookDispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Event.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(delivery.Webhook.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AuthApplications-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", delivery.Event.EventType)
+	req.Header.Set("X-Webhook-Event-ID", delivery.EventID.String())
+	req.Header.Set("X-Webhook-Delivery-ID", delivery.ID.String())
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("получатель ответил %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff вычисляет задержку перед следующей попыткой: 30с, 1м, 2м, ... до 6ч
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookRetryBaseDelay
+	for i := 1; i < attempts && delay < webhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMaxDelay {
+		delay = webhookRetryMaxDelay
+	}
+	return delay
+}
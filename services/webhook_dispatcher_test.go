@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"AuthApplications/config"
+	"AuthApplications/logging"
+	"AuthApplications/models"
+
+	"github.com/google/uuid"
+)
+
+// newTestDispatcher создает обработчик без репозиториев: тестам нужна только отправка
+func newTestDispatcher() *webhookDispatcher {
+	cfg := &config.Config{WebhookTimeout: 5 * time.Second, WebhookMaxAttempts: 3}
+	return NewWebhookDispatcher(nil, nil, nil, logging.Nop(), cfg).(*webhookDispatcher)
+}
+
+// newTestDelivery создает доставку события user.registered на адрес url
+func newTestDelivery(t *testing.T, url string) *models.WebhookDelivery {
+	t.Helper()
+
+	event, err := newOutboxEvent(models.EventUserRegistered, uuid.New(), map[string]string{"email": "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.WebhookDelivery{
+		ID:      uuid.New(),
+		EventID: event.ID,
+		Status:  models.DeliveryPending,
+		Webhook: models.Webhook{URL: url, Secret: "webhook-secret"},
+		Event:   *event,
+	}
+}
+
+func TestWebhookDispatcherSignsDelivery(t *testing.T) {
+	var (
+		header http.Header
+		body   []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	delivery := newTestDelivery(t, server.URL)
+	newTestDispatcher().attempt(context.Background(), delivery)
+
+	if delivery.Status != models.DeliveryDelivered || delivery.DeliveredAt == nil || delivery.LastStatusCode != http.StatusNoContent {
+		t.Fatalf("доставка = %+v", delivery)
+	}
+	if string(body) != delivery.Event.Payload {
+		t.Errorf("тело = %s", body)
+	}
+
+	// Получатель проверяет подпись строки "<timestamp>.<тело>" общим секретом
+	timestamp := header.Get("X-Webhook-Timestamp")
+	if unix, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(unix, 0)) > time.Minute {
+		t.Errorf("X-Webhook-Timestamp = %q", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte("webhook-secret"))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if signature := header.Get("X-Webhook-Signature"); signature != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("X-Webhook-Signature = %q", signature)
+	}
+	if header.Get("X-Webhook-Event") != models.EventUserRegistered || header.Get("X-Webhook-Event-ID") != delivery.EventID.String() {
+		t.Errorf("заголовки события: %v", header)
+	}
+}
+
+func TestWebhookDispatcherRetriesWithBackoffAndGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dispatcher := newTestDispatcher()
+	delivery := newTestDelivery(t, server.URL)
+
+	for attempt := 1; attempt < dispatcher.cfg.WebhookMaxAttempts; attempt++ {
+		started := time.Now()
+		dispatcher.attempt(context.Background(), delivery)
+
+		if delivery.Status != models.DeliveryPending || delivery.Attempts != attempt {
+			t.Fatalf("попытка %d: %+v", attempt, delivery)
+		}
+		if delivery.LastStatusCode != http.StatusServiceUnavailable || delivery.LastError == "" {
+			t.Errorf("попытка %d: код %d, ошибка %q", attempt, delivery.LastStatusCode, delivery.LastError)
+		}
+		if delay := delivery.NextAttemptAt.Sub(started); delay < webhookBackoff(attempt) {
+			t.Errorf("попытка %d: следующая через %v", attempt, delay)
+		}
+	}
+
+	dispatcher.attempt(context.Background(), delivery)
+	if delivery.Status != models.DeliveryDead {
+		t.Fatalf("после WEBHOOK_MAX_ATTEMPTS статус %q", delivery.Status)
+	}
+}
+
+func TestWebhookDispatcherDoesNotFollowRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("перенаправление выполнено")
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	delivery := newTestDelivery(t, server.URL)
+	newTestDispatcher().attempt(context.Background(), delivery)
+
+	if delivery.Status == models.DeliveryDelivered || delivery.LastStatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("доставка = %+v", delivery)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 5, want: 8 * time.Minute},
+		{attempts: 20, want: 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, ожидалось %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookDispatcherFansOutToSubscribedWebhooks(t *testing.T) {
+	allEvents := models.Webhook{ID: uuid.New(), Active: true}
+	booksOnly := models.Webhook{ID: uuid.New(), Active: true, EventTypes: models.EventBookCreated + ", " + models.EventBookUpdated}
+	disabled := models.Webhook{ID: uuid.New(), Active: false}
+	webhooks := &fakeWebhookRepository{webhooks: []models.Webhook{allEvents, booksOnly, disabled}}
+
+	outbox := &fakeOutboxRepository{}
+	for _, eventType := range []string{models.EventUserRegistered, models.EventBookCreated} {
+		event, err := newOutboxEvent(eventType, uuid.New(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outbox.Create(context.Background(), event)
+	}
+
+	cfg := &config.Config{WebhookBatchSize: 10}
+	dispatcher := NewWebhookDispatcher(outbox, webhooks, fakeTxManager{}, logging.Nop(), cfg).(*webhookDispatcher)
+	if err := dispatcher.fanOut(context.Background()); err != nil {
+		t.Fatalf("fanOut: %v", err)
+	}
+
+	got := map[uuid.UUID]int{}
+	for _, delivery := range webhooks.deliveries {
+		if delivery.Status != models.DeliveryPending {
+			t.Errorf("статус новой доставки %q", delivery.Status)
+		}
+		got[delivery.WebhookID]++
+	}
+	if got[allEvents.ID] != 2 || got[booksOnly.ID] != 1 || got[disabled.ID] != 0 {
+		t.Errorf("доставки по webhook: %v", got)
+	}
+	if len(outbox.processed) != 2 {
+		t.Errorf("обработано событий: %d", len(outbox.processed))
+	}
+
+	// Повторный проход не создает доставок для уже разосланных событий
+	if err := dispatcher.fanOut(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(webhooks.deliveries) != 3 {
+		t.Errorf("доставок после повторного прохода: %d", len(webhooks.deliveries))
+	}
+}
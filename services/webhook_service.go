@@ -0,0 +1,174 @@
+// services/webhook_service.go - управление webhook и очередью доставок
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"AuthApplications/dto"
+	"AuthApplications/models"
+	"AuthApplications/repositories"
+
+	"github.com/google/uuid"
+)
+
+// Ошибки управления webhook
+var (
+	ErrWebhookNotFound  = errors.New("webhook не найден")
+	ErrDeliveryNotFound = errors.New("недоставленное событие не найдено")
+	ErrInvalidWebhook   = errors.New("некорректные параметры webhook")
+)
+
+// WebhookService интерфейс сервиса webhook
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, req dto.WebhookRequest) (*dto.WebhookResponse, error)
+	ListWebhooks(ctx context.Context) ([]dto.WebhookResponse, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, filter dto.WebhookDeliveryFilter) (*dto.WebhookDeliveryListResponse, error)
+	RetryDelivery(ctx context.Context, id uuid.UUID) error
+}
+
+// webhookService реализация WebhookService
+type webhookService struct {
+	webhookRepo repositories.WebhookRepository
+}
+
+// NewWebhookService создает новый сервис webhook
+func NewWebhookService(webhookRepo repositories.WebhookRepository) WebhookService {
+	return &webhookService{webhookRepo: webhookRepo}
+}
+
+// CreateWebhook регистрирует webhook и генерирует секрет для подписи запросов
+func (s *webhookService) CreateWebhook(ctx context.Context, req dto.WebhookRequest) (*dto.WebhookResponse, error) {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: адрес должен быть абсолютным http(s) URL", ErrInvalidWebhook)
+	}
+
+	for _, eventType := range req.EventTypes {
+		if !isKnownEventType(eventType) {
+			return nil, fmt.Errorf("%w: неизвестный тип события %q", ErrInvalidWebhook, eventType)
+		}
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &models.Webhook{
+		URL:         req.URL,
+		Secret:      secret,
+		EventTypes:  strings.Join(req.EventTypes, ","),
+		Description: req.Description,
+		Active:      true,
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	response := toWebhookResponse(webhook)
+	response.Secret = secret
+	return &response, nil
+}
+
+// ListWebhooks возвращает все webhook без секретов
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]dto.WebhookResponse, error) {
+	webhooks, err := s.webhookRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		responses = append(responses, toWebhookResponse(&webhooks[i]))
+	}
+	return responses, nil
+}
+
+// DeleteWebhook удаляет webhook вместе с очередью его доставок
+func (s *webhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	deleted, err := s.webhookRepo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListDeliveries возвращает страницу доставок; по умолчанию — недоставленные (dead letter)
+func (s *webhookService) ListDeliveries(ctx context.Context, filter dto.WebhookDeliveryFilter) (*dto.WebhookDeliveryListResponse, error) {
+	deliveries, total, err := s.webhookRepo.FindDeliveries(ctx, filter.Status, filter.WebhookID, filter.Page, filter.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		items = append(items, dto.WebhookDeliveryResponse{
+			ID:             delivery.ID,
+			WebhookID:      delivery.WebhookID,
+			EventID:        delivery.EventID,
+			EventType:      delivery.Event.EventType,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			LastStatusCode: delivery.LastStatusCode,
+			LastError:      delivery.LastError,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			DeliveredAt:    delivery.DeliveredAt,
+			CreatedAt:      delivery.CreatedAt,
+			UpdatedAt:      delivery.UpdatedAt,
+		})
+	}
+
+	return &dto.WebhookDeliveryListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}, nil
+}
+
+// RetryDelivery возвращает недоставленное событие в очередь
+func (s *webhookService) RetryDelivery(ctx context.Context, id uuid.UUID) error {
+	requeued, err := s.webhookRepo.RequeueDelivery(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !requeued {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// toWebhookResponse преобразует webhook в DTO без секрета
+func toWebhookResponse(webhook *models.Webhook) dto.WebhookResponse {
+	eventTypes := []string{}
+	if webhook.EventTypes != "" {
+		eventTypes = strings.Split(webhook.EventTypes, ",")
+	}
+
+	return dto.WebhookResponse{
+		ID:          webhook.ID,
+		URL:         webhook.URL,
+		EventTypes:  eventTypes,
+		Description: webhook.Description,
+		Active:      webhook.Active,
+		CreatedAt:   webhook.CreatedAt,
+	}
+}
+
+// isKnownEventType проверяет, что тип события существует
+func isKnownEventType(eventType string) bool {
+	for _, known := range models.EventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+// tracing/gorm.go - спаны запросов GORM через callbacks
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// querySpanKey ключ спана запроса в экземпляре gorm.DB
+const querySpanKey = "tracing:query_span"
+
+// gormPlugin плагин GORM, создающий спан на каждый запрос.
+// Родитель спана берется из контекста запроса (db.WithContext).
+type gormPlugin struct{}
+
+// InstrumentDB подключает спаны запросов GORM
+func InstrumentDB(db *gorm.DB) error {
+	return db.Use(&gormPlugin{})
+}
+
+// Name возвращает имя плагина
+func (p *gormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize регистрирует callbacks до и после каждой операции GORM
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+
+	for _, processor := range processors {
+		if err := processor.before("tracing:before_"+processor.operation, startSpan(processor.operation)); err != nil {
+			return err
+		}
+		if err := processor.after("tracing:after_"+processor.operation, finishSpan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startSpan начинает спан запроса
+func startSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		attrs := []attribute.KeyValue{
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+		}
+		if db.Statement.Table != "" {
+			attrs = append(attrs, semconv.DBCollectionName(db.Statement.Table))
+		}
+
+		_, span := Tracer().Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+		)
+		db.InstanceSet(querySpanKey, span)
+	}
+}
+
+// finishSpan завершает спан запроса. В спан попадает SQL с плейсхолдерами, без значений параметров.
+// Отсутствие записи ошибкой запроса не считается.
+func finishSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(querySpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if sql := db.Statement.SQL.String(); sql != "" {
+		span.SetAttributes(semconv.DBQueryText(sql))
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", db.RowsAffected))
+
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,166 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"AuthApplications/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordSpans устанавливает глобальный провайдер, записывающий завершенные спаны
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+// newTracedMockDB создает GORM поверх sqlmock с подключенными спанами запросов
+func newTracedMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := InstrumentDB(db); err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+type tracedUser struct {
+	ID    int
+	Email string
+}
+
+func TestGormQuerySpanIsChildOfRequestSpan(t *testing.T) {
+	recorder := recordSpans(t)
+	db, mock := newTracedMockDB(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "traced_users" WHERE email = \$1`).
+		WithArgs("alice@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "alice@example.com"))
+
+	ctx, parent := Start(context.Background(), "UserService.GetUser")
+	var users []tracedUser
+	if err := db.WithContext(ctx).Where("email = ?", "alice@example.com").Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "gorm.query" {
+		t.Fatalf("спаны: %v", spans)
+	}
+	query := spans[0]
+	if query.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("спан запроса не привязан к спану сервиса")
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range query.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs[string(semconv.DBCollectionNameKey)] != "traced_users" || attrs[string(semconv.DBOperationNameKey)] != "query" {
+		t.Errorf("атрибуты спана: %v", attrs)
+	}
+	// В спан попадает SQL с плейсхолдерами, значения параметров не записываются
+	text := attrs[string(semconv.DBQueryTextKey)]
+	if !strings.Contains(text, "email = $1") || strings.Contains(text, "alice@example.com") {
+		t.Errorf("db.query.text = %q", text)
+	}
+}
+
+func TestGormSpanStatus(t *testing.T) {
+	recorder := recordSpans(t)
+	db, mock := newTracedMockDB(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "traced_users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "traced_users"`).WillReturnError(errors.New("connection reset"))
+
+	var user tracedUser
+	if err := db.First(&user).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("ожидалась ErrRecordNotFound, получено %v", err)
+	}
+	if err := db.First(&user).Error; err == nil {
+		t.Fatal("ошибка запроса потеряна")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("спаны: %v", spans)
+	}
+	if spans[0].Status().Code == codes.Error {
+		t.Error("отсутствие записи отмечено ошибкой")
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Error("ошибка запроса не отмечена в спане")
+	}
+}
+
+func TestEndRecordsError(t *testing.T) {
+	recorder := recordSpans(t)
+
+	_, span := Start(context.Background(), "AuthService.Login")
+	End(span, errors.New("неверные учетные данные"))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Status().Code != codes.Error || len(spans[0].Events()) != 1 {
+		t.Fatalf("спан с ошибкой: %+v", spans)
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	recordSpans(t)
+
+	if id := TraceID(context.Background()); id != "" {
+		t.Errorf("идентификатор без трассировки = %q", id)
+	}
+	ctx, span := Start(context.Background(), "request")
+	defer span.End()
+	if id := TraceID(ctx); id != span.SpanContext().TraceID().String() {
+		t.Errorf("TraceID = %q", id)
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), &config.Config{TracingExporter: "jaeger"}); err == nil {
+		t.Fatal("принят неизвестный экспортер")
+	}
+
+	shutdown, err := Setup(context.Background(), &config.Config{TracingExporter: "none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}